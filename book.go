@@ -42,8 +42,14 @@ type book struct {
 	grpcRunners          map[string]*grpcRunner
 	cdpRunners           map[string]*cdpRunner
 	sshRunners           map[string]*sshRunner
+	wsRunners            map[string]*wsRunner
+	kafkaRunners         map[string]*kafkaRunner
+	redisRunners         map[string]*redisRunner
+	smtpRunners          map[string]*smtpRunner
+	mockRunners          map[string]*mockRunner
 	includeRunners       map[string]*includeRunner
 	profile              bool
+	loadtCounters        map[string]string
 	intervalStr          string
 	interval             time.Duration
 	loop                 *Loop
@@ -80,6 +86,7 @@ type book struct {
 	beforeFuncs          []func(*RunResult) error
 	afterFuncs           []func(*RunResult) error
 	capturers            capturers
+	fixtures             *fixtureStore
 	stdout               io.Writer
 	stderr               io.Writer
 	// Skip some errors for `runn list`
@@ -131,6 +138,14 @@ func (bk *book) parseRunners(store map[string]any) error {
 	// parse SSH Runners first for port forwarding
 	var notSSHRunners []string
 	if store != nil {
+		// Routes of mock runners are expanded for each received request, so not here.
+		routes := map[string]any{}
+		for k, v := range bk.runners {
+			if mc, ok := mockRunnerConfigMap(v); ok {
+				routes[k] = mc["routes"]
+				delete(mc, "routes")
+			}
+		}
 		r, err := expr.EvalExpand(bk.runners, store)
 		if err != nil {
 			return err
@@ -140,6 +155,11 @@ func (bk *book) parseRunners(store map[string]any) error {
 		if !ok {
 			return fmt.Errorf("failed to cast: %v", r)
 		}
+		for k, rs := range routes {
+			if mc, ok := mockRunnerConfigMap(bk.runners[k]); ok && rs != nil {
+				mc["routes"] = rs
+			}
+		}
 	}
 	for k, v := range bk.runners {
 		if detectSSHRunner(v) {
@@ -218,6 +238,30 @@ func (bk *book) parseRunner(k string, v any) error {
 				return err
 			}
 			bk.sshRunners[k] = sc
+		case strings.HasPrefix(vv, "ws://") || strings.HasPrefix(vv, "wss://"):
+			wc, err := newWSRunner(k, vv)
+			if err != nil {
+				return err
+			}
+			bk.wsRunners[k] = wc
+		case strings.HasPrefix(vv, "kafka://"):
+			kc, err := newKafkaRunner(k, vv)
+			if err != nil {
+				return err
+			}
+			bk.kafkaRunners[k] = kc
+		case strings.HasPrefix(vv, "redis://") || strings.HasPrefix(vv, "rediss://"):
+			rc, err := newRedisRunner(k, vv)
+			if err != nil {
+				return err
+			}
+			bk.redisRunners[k] = rc
+		case strings.HasPrefix(vv, "smtp://"):
+			sc, err := newSMTPRunner(k, vv)
+			if err != nil {
+				return err
+			}
+			bk.smtpRunners[k] = sc
 		default:
 			dc, err := newDBRunner(k, vv)
 			if err != nil {
@@ -238,6 +282,14 @@ func (bk *book) parseRunner(k string, v any) error {
 			return err
 		}
 
+		// Mock Runner
+		if !detect {
+			detect, err = bk.parseMockRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
 		// gRPC Runner
 		if !detect {
 			detect, err = bk.parseGRPCRunnerWithDetailed(k, tmp)
@@ -306,6 +358,12 @@ func (bk *book) parseHTTPRunnerWithDetailed(name string, b []byte) (bool, error)
 			return false, err
 		}
 	}
+	if c.GraphQLSchemaLocation != "" {
+		c.GraphQLSchemaLocation, err = fp(c.GraphQLSchemaLocation, root)
+		if err != nil {
+			return false, err
+		}
+	}
 	if c.CACert != "" {
 		p, err := fp(c.CACert, root)
 		if err != nil {
@@ -346,6 +404,12 @@ func (bk *book) parseHTTPRunnerWithDetailed(name string, b []byte) (bool, error)
 			return false, fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
 		}
 	}
+	if err := r.setTransport(c); err != nil {
+		return false, err
+	}
+	if err := r.setAuth(c); err != nil {
+		return false, err
+	}
 	r.useCookie = c.UseCookie
 	r.trace = c.Trace.Enable
 	r.traceHeaderName = c.Trace.HeaderName
@@ -470,6 +534,10 @@ func (bk *book) parseDBRunnerWithDetailed(name string, b []byte) (bool, error) {
 		return false, err
 	}
 	r.trace = c.Trace
+	r.transaction, err = parseDBTransactionMode(c.Transaction)
+	if err != nil {
+		return false, err
+	}
 	bk.dbRunners[name] = r
 	return true, nil
 }
@@ -563,6 +631,36 @@ func (bk *book) parseSSHRunnerWithDetailed(name string, b []byte) (bool, error)
 	return true, nil
 }
 
+func (bk *book) parseMockRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &struct {
+		Mock *mockRunnerConfig `yaml:"mock"`
+	}{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if c.Mock == nil {
+		return false, nil
+	}
+	r, err := newMockRunner(name, c.Mock.Addr)
+	if err != nil {
+		return false, err
+	}
+	if err := r.addRoutes(c.Mock.Routes); err != nil {
+		return false, err
+	}
+	bk.mockRunners[name] = r
+	return true, nil
+}
+
+func mockRunnerConfigMap(v any) (map[string]any, bool) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	mc, ok := m["mock"].(map[string]any)
+	return mc, ok
+}
+
 func (bk *book) parseIncludeRunnerWithDetailed(name string, b []byte) (bool, error) {
 	c := &includeRunnerConfig{}
 	if err := yaml.Unmarshal(b, c); err != nil {
@@ -633,6 +731,21 @@ func (bk *book) merge(loaded *book) error {
 	for k, r := range loaded.sshRunners {
 		bk.sshRunners[k] = r
 	}
+	for k, r := range loaded.wsRunners {
+		bk.wsRunners[k] = r
+	}
+	for k, r := range loaded.kafkaRunners {
+		bk.kafkaRunners[k] = r
+	}
+	for k, r := range loaded.redisRunners {
+		bk.redisRunners[k] = r
+	}
+	for k, r := range loaded.smtpRunners {
+		bk.smtpRunners[k] = r
+	}
+	for k, r := range loaded.mockRunners {
+		bk.mockRunners[k] = r
+	}
 	for k, r := range loaded.includeRunners {
 		bk.includeRunners[k] = r
 	}
@@ -707,6 +820,11 @@ func newBook() *book {
 		grpcRunners:    map[string]*grpcRunner{},
 		cdpRunners:     map[string]*cdpRunner{},
 		sshRunners:     map[string]*sshRunner{},
+		wsRunners:      map[string]*wsRunner{},
+		kafkaRunners:   map[string]*kafkaRunner{},
+		redisRunners:   map[string]*redisRunner{},
+		smtpRunners:    map[string]*smtpRunner{},
+		mockRunners:    map[string]*mockRunner{},
 		includeRunners: map[string]*includeRunner{},
 		interval:       0 * time.Second,
 		runnerErrs:     map[string]error{},
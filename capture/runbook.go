@@ -2,6 +2,7 @@ package capture
 
 import (
 	"bytes"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -48,6 +49,9 @@ type runbook struct {
 	currentGRPCResponceIndex int
 	currentGRPCTestCond      []string
 	currentExecTestCond      []string
+
+	currentWebSocketResponseIndex int
+	currentWebSocketTestCond      []string
 }
 
 type RunbookOption func(*cRunbook) error
@@ -199,6 +203,8 @@ func (c *cRunbook) CaptureHTTPResponse(name string, res *http.Response) {
 	r.replaceLatestStep(append(step, yaml.MapItem{Key: "test", Value: fmt.Sprintf("%s\n", strings.Join(cond, "\n&& "))}))
 }
 
+func (c *cRunbook) CaptureHTTPEvent(name string, ev *runn.HTTPEvent) {}
+
 func (c *cRunbook) CaptureGRPCStart(name string, typ runn.GRPCType, service, method string) {
 	const dummyDsn = "[THIS IS gRPC RUNNER]"
 	if v, ok := c.runners[name]; ok {
@@ -357,6 +363,114 @@ func (c *cRunbook) CaptureSSHStderr(stderr string) {
 	// FIXME: not implemented
 }
 
+func (c *cRunbook) CaptureWebSocketStart(name string, h http.Header) {
+	const dummyDsn = "[THIS IS WebSocket RUNNER]"
+	if v, ok := c.runners[name]; ok {
+		c.setRunner(name, v)
+	} else {
+		c.setRunner(name, dummyDsn)
+	}
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	req := yaml.MapSlice{}
+	if len(h) > 0 {
+		hh := map[string]any{}
+		for k, v := range h {
+			if len(v) == 1 {
+				hh[k] = v[0]
+				continue
+			}
+			hh[k] = v
+		}
+		req = append(req, yaml.MapItem{Key: "headers", Value: hh})
+	}
+	step := yaml.MapSlice{
+		{Key: name, Value: req},
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+func (c *cRunbook) CaptureWebSocketSend(typ runn.WebSocketMessageType, data []byte) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	var m yaml.MapSlice
+	switch typ {
+	case runn.WebSocketMessageBinary:
+		m = yaml.MapSlice{{Key: string(runn.WebSocketOpBinary), Value: base64.StdEncoding.EncodeToString(data)}}
+	default:
+		m = yaml.MapSlice{{Key: string(runn.WebSocketOpText), Value: string(data)}}
+	}
+	c.appendWebSocketOp(r, m)
+}
+
+func (c *cRunbook) CaptureWebSocketReceive(typ runn.WebSocketMessageType, data []byte) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	c.appendWebSocketOp(r, string(runn.WebSocketOpReceive))
+	v := string(data)
+	if typ == runn.WebSocketMessageBinary {
+		v = base64.StdEncoding.EncodeToString(data)
+	}
+	cond := fmt.Sprintf("current.res.messages[%d].data == %#v", r.currentWebSocketResponseIndex, v)
+	r.currentWebSocketTestCond = append(r.currentWebSocketTestCond, cond)
+	r.currentWebSocketResponseIndex += 1
+}
+
+func (c *cRunbook) CaptureWebSocketClose() {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	c.appendWebSocketOp(r, string(runn.WebSocketOpClose))
+}
+
+func (c *cRunbook) CaptureWebSocketEnd(name string) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	defer func() {
+		r.currentWebSocketTestCond = nil
+		r.currentWebSocketResponseIndex = 0
+	}()
+	if len(r.currentWebSocketTestCond) == 0 {
+		return
+	}
+	step := r.latestStep()
+	step = append(step, yaml.MapItem{Key: "test", Value: fmt.Sprintf("%s\n", strings.Join(r.currentWebSocketTestCond, "\n&& "))})
+	r.replaceLatestStep(step)
+}
+
+func (c *cRunbook) CaptureKafkaProduce(name string, m *runn.KafkaMessage) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureKafkaConsume(name string, m *runn.KafkaMessage) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureRedisCommand(name string, args []any) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureRedisResult(name string, result any) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureSMTPMessage(name string, m *runn.SMTPMessage) {
+	// FIXME: not implemented
+}
+
+func (c *cRunbook) CaptureMockRequest(name string, r *runn.MockRequest) {
+	// FIXME: not implemented
+}
+
 func (c *cRunbook) CaptureDBStatement(name string, stmt string) {
 	const dummyDsn = "[THIS IS DB RUNNER]"
 	if v, ok := c.runners[name]; ok {
@@ -547,6 +661,32 @@ func (c *cRunbook) appendOp(hb yaml.MapSlice, m any) yaml.MapSlice {
 	return hb
 }
 
+func (c *cRunbook) appendWebSocketOp(r *runbook, m any) {
+	step := r.latestStep()
+	req, ok := step[0].Value.(yaml.MapSlice)
+	if !ok {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to get step[0].Value: %s", step[0].Value))
+		return
+	}
+	for i, item := range req {
+		if item.Key != "messages" {
+			continue
+		}
+		ms, ok := item.Value.([]any)
+		if !ok {
+			c.errs = errors.Join(c.errs, fmt.Errorf("failed to get messages: %s", item.Value))
+			return
+		}
+		req[i].Value = append(ms, m)
+		step[0].Value = req
+		r.replaceLatestStep(step)
+		return
+	}
+	req = append(req, yaml.MapItem{Key: "messages", Value: []any{m}})
+	step[0].Value = req
+	r.replaceLatestStep(step)
+}
+
 func (c *cRunbook) writeRunbook(trs runn.Trails, bookPath string) {
 	v, ok := c.runbooks.Load(trs[0])
 	if !ok {
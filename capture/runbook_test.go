@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"testing"
 
@@ -24,6 +25,7 @@ func TestRunbook(t *testing.T) {
 		{filepath.Join(testutil.Testdata(), "book", "db.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "exec.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "include_main.yml")},
+		{filepath.Join(testutil.Testdata(), "book", "websocket.yml")},
 	}
 	for _, tt := range tests {
 		t.Run(filepath.Base(tt.book), func(t *testing.T) {
@@ -33,11 +35,13 @@ func TestRunbook(t *testing.T) {
 			hs := testutil.HTTPServer(t)
 			gs := testutil.GRPCServer(t, false, false)
 			db, _ := testutil.SQLite(t)
+			ws := testutil.WebSocketServer(t)
 			opts := []runn.Option{
 				runn.Book(tt.book),
 				runn.HTTPRunner("req", hs.URL, hs.Client(), runn.MultipartBoundary(testutil.MultipartBoundary)),
 				runn.GrpcRunner("greq", gs.Conn()),
 				runn.DBRunner("db", db),
+				runn.WebSocketRunner("ws", strings.Replace(ws.URL, "http://", "ws://", 1)),
 				runn.Capture(Runbook(dir)),
 				runn.Scopes(runn.ScopeAllowReadParent, runn.ScopeAllowRunExec),
 			}
@@ -72,6 +76,7 @@ func TestRunnable(t *testing.T) {
 		{filepath.Join(testutil.Testdata(), "book", "grpc.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "db.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "exec.yml")},
+		{filepath.Join(testutil.Testdata(), "book", "websocket.yml")},
 	}
 	for _, tt := range tests {
 		t.Run(filepath.Base(tt.book), func(t *testing.T) {
@@ -82,11 +87,13 @@ func TestRunnable(t *testing.T) {
 				hs := testutil.HTTPServer(t)
 				gs := testutil.GRPCServer(t, false, false)
 				db, _ := testutil.SQLite(t)
+				ws := testutil.WebSocketServer(t)
 				opts := []runn.Option{
 					runn.Book(tt.book),
 					runn.HTTPRunner("req", hs.URL, hs.Client(), runn.MultipartBoundary(testutil.MultipartBoundary)),
 					runn.GrpcRunner("greq", gs.Conn()),
 					runn.DBRunner("db", db),
+					runn.WebSocketRunner("ws", strings.Replace(ws.URL, "http://", "ws://", 1)),
 					runn.Capture(Runbook(dir)),
 					runn.Scopes(runn.ScopeAllowReadParent, runn.ScopeAllowRunExec),
 				}
@@ -103,11 +110,13 @@ func TestRunnable(t *testing.T) {
 				hs := testutil.HTTPServer(t)
 				gs := testutil.GRPCServer(t, false, false)
 				db, _ := testutil.SQLite(t)
+				ws := testutil.WebSocketServer(t)
 				opts := []runn.Option{
 					runn.Book(filepath.Join(dir, capturedFilename(tt.book))),
 					runn.HTTPRunner("req", hs.URL, hs.Client(), runn.MultipartBoundary(testutil.MultipartBoundary)),
 					runn.GrpcRunner("greq", gs.Conn()),
 					runn.DBRunner("db", db),
+					runn.WebSocketRunner("ws", strings.Replace(ws.URL, "http://", "ws://", 1)),
 					runn.Scopes(runn.ScopeAllowReadParent, runn.ScopeAllowRunExec),
 				}
 				o, err := runn.New(opts...)
@@ -16,6 +16,7 @@ type Capturer interface {
 
 	CaptureHTTPRequest(name string, req *http.Request)
 	CaptureHTTPResponse(name string, res *http.Response)
+	CaptureHTTPEvent(name string, ev *HTTPEvent)
 
 	CaptureGRPCStart(name string, typ GRPCType, service, method string)
 	CaptureGRPCRequestHeaders(h map[string][]string)
@@ -36,6 +37,22 @@ type Capturer interface {
 	CaptureSSHStdout(stdout string)
 	CaptureSSHStderr(stderr string)
 
+	CaptureWebSocketStart(name string, h http.Header)
+	CaptureWebSocketSend(typ WebSocketMessageType, data []byte)
+	CaptureWebSocketReceive(typ WebSocketMessageType, data []byte)
+	CaptureWebSocketClose()
+	CaptureWebSocketEnd(name string)
+
+	CaptureKafkaProduce(name string, m *KafkaMessage)
+	CaptureKafkaConsume(name string, m *KafkaMessage)
+
+	CaptureRedisCommand(name string, args []any)
+	CaptureRedisResult(name string, result any)
+
+	CaptureSMTPMessage(name string, m *SMTPMessage)
+
+	CaptureMockRequest(name string, r *MockRequest)
+
 	CaptureDBStatement(name string, stmt string)
 	CaptureDBResponse(name string, res *DBResponse)
 
@@ -86,6 +103,12 @@ func (cs capturers) captureHTTPResponse(name string, res *http.Response) { //nos
 	}
 }
 
+func (cs capturers) captureHTTPEvent(name string, ev *HTTPEvent) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureHTTPEvent(name, ev)
+	}
+}
+
 func (cs capturers) captureGRPCStart(name string, typ GRPCType, service, method string) { //nostyle:recvtype
 	for _, c := range cs {
 		c.CaptureGRPCStart(name, typ, service, method)
@@ -181,6 +204,72 @@ func (cs capturers) captureSSHStderr(stderr string) { //nostyle:recvtype
 	}
 }
 
+func (cs capturers) captureWebSocketStart(name string, h http.Header) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketStart(name, h)
+	}
+}
+
+func (cs capturers) captureWebSocketSend(typ WebSocketMessageType, data []byte) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketSend(typ, data)
+	}
+}
+
+func (cs capturers) captureWebSocketReceive(typ WebSocketMessageType, data []byte) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketReceive(typ, data)
+	}
+}
+
+func (cs capturers) captureWebSocketClose() { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketClose()
+	}
+}
+
+func (cs capturers) captureWebSocketEnd(name string) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWebSocketEnd(name)
+	}
+}
+
+func (cs capturers) captureKafkaProduce(name string, m *KafkaMessage) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureKafkaProduce(name, m)
+	}
+}
+
+func (cs capturers) captureKafkaConsume(name string, m *KafkaMessage) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureKafkaConsume(name, m)
+	}
+}
+
+func (cs capturers) captureRedisCommand(name string, args []any) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureRedisCommand(name, args)
+	}
+}
+
+func (cs capturers) captureRedisResult(name string, result any) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureRedisResult(name, result)
+	}
+}
+
+func (cs capturers) captureSMTPMessage(name string, m *SMTPMessage) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureSMTPMessage(name, m)
+	}
+}
+
+func (cs capturers) captureMockRequest(name string, r *MockRequest) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureMockRequest(name, r)
+	}
+}
+
 func (cs capturers) captureDBStatement(name string, stmt string) { //nostyle:recvtype
 	for _, c := range cs {
 		c.CaptureDBStatement(name, stmt)
@@ -51,11 +51,17 @@ var sortByMethod = []string{
 	http.MethodTrace,
 }
 
+var sortByGraphQLOperation = []string{
+	"query",
+	"mutation",
+	"subscription",
+}
+
 // coverageCmd represents the coverage command.
 var coverageCmd = &cobra.Command{
 	Use:   "coverage [PATH_PATTERN ...]",
-	Short: "show coverage for paths/operations of OpenAPI spec and methods of protocol buffers",
-	Long:  `show coverage for paths/operations of OpenAPI spec and methods of protocol buffers.`,
+	Short: "show coverage for paths/operations of OpenAPI spec, methods of protocol buffers and root fields of GraphQL schema",
+	Long:  `show coverage for paths/operations of OpenAPI spec, methods of protocol buffers and root fields of GraphQL schema.`,
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
@@ -135,9 +141,18 @@ var coverageCmd = &cobra.Command{
 						// Sort by method ( protocol buffers )
 						return keys[i] < keys[j]
 					}
-					// Sort by path ( OpenAPI )
 					mpi := strings.SplitN(keys[i], " ", 2)
 					mpj := strings.SplitN(keys[j], " ", 2)
+					oi := slices.Index(sortByGraphQLOperation, mpi[0])
+					oj := slices.Index(sortByGraphQLOperation, mpj[0])
+					if oi >= 0 && oj >= 0 {
+						// Sort by operation and field ( GraphQL )
+						if oi == oj {
+							return mpi[1] < mpj[1]
+						}
+						return oi < oj
+					}
+					// Sort by path ( OpenAPI )
 					if mpi[1] == mpj[1] {
 						// Sort by method ( OpenAPI )
 						return slices.Index(sortByMethod, mpi[0]) < slices.Index(sortByMethod, mpj[0])
@@ -24,6 +24,8 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -51,6 +53,21 @@ var loadtCmd = &cobra.Command{
 			err = errors.Join(err, donegroup.Wait(ctx))
 		}()
 		pathp := strings.Join(args, string(filepath.ListSeparator))
+		format := flgs.Format
+		switch format {
+		case "", "json", "csv":
+		default:
+			return fmt.Errorf("invalid format: %s", format)
+		}
+		if flgs.LoadTCoordinator != "" {
+			if flgs.LoadTWorker != "" || len(flgs.LoadTStages) > 0 {
+				return errors.New("--coordinator can not be used with --worker or --stages")
+			}
+			return runLoadtCoordinator(ctx, format)
+		}
+		if flgs.LoadTWorker != "" && len(flgs.LoadTStages) > 0 {
+			return errors.New("--worker can not be used with --stages")
+		}
 		flgs.Format = "none" // Disable runn output
 		opts, err := flgs.ToOpts()
 		if err != nil {
@@ -71,19 +88,42 @@ var loadtCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		d, err := duration.Parse(flgs.LoadTDuration)
+		w, err := duration.Parse(flgs.LoadTWarmUp)
 		if err != nil {
 			return err
 		}
-		w, err := duration.Parse(flgs.LoadTWarmUp)
+		if flgs.LoadTWorker != "" {
+			return runn.RunLoadtWorker(ctx, flgs.LoadTWorker, o)
+		}
+		selected, err := o.SelectedOperators()
 		if err != nil {
 			return err
 		}
-		s, err := setting.New(flgs.LoadTConcurrent, flgs.LoadTMaxRPS, d, w)
+
+		if len(flgs.LoadTStages) > 0 {
+			stages, err := runn.ParseLoadtStages(flgs.LoadTStages)
+			if err != nil {
+				return err
+			}
+			st, err := runn.NewLoadtStager(o, w, stages)
+			if err != nil {
+				return err
+			}
+			if err := startLoadt(ctx, st.Start); err != nil {
+				return err
+			}
+			lr, err := runn.NewLoadtResultFromStager(len(selected), w, st, o)
+			if err != nil {
+				return err
+			}
+			return reportLoadt(lr, format, flgs.LoadTThreshold)
+		}
+
+		d, err := duration.Parse(flgs.LoadTDuration)
 		if err != nil {
 			return err
 		}
-		selected, err := o.SelectedOperators()
+		s, err := setting.New(flgs.LoadTConcurrent, flgs.LoadTMaxRPS, d, w)
 		if err != nil {
 			return err
 		}
@@ -91,38 +131,91 @@ var loadtCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if err := startLoadt(ctx, ot.Start); err != nil {
+			return err
+		}
+		lr, err := runn.NewLoadtResult(len(selected), w, d, flgs.LoadTConcurrent, flgs.LoadTMaxRPS, ot.Result, o)
+		if err != nil {
+			return err
+		}
+		return reportLoadt(lr, format, flgs.LoadTThreshold)
+	},
+}
 
-		if isatty.IsTerminal(os.Stdout.Fd()) {
-			// With tty
-			p := tea.NewProgram(newSpinnerModel(), tea.WithContext(ctx))
-			go func() {
-				if _, errr := p.Run(); errr != nil {
-					err = errr
-				}
-			}()
-			if err := ot.Start(ctx); err != nil {
-				return err
-			}
-			p.Quit()
-			p.Wait()
-		} else {
-			if err := ot.Start(ctx); err != nil {
-				return err
-			}
+// runLoadtCoordinator runs the load test on the workers connecting to the coordinator and reports the merged result.
+func runLoadtCoordinator(ctx context.Context, format string) error {
+	w, err := duration.Parse(flgs.LoadTWarmUp)
+	if err != nil {
+		return err
+	}
+	d, err := duration.Parse(flgs.LoadTDuration)
+	if err != nil {
+		return err
+	}
+	c, err := runn.NewLoadtCoordinator(flgs.LoadTCoordinator, flgs.LoadTWorkers)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	_, _ = fmt.Fprintf(os.Stderr, "Waiting for %d workers on %s ...\n", flgs.LoadTWorkers, c.Addr())
+	if err := startLoadt(ctx, func(ctx context.Context) error {
+		return c.Start(ctx, flgs.LoadTConcurrent, flgs.LoadTMaxRPS, d, w)
+	}); err != nil {
+		return err
+	}
+	lr, err := runn.NewLoadtResultFromCoordinator(w, d, flgs.LoadTConcurrent, flgs.LoadTMaxRPS, c)
+	if err != nil {
+		return err
+	}
+	return reportLoadt(lr, format, flgs.LoadTThreshold)
+}
+
+type loadtReporter interface {
+	Report(w io.Writer) error
+	ReportJSON(w io.Writer) error
+	ReportCSV(w io.Writer) error
+	CheckThreshold(threshold string) error
+}
+
+// startLoadt starts the load test with a spinner if stdout is a terminal.
+func startLoadt(ctx context.Context, start func(context.Context) error) (err error) {
+	if !isatty.IsTerminal(os.Stdout.Fd()) {
+		return start(ctx)
+	}
+	// With tty
+	p := tea.NewProgram(newSpinnerModel(), tea.WithContext(ctx))
+	go func() {
+		if _, errr := p.Run(); errr != nil {
+			err = errr
 		}
+	}()
+	if err := start(ctx); err != nil {
+		return err
+	}
+	p.Quit()
+	p.Wait()
+	return nil
+}
 
-		lr, err := runn.NewLoadtResult(len(selected), w, d, flgs.LoadTConcurrent, flgs.LoadTMaxRPS, ot.Result)
-		if err != nil {
+func reportLoadt(lr loadtReporter, format, threshold string) error {
+	switch format {
+	case "json":
+		if err := lr.ReportJSON(os.Stdout); err != nil {
 			return err
 		}
-		if err := lr.Report(os.Stdout); err != nil {
+	case "csv":
+		if err := lr.ReportCSV(os.Stdout); err != nil {
 			return err
 		}
-		if err := lr.CheckThreshold(flgs.LoadTThreshold); err != nil {
+	default:
+		if err := lr.Report(os.Stdout); err != nil {
 			return err
 		}
-		return nil
-	},
+	}
+	if err := lr.CheckThreshold(threshold); err != nil {
+		return err
+	}
+	return nil
 }
 
 func init() {
@@ -157,6 +250,7 @@ func init() {
 	loadtCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
 	loadtCmd.Flags().BoolVarP(&flgs.RetainCacheDir, "retain-cache-dir", "", false, flgs.Usage("RetainCacheDir"))
 	loadtCmd.Flags().StringVarP(&flgs.WaitTimeout, "wait-timeout", "", "10sec", flgs.Usage("WaitTimeout"))
+	loadtCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format"))
 	loadtCmd.Flags().StringVarP(&flgs.EnvFile, "env-file", "", "", flgs.Usage("EnvFile"))
 	if err := loadtCmd.MarkFlagFilename("env-file"); err != nil {
 		panic(err)
@@ -167,4 +261,9 @@ func init() {
 	loadtCmd.Flags().StringVarP(&flgs.LoadTWarmUp, "warm-up", "", "5sec", flgs.Usage("LoadTWarmUp"))
 	loadtCmd.Flags().StringVarP(&flgs.LoadTThreshold, "threshold", "", "", flgs.Usage("LoadTThreshold"))
 	loadtCmd.Flags().IntVarP(&flgs.LoadTMaxRPS, "max-rps", "", 1, flgs.Usage("LoadTMaxRPS"))
+	loadtCmd.Flags().StringSliceVarP(&flgs.LoadTStages, "stages", "", []string{}, flgs.Usage("LoadTStages"))
+	loadtCmd.Flags().StringVarP(&flgs.LoadTCoordinator, "coordinator", "", "", flgs.Usage("LoadTCoordinator"))
+	loadtCmd.Flags().IntVarP(&flgs.LoadTWorkers, "workers", "", 1, flgs.Usage("LoadTWorkers"))
+	loadtCmd.Flags().StringVarP(&flgs.LoadTWorker, "worker", "", "", flgs.Usage("LoadTWorker"))
+	loadtCmd.Flags().StringArrayVarP(&flgs.LoadTCounters, "counter", "", []string{}, flgs.Usage("LoadTCounters"))
 }
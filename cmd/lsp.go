@@ -0,0 +1,55 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/k1LoW/runn"
+	"github.com/spf13/cobra"
+)
+
+// lspCmd represents the lsp command.
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "start the language server for runbooks",
+	Long:  `start the language server for runbooks. It communicates with the editor via Language Server Protocol over stdio.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Do not use flgs.ToOpts() because it consumes STDIN.
+		if err := runn.LoadEnvFile(flgs.EnvFile); err != nil {
+			return err
+		}
+		opts := []runn.Option{
+			runn.Scopes(flgs.Scopes...),
+		}
+		return runn.ServeLSP(cmd.Context(), os.Stdin, os.Stdout, opts...)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+	lspCmd.Flags().StringVarP(&flgs.EnvFile, "env-file", "", "", flgs.Usage("EnvFile"))
+	if err := lspCmd.MarkFlagFilename("env-file"); err != nil {
+		panic(err)
+	}
+}
@@ -23,17 +23,21 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/Songmu/prompter"
 	"github.com/goccy/go-yaml"
 	"github.com/k1LoW/runn"
 	"github.com/k1LoW/runn/capture"
 	"github.com/mattn/go-isatty"
+	"github.com/mattn/go-shellwords"
 	"github.com/spf13/cobra"
 )
 
@@ -44,16 +48,30 @@ var newCmd = &cobra.Command{
 	Long:    `create new runbook or append step to runbook.`,
 	Aliases: []string{"append"},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if flgs.FromOpenAPI != "" {
+			if len(args) > 0 {
+				return errors.New("cannot use arguments with --from-openapi")
+			}
+			if flgs.AndRun {
+				return errors.New("cannot use --and-run with --from-openapi")
+			}
+			return newFromOpenAPI(flgs.FromOpenAPI, flgs.Out)
+		}
 		var (
-			o   *os.File
-			err error
-			al  [][]string
+			o           *os.File
+			err         error
+			al          [][]string
+			interactive bool
 		)
 		if len(args) == 0 {
 			if isatty.IsTerminal(os.Stdin.Fd()) {
-				return errors.New("interactive mode is planned, but not yet implemented")
+				if !isatty.IsTerminal(os.Stdout.Fd()) {
+					return errors.New("interactive mode requires a terminal for STDOUT. Use --out to write the runbook to a file")
+				}
+				interactive = true
+			} else {
+				al = argsListFromStdin(os.Stdin)
 			}
-			al = argsListFromStdin(os.Stdin)
 		} else {
 			al = [][]string{args}
 		}
@@ -75,6 +93,16 @@ var newCmd = &cobra.Command{
 				}
 			}
 		}
+		if interactive {
+			ir := &interactiveRunbook{
+				desc:         &rb.Desc,
+				runners:      rb.Runners,
+				steps:        &rb.Steps,
+				appendStep:   rb.AppendStep,
+				appendDBStep: rb.AppendDBStep,
+			}
+			interact(ctx, ir)
+		}
 		for _, args := range al {
 			if err := rb.AppendStep(args...); err != nil {
 				return err
@@ -103,7 +131,8 @@ var newCmd = &cobra.Command{
 			return nil
 		}
 
-		if flgs.AndRun {
+		if flgs.AndRun && !interactive {
+			// In interactive mode, each step has already been run and captured.
 			if err := runAndCapture(ctx, o, fn); err != nil {
 				return err
 			}
@@ -122,12 +151,92 @@ func init() {
 	newCmd.Flags().StringVarP(&flgs.Desc, "desc", "", "", flgs.Usage("Desc"))
 	newCmd.Flags().StringVarP(&flgs.Out, "out", "", "", flgs.Usage("Out"))
 	newCmd.Flags().BoolVarP(&flgs.AndRun, "and-run", "", false, flgs.Usage("AndRun"))
+	newCmd.Flags().StringVarP(&flgs.FromOpenAPI, "from-openapi", "", "", flgs.Usage("FromOpenAPI"))
+	newCmd.Flags().StringVarP(&flgs.OpenAPIGroupBy, "openapi-group-by", "", "", flgs.Usage("OpenAPIGroupBy"))
+	if err := newCmd.MarkFlagFilename("from-openapi"); err != nil {
+		panic(err)
+	}
 	newCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCProtos, "grpc-proto", "", []string{}, flgs.Usage("GRPCProtos"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCImportPaths, "grpc-import-path", "", []string{}, flgs.Usage("GRPCImportPaths"))
 }
 
-func runAndCapture(ctx context.Context, o *os.File, fn func(*os.File) error) error {
+// newFromOpenAPI writes the runbooks generated from the OpenAPI v3 document to out.
+// out should be a directory when multiple runbooks are generated.
+func newFromOpenAPI(spec, out string) error {
+	abs, err := filepath.Abs(spec)
+	if err != nil {
+		return err
+	}
+	var dir string
+	isDir := false
+	if out != "" {
+		out = filepath.Clean(out)
+		if fi, err := os.Stat(out); (err == nil && fi.IsDir()) || strings.HasSuffix(flgs.Out, string(filepath.Separator)) {
+			dir = out
+			isDir = true
+		} else {
+			dir = filepath.Dir(out)
+		}
+	}
+	// The location of the document is relative to the runbooks.
+	loc := spec
+	if dir != "" {
+		adir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		loc, err = filepath.Rel(adir, abs)
+		if err != nil {
+			return err
+		}
+	}
+	rbs, err := runn.NewRunbooksFromOpenAPI3(spec, runn.OpenAPI3RunbookGroupBy(flgs.OpenAPIGroupBy), runn.OpenAPI3RunbookDocLocation(filepath.ToSlash(loc)))
+	if err != nil {
+		return err
+	}
+	if len(rbs) > 1 && !isDir {
+		return errors.New("--out should be a directory when multiple runbooks are generated")
+	}
+	if isDir {
+		if err := os.MkdirAll(dir, 0755); err != nil { //nolint:gosec
+			return err
+		}
+	}
+	for name, rb := range rbs {
+		if flgs.Desc != "" && len(rbs) == 1 {
+			rb.Desc = flgs.Desc
+		}
+		if out == "" {
+			if err := yaml.NewEncoder(os.Stdout).Encode(rb); err != nil {
+				return err
+			}
+			continue
+		}
+		p := out
+		if isDir {
+			p = filepath.Join(dir, name+".yml")
+		}
+		if _, err := os.Stat(p); err == nil {
+			return fmt.Errorf("%s already exists", p)
+		}
+		f, err := os.Create(p)
+		if err != nil {
+			return err
+		}
+		if err := yaml.NewEncoder(f).Encode(rb); err != nil {
+			_ = f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", p)
+	}
+	return nil
+}
+
+func runAndCapture(ctx context.Context, o io.Writer, fn func(*os.File) error) error {
 	const newf = "new.yml"
 	td, err := os.MkdirTemp("", "runn")
 	if err != nil {
@@ -156,6 +265,7 @@ func runAndCapture(ctx context.Context, o *os.File, fn func(*os.File) error) err
 		runn.GRPCProtos(flgs.GRPCProtos),
 		runn.GRPCImportPaths(flgs.GRPCImportPaths),
 		runn.Scopes(runn.ScopeAllowReadParent),
+		runn.Scopes(flgs.Scopes...),
 	}
 	oo, err := runn.New(opts...)
 	if err != nil {
@@ -184,6 +294,154 @@ func runAndCapture(ctx context.Context, o *os.File, fn func(*os.File) error) err
 	return nil
 }
 
+// interactiveRunbook holds the parts of the runbook edited in interactive mode.
+type interactiveRunbook struct {
+	desc         *string
+	runners      map[string]any
+	steps        *[]yaml.MapSlice
+	rawSteps     []yaml.MapSlice // Steps before being replaced with the captured ones
+	appendStep   func(in ...string) error
+	appendDBStep func(dsn, query string) error
+}
+
+const (
+	stepTypeHTTP = "http"
+	stepTypeGRPC = "grpc"
+	stepTypeDB   = "db"
+	stepTypeExec = "exec"
+	stepTypeDone = "done"
+)
+
+// interact asks desc, runners and steps of the runbook.
+func interact(ctx context.Context, ir *interactiveRunbook) {
+	*ir.desc = prompter.Prompt("Description", *ir.desc)
+	for {
+		key := prompter.Prompt("Runner name (empty to finish adding runners)", "")
+		if key == "" {
+			break
+		}
+		dsn := prompter.Prompt(fmt.Sprintf("DSN of %s", key), "")
+		if dsn == "" {
+			continue
+		}
+		ir.runners[key] = dsn
+	}
+	for {
+		typ := prompter.Choose("Step type", []string{stepTypeHTTP, stepTypeGRPC, stepTypeDB, stepTypeExec, stepTypeDone}, stepTypeDone)
+		var err error
+		switch typ {
+		case stepTypeHTTP:
+			err = appendCommandStep(ir, "curl command", "curl")
+		case stepTypeGRPC:
+			err = appendCommandStep(ir, "grpcurl command", "grpcurl")
+		case stepTypeDB:
+			err = appendQueryStep(ir)
+		case stepTypeExec:
+			err = appendCommandStep(ir, "Shell command", "")
+		case stepTypeDone:
+			return
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+			continue
+		}
+		if flgs.AndRun {
+			if err := runLatestStep(ctx, ir); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "failed to run the step: %s\n", err)
+			}
+		}
+	}
+}
+
+func appendCommandStep(ir *interactiveRunbook, msg, prefix string) error {
+	in := promptMultiline(msg)
+	if in == "" {
+		return errors.New("no command")
+	}
+	if prefix != "" {
+		if !strings.HasPrefix(in, prefix) {
+			return fmt.Errorf("should start with %q: %s", prefix, in)
+		}
+		return ir.appendStep(in)
+	}
+	p := shellwords.NewParser()
+	args, err := p.Parse(in)
+	if err != nil {
+		return err
+	}
+	if p.Position >= 0 {
+		// Pipes and command lists are passed to the shell as they are.
+		args = []string{"sh", "-c", in}
+	}
+	return ir.appendStep(args...)
+}
+
+func appendQueryStep(ir *interactiveRunbook) error {
+	var def string
+	for k, v := range ir.runners {
+		vv, ok := v.(string)
+		if !ok || strings.HasPrefix(vv, "http") || strings.HasPrefix(vv, "grpc") {
+			continue
+		}
+		if def == "" || k < def {
+			def = k
+		}
+	}
+	dsn := prompter.Prompt("DSN or runner name", def)
+	if v, ok := ir.runners[dsn].(string); ok {
+		dsn = v
+	}
+	return ir.appendDBStep(dsn, promptMultiline("SQL query"))
+}
+
+// promptMultiline prompts the input continued with trailing backslashes ( e.g. pasted curl command ).
+func promptMultiline(msg string) string {
+	const continuation = `\`
+	var lines []string
+	l := prompter.Prompt(msg, "")
+	for strings.HasSuffix(l, continuation) {
+		lines = append(lines, strings.TrimSpace(strings.TrimSuffix(l, continuation)))
+		l = prompter.Prompt(">", "")
+	}
+	lines = append(lines, strings.TrimSpace(l))
+	return strings.Join(lines, " ")
+}
+
+// runLatestStep runs the steps of the runbook so far and replaces the latest step with the captured one.
+// The previous steps are also run because the latest step may use their results ( e.g. `steps[0].res.body.token` or cookies ).
+func runLatestStep(ctx context.Context, ir *interactiveRunbook) error {
+	steps := *ir.steps
+	if len(steps) == 0 {
+		return nil
+	}
+	// Run the steps before being captured, because the tests of the captured steps are not always met again.
+	ir.rawSteps = append(ir.rawSteps, steps[len(ir.rawSteps):]...)
+	rb := runn.NewRunbook(*ir.desc)
+	rb.Runners = ir.runners
+	rb.Steps = ir.rawSteps
+	buf := new(bytes.Buffer)
+	if err := runAndCapture(ctx, buf, func(o *os.File) error {
+		return yaml.NewEncoder(o).Encode(rb)
+	}); err != nil {
+		return err
+	}
+	crb, err := runn.ParseRunbook(buf)
+	if err != nil {
+		return err
+	}
+	if len(crb.Steps) != len(rb.Steps) {
+		return fmt.Errorf("failed to capture the step: %s", buf.String())
+	}
+	latest := crb.Steps[len(crb.Steps)-1]
+	steps[len(steps)-1] = latest
+	b, err := yaml.Marshal(latest)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "%s\n", b)
+	return nil
+}
+
 func argsListFromStdin(in io.Reader) [][]string {
 	var al [][]string
 	scanner := bufio.NewScanner(in)
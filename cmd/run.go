@@ -25,16 +25,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/fsnotify/fsnotify"
 	"github.com/k1LoW/donegroup"
 	"github.com/k1LoW/runn"
 	"github.com/spf13/cobra"
 )
 
+// watchDebounce is the time to wait for a series of changes to settle before rerunning.
+const watchDebounce = 200 * time.Millisecond
+
 // runCmd represents the run command.
 var runCmd = &cobra.Command{
 	Use:   "run [PATH_PATTERN ...]",
@@ -78,17 +86,8 @@ var runCmd = &cobra.Command{
 			return err
 		}
 		r := o.Result()
-		switch flgs.Format {
-		case "json":
-			if err := r.OutJSON(os.Stdout); err != nil {
-				return err
-			}
-		case "none":
-		default:
-			// If --verbose == true, leave it to cmdout to display results
-			if err := r.Out(os.Stdout); err != nil {
-				return err
-			}
+		if err := outRunNResult(r); err != nil {
+			return err
 		}
 
 		if flgs.Profile {
@@ -107,6 +106,25 @@ var runCmd = &cobra.Command{
 			}
 		}
 
+		if flgs.Watch {
+			rerun := func(ctx context.Context, paths []string) (runNResult, map[string][]string, error) {
+				// Only the changed runbooks are reloaded and run.
+				ro, err := o.Reload(paths)
+				if err != nil {
+					return nil, nil, err
+				}
+				if err := ro.RunN(ctx); err != nil {
+					return nil, nil, err
+				}
+				files, err := ro.ReferencedFiles()
+				if err != nil {
+					return nil, nil, err
+				}
+				return ro.Result(), files, nil
+			}
+			return watchRun(ctx, o, rerun)
+		}
+
 		if r.HasFailure() {
 			os.Exit(1)
 		}
@@ -114,6 +132,125 @@ var runCmd = &cobra.Command{
 	},
 }
 
+type runNResult interface {
+	HasFailure() bool
+	Out(out io.Writer) error
+	OutJSON(out io.Writer) error
+	OutJUnit(out io.Writer) error
+	OutTAP(out io.Writer) error
+	OutSummary(out io.Writer) error
+}
+
+type referencedFiler interface {
+	ReferencedFiles() (map[string][]string, error)
+}
+
+func outRunNResult(r runNResult) error {
+	switch flgs.Format {
+	case "json":
+		if err := r.OutJSON(os.Stdout); err != nil {
+			return err
+		}
+	case "junit":
+		if err := r.OutJUnit(os.Stdout); err != nil {
+			return err
+		}
+	case "tap":
+		if err := r.OutTAP(os.Stdout); err != nil {
+			return err
+		}
+	case "none":
+	default:
+		// If --verbose == true, leave it to cmdout to display results
+		if err := r.Out(os.Stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchRun watches the runbooks and the files they refer to, and reruns the affected runbooks when they change.
+func watchRun(ctx context.Context, o referencedFiler, rerun func(context.Context, []string) (runNResult, map[string][]string, error)) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	books, err := o.ReferencedFiles()
+	if err != nil {
+		return err
+	}
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	// Watch the directories, because some editors replace the file on saving.
+	watch := func() {
+		for _, files := range books {
+			for _, f := range files {
+				d := f
+				if fi, err := os.Stat(f); err != nil || !fi.IsDir() {
+					d = filepath.Dir(f)
+				}
+				_ = w.Add(d)
+			}
+		}
+	}
+	watch()
+	_, _ = fmt.Fprintln(os.Stderr, "Watching for changes... (Ctrl+C to quit)")
+
+	changed := map[string]struct{}{}
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Chmod) {
+				continue
+			}
+			for b, files := range books {
+				for _, f := range files {
+					if f == ev.Name || f == filepath.Dir(ev.Name) {
+						changed[b] = struct{}{}
+					}
+				}
+			}
+			if len(changed) > 0 {
+				// Wait for a series of changes to settle.
+				timer = time.After(watchDebounce)
+			}
+		case <-timer:
+			timer = nil
+			var paths []string
+			for b := range changed {
+				paths = append(paths, b)
+			}
+			clear(changed)
+			sort.Strings(paths)
+			_, _ = fmt.Fprintf(os.Stderr, "\n[%s] Rerun %s\n", time.Now().Format(time.TimeOnly), strings.Join(paths, ", "))
+			r, reloaded, err := rerun(ctx, paths)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+				continue
+			}
+			if err := r.OutSummary(os.Stdout); err != nil {
+				return err
+			}
+			for b, files := range reloaded {
+				books[b] = files
+			}
+			watch()
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().BoolVarP(&flgs.Debug, "debug", "", false, flgs.Usage("Debug"))
@@ -130,6 +267,8 @@ func init() {
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufConfigs, "grpc-buf-config", "", []string{}, flgs.Usage("GRPCBufConfigs"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufModules, "grpc-buf-module", "", []string{}, flgs.Usage("GRPCBufModules"))
 	runCmd.Flags().StringVarP(&flgs.CaptureDir, "capture", "", "", flgs.Usage("CaptureDir"))
+	runCmd.Flags().StringVarP(&flgs.FixtureDir, "fixtures", "", "", flgs.Usage("FixtureDir"))
+	runCmd.Flags().StringVarP(&flgs.FixtureMode, "fixture-mode", "", string(runn.FixtureReplay), flgs.Usage("FixtureMode"))
 	runCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
 	runCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
 	runCmd.Flags().StringSliceVarP(&flgs.Overlays, "overlay", "", []string{}, flgs.Usage("Overlays"))
@@ -155,5 +294,6 @@ func init() {
 	}
 	runCmd.Flags().BoolVarP(&flgs.Verbose, "verbose", "", false, flgs.Usage("Verbose"))
 	runCmd.Flags().BoolVarP(&flgs.Attach, "attach", "", false, flgs.Usage("Attach"))
+	runCmd.Flags().BoolVarP(&flgs.Watch, "watch", "", false, flgs.Usage("Watch"))
 	runCmd.Flags().BoolVarP(&flgs.ForceColor, "force-color", "", false, flgs.Usage("ForceColor"))
 }
@@ -57,6 +57,7 @@ func (d *cmdOut) CaptureResultByStep(trs Trails, result *RunResult) {
 
 func (d *cmdOut) CaptureHTTPRequest(name string, req *http.Request)                  {}
 func (d *cmdOut) CaptureHTTPResponse(name string, res *http.Response)                {}
+func (d *cmdOut) CaptureHTTPEvent(name string, ev *HTTPEvent)                        {}
 func (d *cmdOut) CaptureGRPCStart(name string, typ GRPCType, service, method string) {}
 func (d *cmdOut) CaptureGRPCRequestHeaders(h map[string][]string)                    {}
 func (d *cmdOut) CaptureGRPCRequestMessage(m map[string]any)                         {}
@@ -73,6 +74,17 @@ func (d *cmdOut) CaptureCDPEnd(name string)
 func (d *cmdOut) CaptureSSHCommand(command string)                                   {}
 func (d *cmdOut) CaptureSSHStdout(stdout string)                                     {}
 func (d *cmdOut) CaptureSSHStderr(stderr string)                                     {}
+func (d *cmdOut) CaptureWebSocketStart(name string, h http.Header)                   {}
+func (d *cmdOut) CaptureWebSocketSend(typ WebSocketMessageType, data []byte)         {}
+func (d *cmdOut) CaptureWebSocketReceive(typ WebSocketMessageType, data []byte)      {}
+func (d *cmdOut) CaptureWebSocketClose()                                             {}
+func (d *cmdOut) CaptureWebSocketEnd(name string)                                    {}
+func (d *cmdOut) CaptureKafkaProduce(name string, m *KafkaMessage)                   {}
+func (d *cmdOut) CaptureKafkaConsume(name string, m *KafkaMessage)                   {}
+func (d *cmdOut) CaptureRedisCommand(name string, args []any)                        {}
+func (d *cmdOut) CaptureRedisResult(name string, result any)                         {}
+func (d *cmdOut) CaptureSMTPMessage(name string, m *SMTPMessage)                     {}
+func (d *cmdOut) CaptureMockRequest(name string, r *MockRequest)                     {}
 func (d *cmdOut) CaptureDBStatement(name string, stmt string)                        {}
 func (d *cmdOut) CaptureDBResponse(name string, res *DBResponse)                     {}
 func (d *cmdOut) CaptureExecCommand(command, shell string, background bool)          {}
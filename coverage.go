@@ -12,6 +12,8 @@ import (
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
 	"github.com/pb33f/libopenapi/orderedmap"
 	"github.com/samber/lo"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
 )
 
 var varRep = regexp.MustCompile(`\{\{([^}]+)\}\}`)
@@ -22,7 +24,7 @@ type Coverage struct {
 	Specs []*SpecCoverage `json:"specs"`
 }
 
-// SpecCoverage is a coverage of spec (e.g. OpenAPI Document, servive of protocol buffers).
+// SpecCoverage is a coverage of spec (e.g. OpenAPI Document, servive of protocol buffers, GraphQL schema).
 type SpecCoverage struct {
 	Key       string         `json:"key"`
 	Coverages map[string]int `json:"coverages"`
@@ -32,7 +34,7 @@ func (o *operator) collectCoverage(ctx context.Context) (*Coverage, error) {
 	cov := &Coverage{}
 	// Collect coverage for openapi3
 	for name, r := range o.httpRunners {
-		ov, ok := r.validator.(*openAPI3Validator)
+		ov, ok := findHTTPValidator[*openAPI3Validator](r.validator)
 		if !ok {
 			o.Debugf("%s does not have openapi3 spec document (%s)\n", name, o.bookPath)
 			continue
@@ -95,6 +97,74 @@ func (o *operator) collectCoverage(ctx context.Context) (*Coverage, error) {
 		}
 	}
 
+	// Collect coverage for GraphQL schemas
+	for name, r := range o.httpRunners {
+		gv, ok := findHTTPValidator[*graphQLValidator](r.validator)
+		if !ok {
+			o.Debugf("%s does not have graphql schema (%s)\n", name, o.bookPath)
+			continue
+		}
+		key := gv.location
+		scov, ok := lo.Find(cov.Specs, func(scov *SpecCoverage) bool {
+			return scov.Key == key
+		})
+		if !ok {
+			scov = &SpecCoverage{
+				Key:       key,
+				Coverages: map[string]int{},
+			}
+			cov.Specs = append(cov.Specs, scov)
+		}
+		for _, def := range []*ast.Definition{gv.schema.Query, gv.schema.Mutation, gv.schema.Subscription} {
+			if def == nil {
+				continue
+			}
+			op := graphQLOperationByDefinition(gv.schema, def)
+			for _, f := range def.Fields {
+				if strings.HasPrefix(f.Name, "__") {
+					// Skip introspection fields
+					continue
+				}
+				scov.Coverages[fmt.Sprintf("%s %s", op, f.Name)] += 0
+			}
+		}
+		for _, s := range o.steps {
+			if s.httpRunner != r {
+				continue
+			}
+			for _, m := range s.httpRequest {
+				mm, ok := m.(map[string]any)
+				if !ok {
+					continue
+				}
+				for _, v := range mm {
+					vv, ok := v.(map[string]any)
+					if !ok {
+						continue
+					}
+					gm, ok := vv["graphql"].(map[string]any)
+					if !ok {
+						continue
+					}
+					q, _ := gm[graphQLQueryKey].(string)
+					n, _ := gm[graphQLOperationNameKey].(string)
+					fields, err := graphQLRootFields(q, n)
+					if err != nil {
+						o.Debugf("graphql query was not parsed: %s (%s)\n", err, o.bookPath)
+						continue
+					}
+					for _, f := range fields {
+						if _, ok := scov.Coverages[f]; !ok {
+							o.Debugf("%s was not matched in %s (%s)\n", f, key, o.bookPath)
+							continue
+						}
+						scov.Coverages[f]++
+					}
+				}
+			}
+		}
+	}
+
 	// Collect coverage for protocol buffers
 	for name, r := range o.grpcRunners {
 		if err := r.resolveAllMethodsUsingProtos(ctx); err != nil {
@@ -138,3 +208,53 @@ func (o *operator) collectCoverage(ctx context.Context) (*Coverage, error) {
 	}
 	return cov, nil
 }
+
+func graphQLOperationByDefinition(schema *ast.Schema, def *ast.Definition) ast.Operation {
+	switch def {
+	case schema.Mutation:
+		return ast.Mutation
+	case schema.Subscription:
+		return ast.Subscription
+	default:
+		return ast.Query
+	}
+}
+
+// graphQLRootFields returns the root fields ( e.g. "query user" ) selected by the operation of the query.
+func graphQLRootFields(query, operationName string) ([]string, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil, err
+	}
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return nil, fmt.Errorf("operation not found: %q", operationName)
+	}
+	var (
+		fields  []string
+		collect func(set ast.SelectionSet, visited map[string]struct{})
+	)
+	collect = func(set ast.SelectionSet, visited map[string]struct{}) {
+		for _, sel := range set {
+			switch v := sel.(type) {
+			case *ast.Field:
+				if strings.HasPrefix(v.Name, "__") {
+					continue
+				}
+				fields = append(fields, fmt.Sprintf("%s %s", op.Operation, v.Name))
+			case *ast.InlineFragment:
+				collect(v.SelectionSet, visited)
+			case *ast.FragmentSpread:
+				if _, ok := visited[v.Name]; ok {
+					continue
+				}
+				visited[v.Name] = struct{}{}
+				if f := doc.Fragments.ForName(v.Name); f != nil {
+					collect(f.SelectionSet, visited)
+				}
+			}
+		}
+	}
+	collect(op.SelectionSet, map[string]struct{}{})
+	return fields, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 
 	"github.com/k1LoW/runn/testutil"
@@ -18,6 +19,8 @@ func TestCoverage(t *testing.T) {
 	}{
 		{"testdata/book/httpbin.yml"},
 		{"testdata/book/grpc.yml"},
+		{"testdata/book/graphql_coverage.yml"},
+		{"testdata/graphql_coverage_same_name.yml"},
 	}
 	t.Setenv("DEBUG", "false")
 	ctx := context.Background()
@@ -33,6 +36,9 @@ func TestCoverage(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
+			sort.SliceStable(cov.Specs, func(i, j int) bool {
+				return cov.Specs[i].Key < cov.Specs[j].Key
+			})
 			got, err := json.Marshal(cov)
 			if err != nil {
 				t.Fatal(err)
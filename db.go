@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"unsafe"
 
@@ -17,13 +19,19 @@ import (
 	"github.com/goccy/go-json"
 	"github.com/golang-sql/sqlexp"
 	"github.com/golang-sql/sqlexp/nest"
-	_ "github.com/googleapis/go-sql-spanner"
+	spannerdriver "github.com/googleapis/go-sql-spanner"
 	"github.com/k1LoW/donegroup"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
 	"github.com/xo/dburl"
 	"modernc.org/sqlite"
 )
 
+const (
+	dbParamsKey = "params"
+	dbArgsKey   = "args"
+)
+
 const (
 	dbStoreLastInsertIDKey = "last_insert_id"
 	dbStoreRowsAffectedKey = "rows_affected"
@@ -39,21 +47,43 @@ type TxQuerier interface {
 	BeginTx(ctx context.Context, opts *nest.TxOptions) (*nest.Tx, error)
 }
 
+type dbTransactionMode string
+
+const (
+	// dbTransactionPerStep - Begin a transaction for each step and commit it at the end of the step ( default ).
+	dbTransactionPerStep dbTransactionMode = "per-step"
+	// dbTransactionPerRunbookRollback - Keep one transaction across steps and roll it back at the end of the runbook.
+	dbTransactionPerRunbookRollback dbTransactionMode = "per-runbook-rollback"
+)
+
 type dbRunner struct {
-	name      string
-	dsn       string
-	client    TxQuerier
-	hostRules hostRules
-	trace     *bool
+	name        string
+	dsn         string
+	client      TxQuerier
+	hostRules   hostRules
+	trace       *bool
+	transaction dbTransactionMode
+	runbookTx   *nest.Tx // Transaction kept across steps with `transaction: per-runbook-rollback`
 	// operatorID - The id of the operator for which the runner is defined.
 	operatorID string
+	mu         sync.Mutex
 }
 
 type dbQuery struct {
-	stmt  string
-	trace *bool
+	stmt      string
+	args      []any          // Positional parameters of `params:` ( or `args:` )
+	namedArgs map[string]any // Named parameters of `params:` ( or `args:` )
+	trace     *bool
 }
 
+type dbPlaceholderStyle int
+
+const (
+	dbPlaceholderQuestion dbPlaceholderStyle = iota // ?, ?, ... ( MySQL, SQLite )
+	dbPlaceholderDollar                             // $1, $2, ... ( PostgreSQL )
+	dbPlaceholderAtP                                // @p1, @p2, ... ( Spanner )
+)
+
 type DBResponse struct {
 	LastInsertID int64
 	RowsAffected int64
@@ -67,8 +97,9 @@ func newDBRunner(name, dsn string) (*dbRunner, error) {
 		return nil, err
 	}
 	return &dbRunner{
-		name: name,
-		dsn:  dsn,
+		name:        name,
+		dsn:         dsn,
+		transaction: dbTransactionPerStep,
 	}, nil
 }
 
@@ -84,7 +115,17 @@ func normalizeDSN(dsn string) string {
 
 func (rnr *dbRunner) Run(ctx context.Context, s *step) error {
 	o := s.parent
-	e, err := o.expandBeforeRecord(s.dbQuery, s)
+	// `params:` are evaluated as expressions, not expanded as strings
+	dq := map[string]any{}
+	params := map[string]any{}
+	for k, v := range s.dbQuery {
+		if k == dbParamsKey || k == dbArgsKey {
+			params[k] = v
+			continue
+		}
+		dq[k] = v
+	}
+	e, err := o.expandBeforeRecord(dq, s)
 	if err != nil {
 		return err
 	}
@@ -92,6 +133,13 @@ func (rnr *dbRunner) Run(ctx context.Context, s *step) error {
 	if !ok {
 		return fmt.Errorf("invalid query: %v", e)
 	}
+	for k, v := range params {
+		ev, err := o.evalBeforeRecord(v, s)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", k, err)
+		}
+		q[k] = ev
+	}
 	query, err := parseDBQuery(q)
 	if err != nil {
 		return fmt.Errorf("invalid query: %v %w", q, err)
@@ -103,6 +151,9 @@ func (rnr *dbRunner) Run(ctx context.Context, s *step) error {
 }
 
 func (rnr *dbRunner) Close() error {
+	if err := rnr.rollbackRunbookTx(); err != nil {
+		return err
+	}
 	if rnr.client == nil {
 		return nil
 	}
@@ -150,8 +201,18 @@ func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 		}
 	}
 	stmts := separateStmt(q.stmt)
+	stmts, args, err := q.bindParams(stmts, dbPlaceholderStyleOf(rnr.client))
+	if err != nil {
+		return err
+	}
 	out := map[string]any{}
-	tx, err := rnr.client.BeginTx(ctx, &sql.TxOptions{})
+	var tx nest.Querier
+	perRunbook := rnr.transaction == dbTransactionPerRunbookRollback
+	if perRunbook {
+		tx, err = rnr.beginRunbookTx(ctx)
+	} else {
+		tx, err = rnr.client.BeginTx(ctx, &sql.TxOptions{})
+	}
 	if err != nil {
 		return err
 	}
@@ -166,13 +227,13 @@ func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 	if err != nil {
 		return err
 	}
-	for _, stmt := range stmts {
+	for i, stmt := range stmts {
 		stmt = stmt + tc // add trace comment
 		o.capturers.captureDBStatement(rnr.name, stmt)
 		err := func() error {
 			if !isSELECTStmt(stmt) {
 				// exec
-				r, err := tx.ExecContext(ctx, stmt)
+				r, err := tx.ExecContext(ctx, stmt, args[i]...)
 				if err != nil {
 					return err
 				}
@@ -197,7 +258,7 @@ func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 
 			// query
 			var rows []map[string]any
-			r, err := tx.QueryContext(ctx, stmt)
+			r, err := tx.QueryContext(ctx, stmt, args[i]...)
 			if err != nil {
 				return err
 			}
@@ -297,19 +358,64 @@ func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 			return nil
 		}()
 		if err != nil {
+			if perRunbook {
+				// Leave the rollback to the end of the runbook
+				return err
+			}
 			if err := tx.Rollback(); err != nil {
 				return err
 			}
 			return err
 		}
 	}
-	if err := tx.Commit(); err != nil {
-		return err
+	if !perRunbook {
+		if err := tx.Commit(); err != nil {
+			return err
+		}
 	}
 	o.record(s.idx, out)
 	return nil
 }
 
+// beginRunbookTx returns the transaction kept across steps. If it has not begun yet, begin it.
+func (rnr *dbRunner) beginRunbookTx(ctx context.Context) (*nest.Tx, error) {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.runbookTx != nil {
+		return rnr.runbookTx, nil
+	}
+	// Use context.WithoutCancel because the transaction is rolled back by the cleanup of the operator after the steps context is canceled.
+	tx, err := rnr.client.BeginTx(context.WithoutCancel(ctx), &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	rnr.runbookTx = tx
+	return tx, nil
+}
+
+// rollbackRunbookTx rolls back the transaction kept across steps.
+func (rnr *dbRunner) rollbackRunbookTx() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.runbookTx == nil {
+		return nil
+	}
+	tx := rnr.runbookTx
+	rnr.runbookTx = nil
+	return tx.Rollback()
+}
+
+func parseDBTransactionMode(mode string) (dbTransactionMode, error) {
+	switch dbTransactionMode(mode) {
+	case "", dbTransactionPerStep:
+		return dbTransactionPerStep, nil
+	case dbTransactionPerRunbookRollback:
+		return dbTransactionPerRunbookRollback, nil
+	default:
+		return "", fmt.Errorf("invalid transaction mode: %s", mode)
+	}
+}
+
 func (q *dbQuery) generateTraceStmtComment(s *step) (string, error) {
 	if q.trace == nil || !*q.trace {
 		return "", nil
@@ -325,6 +431,206 @@ func (q *dbQuery) generateTraceStmtComment(s *step) (string, error) {
 	return fmt.Sprintf(" /* %s */", string(tj)), nil
 }
 
+// bindParams rewrites the placeholders of stmts to the style of the driver and returns the arguments for each statement.
+// Positional parameters use `?` and named parameters use `:name` regardless of the driver.
+// If stmts have no such placeholders, the parameters are passed as is ( e.g. `$1` of PostgreSQL, `@name` of Spanner ).
+func (q *dbQuery) bindParams(stmts []string, style dbPlaceholderStyle) ([]string, [][]any, error) {
+	args := make([][]any, len(stmts))
+	// Without params, the statements are passed as is so that `?` and `:name` keep their meaning in the SQL ( e.g. jsonb operators of PostgreSQL ).
+	if len(q.args) == 0 && len(q.namedArgs) == 0 {
+		return stmts, args, nil
+	}
+	bound := make([]string, len(stmts))
+	n := 0
+	for i, stmt := range stmts {
+		b, names, positional := rewritePlaceholders(stmt, style, n)
+		bound[i] = b
+		switch {
+		case q.namedArgs != nil:
+			if positional > 0 {
+				return nil, nil, fmt.Errorf("positional placeholders cannot be used with named params: %s", stmt)
+			}
+			for j, name := range names {
+				v, ok := q.namedArgs[name]
+				if !ok {
+					return nil, nil, fmt.Errorf("param not found: %s", name)
+				}
+				args[i] = append(args[i], dbArg(style, n+j+1, v))
+			}
+			n += len(names)
+		default:
+			if len(names) > 0 {
+				return nil, nil, fmt.Errorf("named placeholders cannot be used with positional params: %s", stmt)
+			}
+			if n+positional > len(q.args) {
+				return nil, nil, fmt.Errorf("not enough params: %d placeholders, but got %d params", n+positional, len(q.args))
+			}
+			for j := 0; j < positional; j++ {
+				args[i] = append(args[i], dbArg(style, n+j+1, q.args[n+j]))
+			}
+			n += positional
+		}
+	}
+	if n > 0 {
+		if q.args != nil && n != len(q.args) {
+			return nil, nil, fmt.Errorf("too many params: %d placeholders, but got %d params", n, len(q.args))
+		}
+		return bound, args, nil
+	}
+
+	// No placeholders to rewrite. Pass the params as is.
+	if len(stmts) > 1 {
+		return nil, nil, errors.New("params without placeholders (? or :name) cannot be used with multiple statements")
+	}
+	if q.namedArgs != nil {
+		keys := lo.Keys(q.namedArgs)
+		sort.Strings(keys)
+		for _, k := range keys {
+			args[0] = append(args[0], sql.Named(k, dbArgValue(q.namedArgs[k])))
+		}
+		return stmts, args, nil
+	}
+	for _, v := range q.args {
+		args[0] = append(args[0], dbArgValue(v))
+	}
+	return stmts, args, nil
+}
+
+// rewritePlaceholders rewrites `?` and `:name` outside of string literals and comments.
+// The jsonb operators `?|` and `?&`, type casts `::` and array slices `[lower:upper]` of PostgreSQL are left as is.
+// offset is the number of placeholders already rewritten in previous statements.
+func rewritePlaceholders(stmt string, style dbPlaceholderStyle, offset int) (string, []string, int) {
+	var (
+		sb         strings.Builder
+		names      []string
+		positional int
+		brackets   int
+	)
+	rs := []rune(stmt)
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// string literal or quoted identifier
+			j := i + 1
+			for j < len(rs) && rs[j] != c {
+				j++
+			}
+			if j >= len(rs) {
+				j = len(rs) - 1
+			}
+			sb.WriteString(string(rs[i : j+1]))
+			i = j
+		case c == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			// line comment
+			j := i
+			for j < len(rs) && rs[j] != '\n' {
+				j++
+			}
+			sb.WriteString(string(rs[i:j]))
+			i = j - 1
+		case c == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			// inline comment
+			j := i + 2
+			for j+1 < len(rs) && (rs[j] != '*' || rs[j+1] != '/') {
+				j++
+			}
+			end := min(j+2, len(rs))
+			sb.WriteString(string(rs[i:end]))
+			i = end - 1
+		case c == ':' && i+1 < len(rs) && rs[i+1] == ':':
+			// PostgreSQL type cast
+			sb.WriteString("::")
+			i++
+		case c == '?' && i+1 < len(rs) && (rs[i+1] == '|' || rs[i+1] == '&'):
+			// PostgreSQL jsonb operators
+			sb.WriteString(string(rs[i : i+2]))
+			i++
+		case c == '[':
+			brackets++
+			sb.WriteRune(c)
+		case c == ']':
+			if brackets > 0 {
+				brackets--
+			}
+			sb.WriteRune(c)
+		case c == ':' && brackets == 0 && i+1 < len(rs) && isParamNameStart(rs[i+1]):
+			j := i + 1
+			for j < len(rs) && isParamNameChar(rs[j]) {
+				j++
+			}
+			names = append(names, string(rs[i+1:j]))
+			sb.WriteString(dbPlaceholder(style, offset+len(names)))
+			i = j - 1
+		case c == '?':
+			positional++
+			sb.WriteString(dbPlaceholder(style, offset+positional))
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String(), names, positional
+}
+
+func isParamNameStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isParamNameChar(c rune) bool {
+	return isParamNameStart(c) || (c >= '0' && c <= '9')
+}
+
+func dbPlaceholder(style dbPlaceholderStyle, n int) string {
+	switch style {
+	case dbPlaceholderDollar:
+		return fmt.Sprintf("$%d", n)
+	case dbPlaceholderAtP:
+		return fmt.Sprintf("@p%d", n)
+	default:
+		return "?"
+	}
+}
+
+func dbArg(style dbPlaceholderStyle, n int, v any) any {
+	if style == dbPlaceholderAtP {
+		return sql.Named(fmt.Sprintf("p%d", n), dbArgValue(v))
+	}
+	return dbArgValue(v)
+}
+
+// dbArgValue converts the evaluated value into a value that the drivers can accept.
+func dbArgValue(v any) any {
+	switch vv := v.(type) {
+	case map[string]any, []any:
+		b, err := json.Marshal(vv)
+		if err != nil {
+			return v
+		}
+		return string(b)
+	default:
+		return v
+	}
+}
+
+func dbPlaceholderStyleOf(client TxQuerier) dbPlaceholderStyle {
+	ndb, ok := client.(*nest.DB)
+	if !ok {
+		return dbPlaceholderQuestion
+	}
+	db := ndb.DB()
+	if db == nil {
+		return dbPlaceholderQuestion
+	}
+	switch db.Driver().(type) {
+	case *pq.Driver:
+		return dbPlaceholderDollar
+	case *spannerdriver.Driver:
+		return dbPlaceholderAtP
+	default:
+		return dbPlaceholderQuestion
+	}
+}
+
 func connectDB(dsn string) (TxQuerier, error) {
 	var (
 		db  *sql.DB
@@ -3,6 +3,7 @@ package runn
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"testing"
@@ -181,6 +182,242 @@ SELECT * FROM users;
 	}
 }
 
+func TestDBRunnerWithParams(t *testing.T) {
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/db_params.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDBRunnerTransaction(t *testing.T) {
+	tests := []struct {
+		book        string
+		transaction string
+		want        int
+	}{
+		{"testdata/book/db_transaction_default.yml", "", 2},
+		{"testdata/book/db_transaction.yml", "per-step", 2},
+		{"testdata/book/db_transaction.yml", "per-runbook-rollback", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.transaction, func(t *testing.T) {
+			ctx := context.Background()
+			db, dsn := testutil.SQLite(t)
+			if _, err := db.Exec(`CREATE TABLE users (
+          id INTEGER PRIMARY KEY AUTOINCREMENT,
+          username TEXT UNIQUE NOT NULL,
+          password TEXT NOT NULL,
+          email TEXT UNIQUE NOT NULL,
+          created NUMERIC NOT NULL,
+          updated NUMERIC
+        );`); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("TEST_DB_DSN", dsn)
+			t.Setenv("TEST_DB_TRANSACTION", tt.transaction)
+			o, err := New(Book(tt.book))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Fatal(err)
+			}
+			var got int
+			if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid transaction mode", func(t *testing.T) {
+		_, dsn := testutil.SQLite(t)
+		bk := newBook()
+		opt := DBRunnerWithOptions("db", dsn, DBTransaction("per-invalid"))
+		if err := opt(bk); err != nil {
+			t.Fatal(err)
+		}
+		if bk.runnerErrs["db"] == nil {
+			t.Error("want error")
+		}
+	})
+}
+
+func TestBindParams(t *testing.T) {
+	tests := []struct {
+		stmts     []string
+		args      []any
+		namedArgs map[string]any
+		style     dbPlaceholderStyle
+		want      []string
+		wantArgs  [][]any
+		wantErr   bool
+	}{
+		{
+			[]string{"SELECT * FROM users WHERE id = ?"},
+			nil,
+			nil,
+			dbPlaceholderQuestion,
+			[]string{"SELECT * FROM users WHERE id = ?"},
+			[][]any{nil},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE id = ? AND name = ?"},
+			[]any{1, "alice"},
+			nil,
+			dbPlaceholderDollar,
+			[]string{"SELECT * FROM users WHERE id = $1 AND name = $2"},
+			[][]any{{1, "alice"}},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE id = ? AND name = '?' -- ?\n AND email = ? /* ? */"},
+			[]any{1, "alice@example.com"},
+			nil,
+			dbPlaceholderDollar,
+			[]string{"SELECT * FROM users WHERE id = $1 AND name = '?' -- ?\n AND email = $2 /* ? */"},
+			[][]any{{1, "alice@example.com"}},
+			false,
+		},
+		{
+			[]string{"INSERT INTO users (name) VALUES (?);", "SELECT * FROM users WHERE name = ?;"},
+			[]any{"alice", "bob"},
+			nil,
+			dbPlaceholderDollar,
+			[]string{"INSERT INTO users (name) VALUES ($1);", "SELECT * FROM users WHERE name = $2;"},
+			[][]any{{"alice"}, {"bob"}},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE id = :id AND created > '2022-02-22 00:00:00'::timestamp AND name = :name OR nick = :name"},
+			nil,
+			map[string]any{"id": 1, "name": "alice"},
+			dbPlaceholderQuestion,
+			[]string{"SELECT * FROM users WHERE id = ? AND created > '2022-02-22 00:00:00'::timestamp AND name = ? OR nick = ?"},
+			[][]any{{1, "alice", "alice"}},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE id = :id"},
+			nil,
+			map[string]any{"id": 1},
+			dbPlaceholderAtP,
+			[]string{"SELECT * FROM users WHERE id = @p1"},
+			[][]any{{sql.Named("p1", 1)}},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE id = @id"},
+			nil,
+			map[string]any{"id": 1},
+			dbPlaceholderAtP,
+			[]string{"SELECT * FROM users WHERE id = @id"},
+			[][]any{{sql.Named("id", 1)}},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE id = $1"},
+			[]any{1},
+			nil,
+			dbPlaceholderDollar,
+			[]string{"SELECT * FROM users WHERE id = $1"},
+			[][]any{{1}},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE info = ?"},
+			[]any{map[string]any{"age": 20}},
+			nil,
+			dbPlaceholderQuestion,
+			[]string{"SELECT * FROM users WHERE info = ?"},
+			[][]any{{`{"age":20}`}},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE info ? 'age' AND info ?| array['a', 'b'] AND info ?& array['c'] AND (tags[1:2])[1] = :tag"},
+			nil,
+			nil,
+			dbPlaceholderDollar,
+			[]string{"SELECT * FROM users WHERE info ? 'age' AND info ?| array['a', 'b'] AND info ?& array['c'] AND (tags[1:2])[1] = :tag"},
+			[][]any{nil},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE info ?| array[?] AND info ?& array[?] AND id = ?"},
+			[]any{"a", "b", 1},
+			nil,
+			dbPlaceholderDollar,
+			[]string{"SELECT * FROM users WHERE info ?| array[$1] AND info ?& array[$2] AND id = $3"},
+			[][]any{{"a", "b", 1}},
+			false,
+		},
+		{
+			[]string{"SELECT tags[1:n], tags[lower:upper] FROM users WHERE id = :id AND created > :created::timestamp"},
+			nil,
+			map[string]any{"id": 1, "created": "2022-02-22"},
+			dbPlaceholderDollar,
+			[]string{"SELECT tags[1:n], tags[lower:upper] FROM users WHERE id = $1 AND created > $2::timestamp"},
+			[][]any{{1, "2022-02-22"}},
+			false,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE id = ? AND name = ?"},
+			[]any{1},
+			nil,
+			dbPlaceholderQuestion,
+			nil,
+			nil,
+			true,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE id = ?"},
+			[]any{1, 2},
+			nil,
+			dbPlaceholderQuestion,
+			nil,
+			nil,
+			true,
+		},
+		{
+			[]string{"SELECT * FROM users WHERE id = :id"},
+			nil,
+			map[string]any{"name": "alice"},
+			dbPlaceholderQuestion,
+			nil,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.stmts, ""), func(t *testing.T) {
+			q := &dbQuery{args: tt.args, namedArgs: tt.namedArgs}
+			got, gotArgs, err := q.bindParams(tt.stmts, tt.style)
+			if err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(gotArgs, tt.wantArgs, cmp.AllowUnexported(sql.NamedArg{})); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
 func TestSeparateStmt(t *testing.T) {
 	tests := []struct {
 		stmt string
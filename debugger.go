@@ -1,11 +1,13 @@
 package runn
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httputil"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/goccy/go-json"
@@ -44,6 +46,10 @@ func (d *debugger) CaptureHTTPResponse(name string, res *http.Response) {
 	_, _ = fmt.Fprintf(d.out, "-----START HTTP RESPONSE-----\n%s\n-----END HTTP RESPONSE-----\n", string(b))
 }
 
+func (d *debugger) CaptureHTTPEvent(name string, ev *HTTPEvent) {
+	_, _ = fmt.Fprintf(d.out, "-----START HTTP EVENT-----\n%s\n-----END HTTP EVENT-----\n", dumpHTTPEvent(ev))
+}
+
 func (d *debugger) CaptureGRPCStart(name string, typ GRPCType, service, method string) {
 	_, _ = fmt.Fprintf(d.out, ">>>>>START gRPC (%s/%s)>>>>>\n", service, method)
 }
@@ -108,6 +114,57 @@ func (d *debugger) CaptureSSHStderr(stderr string) {
 	_, _ = fmt.Fprintf(d.out, "-----START STDERR-----\n%s\n-----END STDERR-----\n", stderr)
 }
 
+func (d *debugger) CaptureWebSocketStart(name string, h http.Header) {
+	_, _ = fmt.Fprintf(d.out, ">>>>>START WebSocket (%s)>>>>>\n", name)
+	if len(h) > 0 {
+		_, _ = fmt.Fprintf(d.out, "-----START WebSocket REQUEST HEADERS-----\n%s\n-----END WebSocket REQUEST HEADERS-----\n", dumpGRPCMetadata(h))
+	}
+}
+
+func (d *debugger) CaptureWebSocketSend(typ WebSocketMessageType, data []byte) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket SEND (%s)-----\n%s\n-----END WebSocket SEND-----\n", typ, dumpWebSocketMessage(typ, data))
+}
+
+func (d *debugger) CaptureWebSocketReceive(typ WebSocketMessageType, data []byte) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket RECEIVE (%s)-----\n%s\n-----END WebSocket RECEIVE-----\n", typ, dumpWebSocketMessage(typ, data))
+}
+
+func (d *debugger) CaptureWebSocketClose() {
+	_, _ = fmt.Fprint(d.out, "-----WebSocket CLOSE-----\n")
+}
+
+func (d *debugger) CaptureWebSocketEnd(name string) {
+	_, _ = fmt.Fprintf(d.out, "<<<<<END WebSocket (%s)<<<<<\n", name)
+}
+
+func (d *debugger) CaptureKafkaProduce(name string, m *KafkaMessage) {
+	_, _ = fmt.Fprintf(d.out, "-----START Kafka PRODUCE-----\n%s\n-----END Kafka PRODUCE-----\n", dumpKafkaMessage(m))
+}
+
+func (d *debugger) CaptureKafkaConsume(name string, m *KafkaMessage) {
+	_, _ = fmt.Fprintf(d.out, "-----START Kafka CONSUME-----\n%s\n-----END Kafka CONSUME-----\n", dumpKafkaMessage(m))
+}
+
+func (d *debugger) CaptureRedisCommand(name string, args []any) {
+	_, _ = fmt.Fprintf(d.out, "-----START REDIS COMMAND-----\n%s\n-----END REDIS COMMAND-----\n", dumpRedisCommand(args))
+}
+
+func (d *debugger) CaptureRedisResult(name string, result any) {
+	b, err := json.Marshal(result)
+	if err != nil {
+		b = []byte(fmt.Sprintf("%v", result))
+	}
+	_, _ = fmt.Fprintf(d.out, "-----START REDIS RESULT-----\n%s\n-----END REDIS RESULT-----\n", string(b))
+}
+
+func (d *debugger) CaptureSMTPMessage(name string, m *SMTPMessage) {
+	_, _ = fmt.Fprintf(d.out, "-----START SMTP MESSAGE-----\n%s\n-----END SMTP MESSAGE-----\n", dumpSMTPMessage(m))
+}
+
+func (d *debugger) CaptureMockRequest(name string, r *MockRequest) {
+	_, _ = fmt.Fprintf(d.out, "-----START MOCK REQUEST-----\n%s\n-----END MOCK REQUEST-----\n", dumpMockRequest(r))
+}
+
 func (d *debugger) CaptureDBStatement(name string, stmt string) {
 	_, _ = fmt.Fprintf(d.out, "-----START QUERY-----\n%s\n-----END QUERY-----\n", stmt)
 }
@@ -192,6 +249,105 @@ var (
 	dumpGRPCMessage = dumpMapInterface
 )
 
+func dumpWebSocketMessage(typ WebSocketMessageType, data []byte) string {
+	if typ == WebSocketMessageBinary {
+		return strings.TrimSuffix(hex.Dump(data), "\n")
+	}
+	return string(data)
+}
+
+func dumpHTTPEvent(ev *HTTPEvent) string {
+	var d []string
+	if ev.ID != "" {
+		d = append(d, fmt.Sprintf("id: %s", ev.ID))
+	}
+	if ev.Event != "" {
+		d = append(d, fmt.Sprintf("event: %s", ev.Event))
+	}
+	if ev.Retry > 0 {
+		d = append(d, fmt.Sprintf("retry: %d", ev.Retry))
+	}
+	d = append(d, fmt.Sprintf("data:\n%s", ev.Data))
+	return strings.Join(d, "\n")
+}
+
+func dumpKafkaMessage(m *KafkaMessage) string {
+	var d []string
+	d = append(d, fmt.Sprintf("topic: %s", m.Topic))
+	d = append(d, fmt.Sprintf("partition: %d", m.Partition))
+	d = append(d, fmt.Sprintf("offset: %d", m.Offset))
+	if len(m.Key) > 0 {
+		d = append(d, fmt.Sprintf("key: %s", string(m.Key)))
+	}
+	if len(m.Headers) > 0 {
+		var keys []string
+		for k := range m.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		d = append(d, "headers:")
+		for _, k := range keys {
+			d = append(d, fmt.Sprintf("  %s: %s", k, m.Headers[k]))
+		}
+	}
+	d = append(d, fmt.Sprintf("value:\n%s", string(m.Value)))
+	return strings.Join(d, "\n")
+}
+
+func dumpRedisCommand(args []any) string {
+	var d []string
+	for _, a := range args {
+		s := fmt.Sprintf("%v", a)
+		if s == "" || strings.ContainsAny(s, " \t\n\"") {
+			s = strconv.Quote(s)
+		}
+		d = append(d, s)
+	}
+	return strings.Join(d, " ")
+}
+
+func dumpSMTPMessage(m *SMTPMessage) string {
+	var d []string
+	d = append(d, fmt.Sprintf("from: %s", m.From))
+	d = append(d, fmt.Sprintf("to: %s", strings.Join(m.To, ", ")))
+	d = append(d, fmt.Sprintf("subject: %s", m.Subject))
+	if len(m.Links) > 0 {
+		d = append(d, "links:")
+		for _, l := range m.Links {
+			d = append(d, fmt.Sprintf("  %s", l))
+		}
+	}
+	if m.Text != "" {
+		d = append(d, fmt.Sprintf("text:\n%s", strings.TrimRight(m.Text, "\r\n")))
+	}
+	if m.HTML != "" {
+		d = append(d, fmt.Sprintf("html:\n%s", strings.TrimRight(m.HTML, "\r\n")))
+	}
+	return strings.Join(d, "\n")
+}
+
+func dumpMockRequest(r *MockRequest) string {
+	var d []string
+	target := r.Path
+	if len(r.Query) > 0 {
+		target = fmt.Sprintf("%s?%s", r.Path, r.Query.Encode())
+	}
+	d = append(d, fmt.Sprintf("%s %s", r.Method, target))
+	var keys []string
+	for k := range r.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		d = append(d, fmt.Sprintf("%s: %s", k, strings.Join(r.Header[k], ", ")))
+	}
+	if len(r.Body) > 0 {
+		d = append(d, "", string(r.Body))
+	}
+	d = append(d, fmt.Sprintf("(responded with %d)", r.Status))
+	return strings.Join(d, "\n")
+}
+
 func dumpGRPCMetadata(m map[string][]string) string {
 	var keys []string
 	for k := range m {
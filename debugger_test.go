@@ -26,6 +26,9 @@ func TestDebugger(t *testing.T) {
 		{"testdata/book/cdp.yml"},
 		{"testdata/book/db.yml"},
 		{"testdata/book/exec.yml"},
+		{"testdata/book/websocket.yml"},
+		{"testdata/book/kafka.yml"},
+		{"testdata/book/redis.yml"},
 	}
 	ctx := context.Background()
 	for _, tt := range tests {
@@ -37,11 +40,17 @@ func TestDebugger(t *testing.T) {
 			hs := testutil.HTTPServer(t)
 			gs := testutil.GRPCServer(t, false, false)
 			db, _ := testutil.SQLite(t)
+			ws := testutil.WebSocketServer(t)
+			kafka := testutil.KafkaCluster(t, "events", "notifications")
+			redis := testutil.RedisServer(t)
 			opts := []Option{
 				Book(tt.book),
 				HTTPRunner("req", hs.URL, hs.Client(), MultipartBoundary(testutil.MultipartBoundary)),
 				GrpcRunner("greq", gs.Conn()),
 				DBRunner("db", db),
+				WebSocketRunner("ws", strings.Replace(ws.URL, "http://", "ws://", 1)),
+				KafkaRunner("mq", kafka),
+				RedisRunner("cache", redis),
 				Capture(NewDebugger(out)),
 				Var("url", hs.URL),
 				Scopes(ScopeAllowRunExec, ScopeAllowReadParent),
@@ -0,0 +1,689 @@
+package runn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/goccy/go-json"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type FixtureMode string
+
+const (
+	// FixtureRecord - Run against the network and save the exchanges to the fixture directory.
+	FixtureRecord FixtureMode = "record"
+	// FixtureReplay - Serve responses from the fixture directory instead of the network.
+	FixtureReplay FixtureMode = "replay"
+	// FixtureDrift - Run against the network and fail steps whose responses differ from the recorded ones.
+	FixtureDrift FixtureMode = "drift"
+)
+
+const (
+	fixtureMultipartBoundary = "runn-fixture-boundary"
+	fixtureMaxNameLen        = 64
+)
+
+var fixtureNameRe = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+var _ Capturer = (*fixtureSession)(nil)
+
+// fixture - Recorded exchanges of a request. Responses are stored in the order of occurrence in a runbook run.
+type fixture struct {
+	Runner    string             `json:"runner"`
+	Type      GRPCType           `json:"type,omitempty"`
+	Request   *fixtureRequest    `json:"request"`
+	Responses []*fixtureResponse `json:"responses"`
+}
+
+type fixtureRequest struct {
+	Method     string           `json:"method"`
+	Path       string           `json:"path,omitempty"`
+	Service    string           `json:"service,omitempty"`
+	Body       string           `json:"body,omitempty"`
+	BodyBase64 string           `json:"bodyBase64,omitempty"`
+	Messages   []map[string]any `json:"messages,omitempty"`
+}
+
+type fixtureResponse struct {
+	Status     int                 `json:"status"`
+	Proto      string              `json:"proto,omitempty"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Body       string              `json:"body,omitempty"`
+	BodyBase64 string              `json:"bodyBase64,omitempty"`
+	Message    string              `json:"message,omitempty"`
+	Messages   []map[string]any    `json:"messages,omitempty"`
+	Trailers   map[string][]string `json:"trailers,omitempty"`
+}
+
+// fixtureStore - Fixture directory shared by all runbooks loaded with the same Fixtures option.
+type fixtureStore struct {
+	dir   string
+	mode  FixtureMode
+	cache map[string]*fixture
+	// written - Fixture files written in this process. Responses recorded in previous processes are discarded on the first write.
+	written map[string]struct{}
+	mu      sync.Mutex
+}
+
+// fixtureSession - Fixture state of an operator (and its nested operators).
+type fixtureSession struct {
+	store  *fixtureStore
+	counts map[string]int
+	// pending - The exchange currently being captured.
+	pending *fixtureExchange
+	errs    error
+	mu      sync.Mutex
+}
+
+type fixtureExchange struct {
+	fx   *fixture
+	body []byte
+	res  *fixtureResponse
+}
+
+func newFixtureStore(dir string, mode FixtureMode) (*fixtureStore, error) {
+	switch mode {
+	case FixtureRecord, FixtureReplay, FixtureDrift:
+	default:
+		return nil, fmt.Errorf("invalid fixture mode: %s", mode)
+	}
+	if dir == "" {
+		return nil, errors.New("fixture directory is empty")
+	}
+	return &fixtureStore{
+		dir:     dir,
+		mode:    mode,
+		cache:   map[string]*fixture{},
+		written: map[string]struct{}{},
+	}, nil
+}
+
+// path returns the path of the fixture file keyed by runner name, method, path (or service) and normalized body.
+func (fs *fixtureStore) path(fx *fixture, body []byte) string {
+	h := sha256.New()
+	for _, s := range []string{fx.Runner, fx.Request.Method, fx.Request.Path, fx.Request.Service} {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	_, _ = h.Write(body)
+	sum := hex.EncodeToString(h.Sum(nil))[:12]
+	parts := []string{fx.Request.Method, fx.Request.Path}
+	if fx.Request.Service != "" {
+		parts = []string{fx.Request.Service, fx.Request.Method}
+	}
+	name := strings.Trim(fixtureNameRe.ReplaceAllString(strings.Join(parts, "/"), "_"), "_")
+	if len(name) > fixtureMaxNameLen {
+		name = name[:fixtureMaxNameLen]
+	}
+	return filepath.Join(fs.dir, fixtureNameRe.ReplaceAllString(fx.Runner, "_"), fmt.Sprintf("%s-%s.json", name, sum))
+}
+
+func (fs *fixtureStore) load(p string) (*fixture, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fx, ok := fs.cache[p]; ok {
+		return fx, nil
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("fixture not found: %s", p)
+		}
+		return nil, err
+	}
+	fx := &fixture{}
+	if err := json.Unmarshal(b, fx); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", p, err)
+	}
+	fs.cache[p] = fx
+	return fx, nil
+}
+
+// response returns the idx-th recorded response. If the request occurs more times than recorded, the last response is returned.
+func (fs *fixtureStore) response(p string, idx int) (*fixture, *fixtureResponse, error) {
+	fx, err := fs.load(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fx.Responses) == 0 {
+		return nil, nil, fmt.Errorf("no responses in fixture: %s", p)
+	}
+	if idx >= len(fx.Responses) {
+		idx = len(fx.Responses) - 1
+	}
+	return fx, fx.Responses[idx], nil
+}
+
+func (fs *fixtureStore) save(p string, ex *fixtureExchange, idx int) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fx := fs.cache[p]
+	if _, written := fs.written[p]; !written {
+		fx = &fixture{
+			Runner:  ex.fx.Runner,
+			Type:    ex.fx.Type,
+			Request: ex.fx.Request,
+		}
+		fs.cache[p] = fx
+		fs.written[p] = struct{}{}
+	}
+	for len(fx.Responses) <= idx {
+		fx.Responses = append(fx.Responses, nil)
+	}
+	fx.Responses[idx] = ex.res
+	// Fill responses of occurrences that are not recorded (e.g. recorded by another runbook).
+	for i := range fx.Responses {
+		if fx.Responses[i] == nil {
+			fx.Responses[i] = ex.res
+		}
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(fx); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(p, buf.Bytes(), 0600)
+}
+
+func newFixtureSession(fs *fixtureStore) *fixtureSession {
+	return &fixtureSession{
+		store:  fs,
+		counts: map[string]int{},
+	}
+}
+
+func (f *fixtureSession) replaying() bool {
+	return f != nil && f.store.mode == FixtureReplay
+}
+
+func (f *fixtureSession) capturing() bool {
+	return f != nil && f.store.mode != FixtureReplay
+}
+
+// next returns the path of the fixture and the occurrence index of the exchange.
+func (f *fixtureSession) next(ex *fixtureExchange) (string, int) {
+	p := f.store.path(ex.fx, ex.body)
+	idx := f.counts[p]
+	f.counts[p]++
+	return p, idx
+}
+
+// takeErr returns the error of the fixture that occurred in the latest exchange and clears it.
+func (f *fixtureSession) takeErr() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	err := f.errs
+	f.errs = nil
+	return err
+}
+
+// replayHTTP returns the recorded response to req instead of sending it.
+func (f *fixtureSession) replayHTTP(name string, req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ex, err := newHTTPFixtureExchange(name, req)
+	if err != nil {
+		return nil, err
+	}
+	p, idx := f.next(ex)
+	_, fr, err := f.store.response(p, idx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay %s %s: %w", req.Method, ex.fx.Request.Path, err)
+	}
+	b, err := fr.body()
+	if err != nil {
+		return nil, err
+	}
+	h := http.Header{}
+	for k, v := range fr.Headers {
+		h[k] = append([]string{}, v...)
+	}
+	proto := fr.Proto
+	major, minor, ok := http.ParseHTTPVersion(proto)
+	if !ok {
+		proto, major, minor = "HTTP/1.1", 1, 1
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fr.Status, http.StatusText(fr.Status)),
+		StatusCode:    fr.Status,
+		Proto:         proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(b)),
+		ContentLength: int64(len(b)),
+		Request:       req,
+	}, nil
+}
+
+// replayGRPC returns the recorded exchange of the gRPC request.
+func (f *fixtureSession) replayGRPC(name, service, method string, messages []map[string]any) (*fixture, *fixtureResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ex, err := newGRPCFixtureExchange(name, "", service, method, messages)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, idx := f.next(ex)
+	fx, fr, err := f.store.response(p, idx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to replay %s/%s: %w", service, method, err)
+	}
+	return fx, fr, nil
+}
+
+// done records or compares the captured exchange.
+func (f *fixtureSession) done() {
+	ex := f.pending
+	f.pending = nil
+	if ex == nil {
+		return
+	}
+	p, idx := f.next(ex)
+	switch f.store.mode {
+	case FixtureRecord:
+		if err := f.store.save(p, ex, idx); err != nil {
+			f.errs = errors.Join(f.errs, fmt.Errorf("failed to record fixture: %w", err))
+		}
+	case FixtureDrift:
+		_, fr, err := f.store.response(p, idx)
+		if err != nil {
+			f.errs = errors.Join(f.errs, err)
+			return
+		}
+		want, err := fr.comparable(ex.fx.Type != "")
+		if err != nil {
+			f.errs = errors.Join(f.errs, err)
+			return
+		}
+		got, err := ex.res.comparable(ex.fx.Type != "")
+		if err != nil {
+			f.errs = errors.Join(f.errs, err)
+			return
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			f.errs = errors.Join(f.errs, fmt.Errorf("response drifted from fixture %s (-recorded +live):\n%s", p, diff))
+		}
+	}
+}
+
+func newHTTPFixtureExchange(name string, req *http.Request) (*fixtureExchange, error) {
+	var (
+		save io.ReadCloser
+		err  error
+	)
+	save, req.Body, err = drainBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(save)
+	if err != nil {
+		return nil, err
+	}
+	contentType := req.Header.Get("Content-Type")
+	body, err := normalizeFixtureBody(contentType, b)
+	if err != nil {
+		return nil, err
+	}
+	path := req.URL.EscapedPath()
+	if q := req.URL.Query(); len(q) > 0 {
+		path = fmt.Sprintf("%s?%s", path, q.Encode())
+	}
+	fr := &fixtureRequest{
+		Method: req.Method,
+		Path:   path,
+	}
+	fr.Body, fr.BodyBase64 = encodeFixtureBody(b)
+	return &fixtureExchange{
+		fx: &fixture{
+			Runner:  name,
+			Request: fr,
+		},
+		body: body,
+	}, nil
+}
+
+func newGRPCFixtureExchange(name string, typ GRPCType, service, method string, messages []map[string]any) (*fixtureExchange, error) {
+	body, err := json.Marshal(messages)
+	if err != nil {
+		return nil, err
+	}
+	return &fixtureExchange{
+		fx: &fixture{
+			Runner: name,
+			Type:   typ,
+			Request: &fixtureRequest{
+				Method:   method,
+				Service:  service,
+				Messages: messages,
+			},
+		},
+		body: body,
+		res:  &fixtureResponse{},
+	}, nil
+}
+
+// normalizeFixtureBody normalizes the request body so that semantically equal requests have the same key.
+func normalizeFixtureBody(contentType string, b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "":
+		return normalizeFixtureMultipart(params["boundary"], b)
+	case mediaType == MediaTypeApplicationFormUrlencoded:
+		v, err := url.ParseQuery(string(b))
+		if err != nil {
+			return b, nil //nolint:nilerr
+		}
+		return []byte(v.Encode()), nil
+	}
+	v, ok := decodeFixtureJSON(b)
+	if !ok {
+		return b, nil
+	}
+	return json.Marshal(v)
+}
+
+// normalizeFixtureMultipart rewrites the multipart body with the fixed boundary and the sorted parts,
+// because the boundary is random unless it is specified and the order of parts may vary.
+func normalizeFixtureMultipart(boundary string, b []byte) ([]byte, error) {
+	var parts [][]byte
+	mr := multipart.NewReader(bytes.NewReader(b), boundary)
+	for {
+		p, err := mr.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return b, nil //nolint:nilerr
+		}
+		var keys []string
+		for k := range p.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf := &bytes.Buffer{}
+		for _, k := range keys {
+			_, _ = fmt.Fprintf(buf, "%s: %s\r\n", k, strings.Join(p.Header[k], ", "))
+		}
+		_, _ = buf.WriteString("\r\n")
+		if _, err := buf.ReadFrom(p); err != nil {
+			return nil, err
+		}
+		parts = append(parts, buf.Bytes())
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return bytes.Compare(parts[i], parts[j]) < 0
+	})
+	buf := &bytes.Buffer{}
+	for _, p := range parts {
+		_, _ = fmt.Fprintf(buf, "--%s\r\n", fixtureMultipartBoundary)
+		_, _ = buf.Write(p)
+		_, _ = buf.WriteString("\r\n")
+	}
+	_, _ = fmt.Fprintf(buf, "--%s--\r\n", fixtureMultipartBoundary)
+	return buf.Bytes(), nil
+}
+
+func decodeFixtureJSON(b []byte) (any, bool) {
+	if !json.Valid(b) {
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+func encodeFixtureBody(b []byte) (string, string) {
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return "", base64.StdEncoding.EncodeToString(b)
+}
+
+func (fr *fixtureResponse) body() ([]byte, error) {
+	if fr.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(fr.BodyBase64)
+	}
+	return []byte(fr.Body), nil
+}
+
+// comparable returns the parts of the response compared for drift detection.
+// Headers are not compared because they often contain values that change on every request, such as Date.
+func (fr *fixtureResponse) comparable(grpc bool) (map[string]any, error) {
+	if grpc {
+		var messages []map[string]any
+		if len(fr.Messages) > 0 {
+			messages = fr.Messages
+		}
+		return map[string]any{
+			"status":   fr.Status,
+			"message":  fr.Message,
+			"messages": messages,
+		}, nil
+	}
+	b, err := fr.body()
+	if err != nil {
+		return nil, err
+	}
+	var body any = string(b)
+	if v, ok := decodeFixtureJSON(b); ok {
+		body = v
+	}
+	return map[string]any{
+		"status": fr.Status,
+		"body":   body,
+	}, nil
+}
+
+func (f *fixtureSession) CaptureStart(trs Trails, bookPath, desc string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Occurrences are counted per run of a runbook.
+	f.counts = map[string]int{}
+}
+func (f *fixtureSession) CaptureResult(trs Trails, result *RunResult)       {}
+func (f *fixtureSession) CaptureEnd(trs Trails, bookPath, desc string)      {}
+func (f *fixtureSession) CaptureResultByStep(trs Trails, result *RunResult) {}
+
+func (f *fixtureSession) CaptureHTTPRequest(name string, req *http.Request) {
+	if !f.capturing() {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ex, err := newHTTPFixtureExchange(name, req)
+	if err != nil {
+		f.errs = errors.Join(f.errs, err)
+		return
+	}
+	f.pending = ex
+}
+
+func (f *fixtureSession) CaptureHTTPResponse(name string, res *http.Response) {
+	if !f.capturing() {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending == nil {
+		return
+	}
+	var (
+		save io.ReadCloser
+		err  error
+	)
+	save, res.Body, err = drainBody(res.Body)
+	if err != nil {
+		f.errs = errors.Join(f.errs, err)
+		return
+	}
+	b, err := io.ReadAll(save)
+	if err != nil {
+		f.errs = errors.Join(f.errs, err)
+		return
+	}
+	fr := &fixtureResponse{
+		Status:  res.StatusCode,
+		Proto:   res.Proto,
+		Headers: res.Header.Clone(),
+	}
+	fr.Body, fr.BodyBase64 = encodeFixtureBody(b)
+	f.pending.res = fr
+	f.done()
+}
+
+func (f *fixtureSession) CaptureHTTPEvent(name string, ev *HTTPEvent) {}
+
+func (f *fixtureSession) CaptureGRPCStart(name string, typ GRPCType, service, method string) {
+	if !f.capturing() {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ex, err := newGRPCFixtureExchange(name, typ, service, method, nil)
+	if err != nil {
+		f.errs = errors.Join(f.errs, err)
+		return
+	}
+	f.pending = ex
+}
+
+func (f *fixtureSession) CaptureGRPCRequestHeaders(h map[string][]string) {}
+
+func (f *fixtureSession) CaptureGRPCRequestMessage(m map[string]any) {
+	f.capturePending(func(ex *fixtureExchange) {
+		ex.fx.Request.Messages = append(ex.fx.Request.Messages, m)
+	})
+}
+
+func (f *fixtureSession) CaptureGRPCResponseStatus(s *status.Status) {
+	f.capturePending(func(ex *fixtureExchange) {
+		ex.res.Status = int(s.Code())
+		ex.res.Message = ""
+		if s.Code() != codes.OK {
+			ex.res.Message = s.Message()
+		}
+	})
+}
+
+func (f *fixtureSession) CaptureGRPCResponseHeaders(h map[string][]string) {
+	f.capturePending(func(ex *fixtureExchange) {
+		if ex.res.Headers == nil {
+			ex.res.Headers = map[string][]string{}
+		}
+		for k, v := range h {
+			ex.res.Headers[k] = append(ex.res.Headers[k], v...)
+		}
+	})
+}
+
+func (f *fixtureSession) CaptureGRPCResponseMessage(m map[string]any) {
+	f.capturePending(func(ex *fixtureExchange) {
+		ex.res.Messages = append(ex.res.Messages, m)
+	})
+}
+
+func (f *fixtureSession) CaptureGRPCResponseTrailers(t map[string][]string) {
+	f.capturePending(func(ex *fixtureExchange) {
+		ex.res.Trailers = t
+	})
+}
+
+func (f *fixtureSession) CaptureGRPCClientClose() {}
+
+func (f *fixtureSession) CaptureGRPCEnd(name string, typ GRPCType, service, method string) {
+	if !f.capturing() {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending == nil || f.pending.fx.Type == "" {
+		return
+	}
+	// The key is calculated from the messages actually sent.
+	body, err := json.Marshal(f.pending.fx.Request.Messages)
+	if err != nil {
+		f.errs = errors.Join(f.errs, err)
+		f.pending = nil
+		return
+	}
+	f.pending.body = body
+	f.done()
+}
+
+func (f *fixtureSession) capturePending(fn func(ex *fixtureExchange)) {
+	if !f.capturing() {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending == nil || f.pending.fx.Type == "" {
+		return
+	}
+	fn(f.pending)
+}
+
+func (f *fixtureSession) CaptureCDPStart(name string)                                   {}
+func (f *fixtureSession) CaptureCDPAction(a CDPAction)                                  {}
+func (f *fixtureSession) CaptureCDPResponse(a CDPAction, res map[string]any)            {}
+func (f *fixtureSession) CaptureCDPEnd(name string)                                     {}
+func (f *fixtureSession) CaptureSSHCommand(command string)                              {}
+func (f *fixtureSession) CaptureSSHStdout(stdout string)                                {}
+func (f *fixtureSession) CaptureSSHStderr(stderr string)                                {}
+func (f *fixtureSession) CaptureWebSocketStart(name string, h http.Header)              {}
+func (f *fixtureSession) CaptureWebSocketSend(typ WebSocketMessageType, data []byte)    {}
+func (f *fixtureSession) CaptureWebSocketReceive(typ WebSocketMessageType, data []byte) {}
+func (f *fixtureSession) CaptureWebSocketClose()                                        {}
+func (f *fixtureSession) CaptureWebSocketEnd(name string)                               {}
+func (f *fixtureSession) CaptureKafkaProduce(name string, m *KafkaMessage)              {}
+func (f *fixtureSession) CaptureKafkaConsume(name string, m *KafkaMessage)              {}
+func (f *fixtureSession) CaptureRedisCommand(name string, args []any)                   {}
+func (f *fixtureSession) CaptureRedisResult(name string, result any)                    {}
+func (f *fixtureSession) CaptureSMTPMessage(name string, m *SMTPMessage)                {}
+func (f *fixtureSession) CaptureMockRequest(name string, r *MockRequest)                {}
+func (f *fixtureSession) CaptureDBStatement(name string, stmt string)                   {}
+func (f *fixtureSession) CaptureDBResponse(name string, res *DBResponse)                {}
+func (f *fixtureSession) CaptureExecCommand(command, shell string, background bool)     {}
+func (f *fixtureSession) CaptureExecStdin(stdin string)                                 {}
+func (f *fixtureSession) CaptureExecStdout(stdout string)                               {}
+func (f *fixtureSession) CaptureExecStderr(stderr string)                               {}
+func (f *fixtureSession) SetCurrentTrails(trs Trails)                                   {}
+func (f *fixtureSession) Errs() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.errs
+}
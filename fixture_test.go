@@ -0,0 +1,175 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/grpcstub"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFixtures(t *testing.T) {
+	tests := []struct {
+		book string
+		grpc bool
+	}{
+		{"testdata/book/http.yml", false},
+		{"testdata/book/http_multipart.yml", false},
+		{"testdata/grpc_fixture.yml", true},
+	}
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.book), func(t *testing.T) {
+			dir := t.TempDir()
+			t.Run("record", func(t *testing.T) {
+				ctx, cancel := donegroup.WithCancel(context.Background())
+				t.Cleanup(cancel)
+				hs := testutil.HTTPServer(t)
+				opts := []Option{
+					Book(tt.book),
+					HTTPRunner("req", hs.URL, hs.Client()),
+					Fixtures(dir, FixtureRecord),
+					Scopes(ScopeAllowReadParent),
+				}
+				if tt.grpc {
+					gs := fixtureGRPCServer(t)
+					opts = append(opts, GrpcRunner("greq", gs.Conn()))
+				}
+				o, err := New(opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := o.Run(ctx); err != nil {
+					t.Fatal(err)
+				}
+				entries, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(entries) == 0 {
+					t.Error("no fixtures are recorded")
+				}
+			})
+
+			t.Run("replay", func(t *testing.T) {
+				ctx, cancel := donegroup.WithCancel(context.Background())
+				t.Cleanup(cancel)
+				opts := []Option{
+					Book(tt.book),
+					// No servers are running.
+					HTTPRunner("req", "http://localhost:1", &http.Client{}),
+					Fixtures(dir, FixtureReplay),
+					Scopes(ScopeAllowReadParent),
+				}
+				o, err := New(opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := o.Run(ctx); err != nil {
+					t.Error(err)
+				}
+			})
+		})
+	}
+}
+
+func TestFixturesDrift(t *testing.T) {
+	dir := t.TempDir()
+	book := "testdata/book/http.yml"
+	run := func(t *testing.T, mode FixtureMode) error {
+		t.Helper()
+		ctx, cancel := donegroup.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		hs := testutil.HTTPServer(t)
+		o, err := New(Book(book), HTTPRunner("req", hs.URL, hs.Client()), Fixtures(dir, mode), Scopes(ScopeAllowReadParent))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return o.Run(ctx)
+	}
+	if err := run(t, FixtureRecord); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(t, FixtureDrift); err != nil {
+		t.Errorf("want no drift: %v", err)
+	}
+
+	// Tamper with the recorded response of GET /users
+	entries, err := filepath.Glob(filepath.Join(dir, "req", "GET_users-*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %v", entries)
+	}
+	b, err := os.ReadFile(entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fx := &fixture{}
+	if err := json.Unmarshal(b, fx); err != nil {
+		t.Fatal(err)
+	}
+	fx.Responses[0].Body = strings.Replace(fx.Responses[0].Body, "bob", "robert", 1)
+	b, err = json.Marshal(fx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entries[0], b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = run(t, FixtureDrift)
+	if err == nil || !strings.Contains(err.Error(), "drifted") {
+		t.Errorf("want drift error: %v", err)
+	}
+}
+
+func fixtureGRPCServer(t *testing.T) *grpcstub.Server {
+	t.Helper()
+	ts := grpcstub.NewServer(t, "testdata/fixturetest.proto")
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	ts.Method("Hello").Match(func(r *grpcstub.Request) bool {
+		return r.Message["name"] == "nobody"
+	}).Status(status.New(codes.NotFound, "not found"))
+	ts.Method("Hello").Response(map[string]any{"message": "hello alice"})
+	ts.Method("ListHello").Response(map[string]any{"message": "hello bob"}).Response(map[string]any{"message": "hello bob again"})
+	return ts
+}
+
+func TestNormalizeFixtureBody(t *testing.T) {
+	tests := []struct {
+		contentType string
+		in          string
+		want        string
+	}{
+		{"application/json", `{"b": 1, "a": [1, 2]}`, `{"a":[1,2],"b":1}`},
+		{"application/json", `{"n": 12345678901234567890}`, `{"n":12345678901234567890}`},
+		{"application/x-www-form-urlencoded", "b=2&a=1", "a=1&b=2"},
+		{
+			"multipart/form-data; boundary=abc123",
+			"--abc123\r\nContent-Disposition: form-data; name=\"b\"\r\n\r\n2\r\n--abc123\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\n1\r\n--abc123--\r\n",
+			"--runn-fixture-boundary\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\n1\r\n--runn-fixture-boundary\r\nContent-Disposition: form-data; name=\"b\"\r\n\r\n2\r\n--runn-fixture-boundary--\r\n",
+		},
+		{"text/plain", "hello", "hello"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		got, err := normalizeFixtureBody(tt.contentType, []byte(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s %q: got %q, want %q", tt.contentType, tt.in, got, tt.want)
+		}
+	}
+}
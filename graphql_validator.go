@@ -0,0 +1,110 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+var _ httpValidator = (*graphQLValidator)(nil)
+
+// graphQLValidator validates GraphQL requests against the SDL schema.
+type graphQLValidator struct {
+	skipValidateRequest bool
+	location            string
+	schema              *ast.Schema
+}
+
+func newGraphQLValidator(c *httpRunnerConfig) (*graphQLValidator, error) {
+	if c.GraphQLSchemaLocation == "" {
+		return nil, errors.New("cannot load graphql schema")
+	}
+	b, err := readFile(c.GraphQLSchemaLocation)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := gqlparser.LoadSchema(&ast.Source{
+		Name:  c.GraphQLSchemaLocation,
+		Input: string(b),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid graphql schema: %w", err)
+	}
+	return &graphQLValidator{
+		skipValidateRequest: c.SkipValidateRequest,
+		location:            c.GraphQLSchemaLocation,
+		schema:              schema,
+	}, nil
+}
+
+func (v *graphQLValidator) ValidateRequest(ctx context.Context, req *http.Request) error {
+	if v.skipValidateRequest || req.Body == nil {
+		return nil
+	}
+	if !strings.Contains(req.Header.Get("Content-Type"), "json") {
+		return nil
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(b))
+
+	var gr struct {
+		Query         *string        `json:"query"`
+		Variables     map[string]any `json:"variables"`
+		OperationName string         `json:"operationName"`
+	}
+	if err := json.Unmarshal(b, &gr); err != nil || gr.Query == nil {
+		// Not a GraphQL request.
+		return nil
+	}
+	if err := v.validate(*gr.Query, gr.OperationName, gr.Variables); err != nil {
+		dreq := req.Clone(ctx)
+		dreq.Body = io.NopCloser(bytes.NewReader(b))
+		d, errr := httputil.DumpRequest(dreq, true)
+		if errr != nil {
+			return fmt.Errorf("runn error: %w", errr)
+		}
+		return fmt.Errorf("graphql validation error: %w\n-----START HTTP REQUEST-----\n%s\n-----END HTTP REQUEST-----\n", err, string(d))
+	}
+	return nil
+}
+
+func (v *graphQLValidator) ValidateResponse(ctx context.Context, req *http.Request, res *http.Response) error {
+	// GraphQL responses are not validated against the schema.
+	return nil
+}
+
+func (v *graphQLValidator) validate(query, operationName string, variables map[string]any) error {
+	doc, errs := gqlparser.LoadQueryWithRules(v.schema, query, nil)
+	if len(errs) > 0 {
+		var err error
+		for _, e := range errs {
+			err = errors.Join(err, e)
+		}
+		return err
+	}
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		if operationName == "" {
+			return errors.New("operationName is required when the query contains multiple operations")
+		}
+		return fmt.Errorf("operation not found: %s", operationName)
+	}
+	if _, err := validator.VariableValues(v.schema, op, variables); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package runn
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGraphQLValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{
+			"valid query",
+			`{"query": "{ users { id name } }"}`,
+			false,
+		},
+		{
+			"valid query with variables",
+			`{"query": "query GetUser($id: ID!) { user(id: $id) { name } }", "variables": {"id": "1"}, "operationName": "GetUser"}`,
+			false,
+		},
+		{
+			"valid mutation",
+			`{"query": "mutation ($input: CreateUserInput!) { createUser(input: $input) { id } }", "variables": {"input": {"name": "alice"}}}`,
+			false,
+		},
+		{
+			"unknown field",
+			`{"query": "{ users { id password } }"}`,
+			true,
+		},
+		{
+			"syntax error",
+			`{"query": "{ users { id "}`,
+			true,
+		},
+		{
+			"missing required variable",
+			`{"query": "query GetUser($id: ID!) { user(id: $id) { name } }"}`,
+			true,
+		},
+		{
+			"invalid variable type",
+			`{"query": "mutation ($input: CreateUserInput!) { createUser(input: $input) { id } }", "variables": {"input": {"email": "alice@example.com"}}}`,
+			true,
+		},
+		{
+			"operation not found",
+			`{"query": "query A { users { id } } query B { users { name } }", "operationName": "C"}`,
+			true,
+		},
+		{
+			"multiple operations without operationName",
+			`{"query": "query A { users { id } } query B { users { name } }"}`,
+			true,
+		},
+		{
+			"not a GraphQL request",
+			`{"key": "value"}`,
+			false,
+		},
+	}
+	ctx := context.Background()
+	v, err := newGraphQLValidator(&httpRunnerConfig{
+		GraphQLSchemaLocation: "testdata/graphql/schema.graphql",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &http.Request{
+				Method: http.MethodPost,
+				URL:    pathToURL(t, "/graphql"),
+				Header: http.Header{"Content-Type": []string{"application/json"}},
+				Body:   io.NopCloser(strings.NewReader(tt.body)),
+			}
+			err := v.ValidateRequest(ctx, req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+			// The request body can be read again after validation.
+			b, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(b); got != tt.body {
+				t.Errorf("got %v\nwant %v", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestGraphQLValidatorInvalidSchema(t *testing.T) {
+	tests := []struct {
+		location string
+	}{
+		{"testdata/graphql/notexist.graphql"},
+		{"testdata/openapi3.yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			if _, err := newGraphQLValidator(&httpRunnerConfig{GraphQLSchemaLocation: tt.location}); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
@@ -135,9 +135,15 @@ func (rnr *grpcRunner) Run(ctx context.Context, s *step) error {
 	if err != nil {
 		return err
 	}
+	if o.fixture.replaying() {
+		return rnr.replay(req, s)
+	}
 	if err := rnr.run(ctx, req, s); err != nil {
 		return err
 	}
+	if err := o.fixture.takeErr(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -187,6 +193,70 @@ func (rnr *grpcRunner) run(ctx context.Context, r *grpcRequest, s *step) error {
 	}
 }
 
+// replay serves the recorded response from the fixture without connecting to the server.
+func (rnr *grpcRunner) replay(r *grpcRequest, s *step) error {
+	o := s.parent
+	var messages []map[string]any
+	for _, m := range r.messages {
+		if m.op != GRPCOpMessage {
+			continue
+		}
+		e, err := o.expandBeforeRecord(m.params, s)
+		if err != nil {
+			return err
+		}
+		msg, ok := e.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid message: %v", e)
+		}
+		messages = append(messages, msg)
+	}
+	fx, res, err := o.fixture.replayGRPC(rnr.name, r.service, r.method, messages)
+	if err != nil {
+		return err
+	}
+	stat := status.New(codes.Code(res.Status), res.Message) //nolint:gosec
+	headers := metadata.MD(res.Headers)
+	if headers == nil {
+		headers = metadata.MD{}
+	}
+	trailers := metadata.MD(res.Trailers)
+	if trailers == nil {
+		trailers = metadata.MD{}
+	}
+
+	o.capturers.captureGRPCStart(rnr.name, fx.Type, r.service, r.method)
+	defer o.capturers.captureGRPCEnd(rnr.name, fx.Type, r.service, r.method)
+	o.capturers.captureGRPCRequestHeaders(r.headers)
+	for _, m := range messages {
+		o.capturers.captureGRPCRequestMessage(m)
+	}
+	o.capturers.captureGRPCResponseStatus(stat)
+	o.capturers.captureGRPCResponseHeaders(headers)
+	for _, m := range res.Messages {
+		o.capturers.captureGRPCResponseMessage(m)
+	}
+	o.capturers.captureGRPCResponseTrailers(trailers)
+
+	d := map[string]any{
+		string(grpcStoreStatusKey):   res.Status,
+		string(grpcStoreHeaderKey):   headers,
+		string(grpcStoreTrailerKey):  trailers,
+		string(grpcStoreMessageKey):  nil,
+		string(grpcStoreMessagesKey): res.Messages,
+	}
+	switch {
+	case stat.Code() != codes.OK:
+		d[grpcStoreMessageKey] = stat.Message()
+	case len(res.Messages) > 0:
+		d[grpcStoreMessageKey] = res.Messages[len(res.Messages)-1]
+	}
+	o.record(s.idx, map[string]any{
+		string(grpcStoreResponseKey): d,
+	})
+	return nil
+}
+
 func (rnr *grpcRunner) connectAndResolve(ctx context.Context, o *operator) error {
 	if rnr.cc == nil {
 		opts := []grpc.DialOption{
@@ -119,19 +119,42 @@ func (r hostRules) dialTimeoutFunc() func(network, address string, timeout time.
 func (r hostRules) replaceDSN(dsn string) string { //nostyle:recvtype
 	u, err := dburl.Parse(dsn)
 	if err != nil {
-		return dsn
+		// DSN that is not for database ( e.g. redis:// )
+		uu, err := url.Parse(dsn)
+		if err != nil {
+			return dsn
+		}
+		h, ok := r.replaceHost(uu.Host)
+		if !ok {
+			return dsn
+		}
+		uu.Host = h
+		return uu.String()
 	}
-	if u.Host == "" {
+	h, ok := r.replaceHost(u.Host)
+	if !ok {
 		return dsn
 	}
-	var host, port string
-	if strings.Contains(u.Host, ":") {
-		host, port, err = net.SplitHostPort(u.Host)
+	u.Host = h
+	return u.String()
+}
+
+// replaceHost replaces host ( with port ) using the first matched rule.
+func (r hostRules) replaceHost(h string) (string, bool) { //nostyle:recvtype
+	if h == "" {
+		return "", false
+	}
+	var (
+		host, port string
+		err        error
+	)
+	if strings.Contains(h, ":") {
+		host, port, err = net.SplitHostPort(h)
 		if err != nil {
-			return dsn
+			return "", false
 		}
 	} else {
-		host = u.Host
+		host = h
 	}
 	for _, rule := range r {
 		if wildcard.MatchSimple(rule.host, host) {
@@ -139,21 +162,19 @@ func (r hostRules) replaceDSN(dsn string) string { //nostyle:recvtype
 			if strings.Contains(rule.rule, ":") {
 				rhost, rport, err = net.SplitHostPort(rule.rule)
 				if err != nil {
-					return dsn
+					return "", false
 				}
 			} else {
 				rhost = rule.rule
 				rport = port
 			}
 			if rport != "" {
-				u.Host = net.JoinHostPort(rhost, rport)
-			} else {
-				u.Host = rhost
+				return net.JoinHostPort(rhost, rport), true
 			}
-			return u.String()
+			return rhost, true
 		}
 	}
-	return dsn
+	return "", false
 }
 
 func parseDialTarget(target string) (string, string) {
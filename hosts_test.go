@@ -163,6 +163,20 @@ func TestReplaceDSN(t *testing.T) {
 			},
 			"spanner://other-project/test-instance/test-database",
 		},
+		{
+			"redis://:pass@redis.example.com:6379/0",
+			hostRules{
+				{"redis.example.com", "127.0.0.1:16379"},
+			},
+			"redis://:pass@127.0.0.1:16379/0",
+		},
+		{
+			"redis://redis.example.com/1",
+			hostRules{
+				{"other.example.com", "127.0.0.1"},
+			},
+			"redis://redis.example.com/1",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.dsn, func(t *testing.T) {
@@ -20,10 +20,12 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ajg/form"
 	"github.com/goccy/go-json"
+	"golang.org/x/net/http2"
 )
 
 const (
@@ -32,6 +34,14 @@ const (
 	MediaTypeApplicationFormUrlencoded = "application/x-www-form-urlencoded"
 	MediaTypeMultipartFormData         = "multipart/form-data"
 	MediaTypeApplicationOctetStream    = "application/octet-stream"
+	MediaTypeApplicationXML            = "application/xml"
+	MediaTypeTextXML                   = "text/xml"
+	MediaTypeApplicationNDJSON         = "application/x-ndjson"
+	MediaTypeApplicationProtobuf       = "application/protobuf"
+	MediaTypeApplicationXProtobuf      = "application/x-protobuf"
+	MediaTypeApplicationMsgpack        = "application/msgpack"
+	MediaTypeApplicationXMsgpack       = "application/x-msgpack"
+	MediaTypeApplicationCBOR           = "application/cbor"
 )
 
 const (
@@ -40,9 +50,19 @@ const (
 	httpStoreRawBodyKey  = "rawBody"
 	httpStoreHeaderKey   = "headers"
 	httpStoreCookieKey   = "cookies"
+	httpStoreErrorsKey   = "errors"
+	httpStoreProtoKey    = "proto"
+	httpStoreEventsKey   = "events"
 	httpStoreResponseKey = "res"
 )
 
+const (
+	graphQLQueryKey         = "query"
+	graphQLVariablesKey     = "variables"
+	graphQLOperationNameKey = "operationName"
+	graphQLErrorsKey        = "errors"
+)
+
 var notFollowRedirectFn = func(req *http.Request, via []*http.Request) error {
 	return http.ErrUseLastResponse
 }
@@ -61,6 +81,10 @@ type httpRunner struct {
 	useCookie         *bool
 	trace             *bool
 	traceHeaderName   string
+	// forceHTTP2 - Fail requests that are not done with HTTP/2.
+	forceHTTP2 bool
+	// auth - Authentication helper ( Basic, Digest, OAuth2, AWS SigV4 or HMAC ).
+	auth *httpAuth
 }
 
 type httpRequest struct {
@@ -71,6 +95,11 @@ type httpRequest struct {
 	body      any
 	useCookie *bool
 	trace     *bool
+	timeout   time.Duration
+	// graphql - The body is a GraphQL request ( query, variables and operationName ).
+	graphql bool
+	// stream - Read the response body incrementally as events.
+	stream *httpStream
 
 	multipartWriter   *multipart.Writer
 	multipartBoundary string
@@ -109,6 +138,90 @@ func newHTTPRunnerWithHandler(name string, h http.Handler) (*httpRunner, error)
 	}, nil
 }
 
+// setTransport applies the transport options of c to the client.
+func (rnr *httpRunner) setTransport(c *httpRunnerConfig) error {
+	poolOpts := c.MaxIdleConns > 0 || c.MaxIdleConnsPerHost > 0 || c.MaxConnsPerHost > 0 || c.DisableKeepAlives
+	if !c.HTTP2 && c.Proxy == "" && !poolOpts {
+		return nil
+	}
+	if rnr.client == nil {
+		return errors.New("transport options are not supported by the HTTP runner with http.Handler")
+	}
+	// Do not modify the client and the transport passed from outside.
+	client := *rnr.client
+	rnr.client = &client
+	rnr.forceHTTP2 = c.HTTP2
+	if c.HTTP2 && rnr.endpoint != nil && rnr.endpoint.Scheme == "http" {
+		if c.Proxy != "" || poolOpts {
+			return errors.New("proxy and connection pool options cannot be used with HTTP/2 over plaintext (h2c)")
+		}
+		rnr.client.Transport = &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		}
+		return nil
+	}
+	var ts *http.Transport
+	switch v := rnr.client.Transport.(type) {
+	case nil:
+		tp, ok := http.DefaultTransport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("failed to cast: %v", http.DefaultTransport)
+		}
+		ts = tp.Clone()
+	case *http.Transport:
+		ts = v.Clone()
+	default:
+		return fmt.Errorf("could not set transport options: interface conversion error: http.RoundTripper is %#v, not *http.Transport", rnr.client.Transport)
+	}
+	if c.HTTP2 {
+		ts.ForceAttemptHTTP2 = true
+	}
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("unsupported proxy scheme: %s", c.Proxy)
+		}
+		ts.Proxy = http.ProxyURL(u)
+	}
+	if c.MaxIdleConns > 0 {
+		ts.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost > 0 {
+		ts.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.MaxConnsPerHost > 0 {
+		ts.MaxConnsPerHost = c.MaxConnsPerHost
+	}
+	ts.DisableKeepAlives = c.DisableKeepAlives
+	rnr.client.Transport = ts
+	return nil
+}
+
+// setDialContext sets the function to dial the connections ( e.g. for hostRules ) to the transport.
+func (rnr *httpRunner) setDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) error {
+	switch tp := rnr.client.Transport.(type) {
+	case *http.Transport:
+		tp.DialContext = dial
+	case *http2.Transport:
+		// HTTP/2 over plaintext (h2c) dials the connections with DialTLSContext.
+		tp.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		}
+	default:
+		return fmt.Errorf("failed to cast: %v", rnr.client.Transport)
+	}
+	return nil
+}
+
 func (r *httpRequest) validate() error {
 	switch r.method {
 	case http.MethodPost, http.MethodPatch:
@@ -122,8 +235,15 @@ func (r *httpRequest) validate() error {
 	if r.isMultipartFormDataMediaType() {
 		return nil
 	}
-	switch r.mediaType {
-	case MediaTypeApplicationJSON, MediaTypeTextPlain, MediaTypeApplicationFormUrlencoded, MediaTypeApplicationOctetStream, "":
+	mt, params := parseMediaType(r.mediaType)
+	if _, ok := protobufMessageType(params); !ok && isProtobufMediaType(mt) {
+		return fmt.Errorf("%s requires %s parameter ( e.g. %s; %s=pkg.Message )", mt, protobufMessageTypeParam, mt, protobufMessageTypeParam)
+	}
+	switch mt {
+	case MediaTypeApplicationJSON, MediaTypeTextPlain, MediaTypeApplicationFormUrlencoded, MediaTypeApplicationOctetStream, "",
+		MediaTypeApplicationXML, MediaTypeTextXML, MediaTypeApplicationNDJSON,
+		MediaTypeApplicationProtobuf, MediaTypeApplicationXProtobuf,
+		MediaTypeApplicationMsgpack, MediaTypeApplicationXMsgpack, MediaTypeApplicationCBOR:
 	default:
 		return fmt.Errorf("unsupported mediaType: %s", r.mediaType)
 	}
@@ -137,7 +257,8 @@ func (r *httpRequest) encodeBody() (io.Reader, error) {
 	if r.isMultipartFormDataMediaType() {
 		return r.encodeMultipart()
 	}
-	switch r.mediaType {
+	mt, params := parseMediaType(r.mediaType)
+	switch mt {
 	case MediaTypeApplicationJSON:
 		b, err := json.Marshal(r.body)
 		if err != nil {
@@ -200,6 +321,37 @@ func (r *httpRequest) encodeBody() (io.Reader, error) {
 			return nil, fmt.Errorf("invalid body: %v", r.body)
 		}
 		return strings.NewReader(s), nil
+	case MediaTypeApplicationXML, MediaTypeTextXML:
+		b, err := encodeXML(r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case MediaTypeApplicationNDJSON:
+		b, err := encodeNDJSON(r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case MediaTypeApplicationProtobuf, MediaTypeApplicationXProtobuf:
+		name, _ := protobufMessageType(params)
+		b, err := encodeProtobuf(name, r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case MediaTypeApplicationMsgpack, MediaTypeApplicationXMsgpack:
+		b, err := encodeMsgpack(r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case MediaTypeApplicationCBOR:
+		b, err := encodeCBOR(r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
 	default:
 		return nil, fmt.Errorf("unsupported mediaType: %s", r.mediaType)
 	}
@@ -394,8 +546,17 @@ func (rnr *httpRunner) Run(ctx context.Context, s *step) error {
 
 func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 	o := s.parent
+	if rnr.handler != nil && r.timeout > 0 {
+		// http.Handler is called directly, so it cannot be interrupted.
+		return errors.New("timeout of the request is not supported by the HTTP runner with http.Handler")
+	}
 	r.multipartBoundary = rnr.multipartBoundary
 	r.root = o.root
+	if mt, _ := parseMediaType(r.mediaType); isProtobufMediaType(mt) {
+		if err := o.loadGRPCProtos(ctx); err != nil {
+			return err
+		}
+	}
 	reqBody, err := r.encodeBody()
 	if err != nil {
 		return err
@@ -417,6 +578,28 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 		return err
 	}
 
+	var timedOut atomic.Bool
+	if r.stream != nil {
+		timeout := r.stream.timeout
+		if timeout == 0 {
+			timeout = r.timeout
+		}
+		if timeout == 0 && rnr.client != nil {
+			timeout = rnr.client.Timeout
+		}
+		if timeout == 0 {
+			timeout = httpStreamDefaultTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		t := time.AfterFunc(timeout, func() {
+			timedOut.Store(true)
+			cancel()
+		})
+		defer t.Stop()
+	}
+
 	var (
 		req *http.Request
 		res *http.Response
@@ -466,6 +649,20 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 			}
 			ts.TLSClientConfig.Certificates = []tls.Certificate{cert}
 		}
+		// Copy the client after setting the transport so that the copy uses the same transport.
+		client := rnr.client
+		switch {
+		case r.stream != nil:
+			// The timeout of the stream is applied instead
+			c := *rnr.client
+			c.Timeout = 0
+			client = &c
+		case r.timeout > 0:
+			// Override the timeout of the runner
+			c := *rnr.client
+			c.Timeout = r.timeout
+			client = &c
+		}
 
 		u, err := mergeURL(rnr.endpoint, r.path)
 		if err != nil {
@@ -493,13 +690,27 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 			return err
 		}
 
-		res, err = rnr.client.Do(req)
+		switch {
+		case o.fixture.replaying():
+			res, err = o.fixture.replayHTTP(rnr.name, req)
+		case rnr.auth != nil:
+			res, err = rnr.auth.do(o.maskRule, rnr.client, req, client.Do)
+		default:
+			res, err = client.Do(req)
+		}
 		if err != nil {
 			return err
 		}
 		defer res.Body.Close()
+		if rnr.forceHTTP2 && res.ProtoMajor != 2 {
+			return fmt.Errorf("HTTP/2 is forced but the response is %s", res.Proto)
+		}
 	case rnr.handler != nil:
 		req = httptest.NewRequest(r.method, r.path, reqBody)
+		if r.stream != nil {
+			// The handler can stop writing the stream by the context
+			req = req.WithContext(ctx)
+		}
 		if r.mediaType != "" {
 			req.Header.Set("Content-Type", r.mediaType)
 		}
@@ -518,15 +729,42 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 		if err := rnr.validator.ValidateRequest(ctx, req); err != nil {
 			return err
 		}
-		w := httptest.NewRecorder()
-		rnr.handler.ServeHTTP(w, req)
-		res = w.Result()
+		serve := func(req *http.Request) (*http.Response, error) {
+			w := httptest.NewRecorder()
+			rnr.handler.ServeHTTP(w, req)
+			return w.Result(), nil
+		}
+		switch {
+		case o.fixture.replaying():
+			res, err = o.fixture.replayHTTP(rnr.name, req)
+		case rnr.auth != nil:
+			res, err = rnr.auth.do(o.maskRule, nil, req, serve)
+		default:
+			res, err = serve(req)
+		}
+		if err != nil {
+			return err
+		}
 		defer res.Body.Close()
 	default:
 		return fmt.Errorf("invalid http runner: %s", rnr.name)
 	}
 
+	var events []map[string]any
+	if r.stream != nil {
+		// Capturers read the whole body, so the response is captured after the stream is read.
+		var b []byte
+		b, events, err = rnr.readStream(res, r.stream, s, timedOut.Load)
+		if err != nil {
+			return err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
 	o.capturers.captureHTTPResponse(rnr.name, res)
+	if err := o.fixture.takeErr(); err != nil {
+		return err
+	}
 
 	if err := rnr.validator.ValidateResponse(ctx, req, res); err != nil {
 		var target *UnsupportedError
@@ -544,9 +782,15 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 
 	d := map[string]any{}
 	d[httpStoreStatusKey] = res.StatusCode
-	if strings.Contains(res.Header.Get("Content-Type"), "json") && len(resBody) > 0 {
-		var b any
-		if err := json.Unmarshal(resBody, &b); err != nil {
+	if len(resBody) > 0 && r.stream == nil {
+		ct := res.Header.Get("Content-Type")
+		if mt, _ := parseMediaType(ct); isProtobufMediaType(mt) {
+			if err := o.loadGRPCProtos(ctx); err != nil {
+				return err
+			}
+		}
+		b, err := decodeResponseBody(ct, resBody)
+		if err != nil {
 			return err
 		}
 		d[httpStoreBodyKey] = b
@@ -555,6 +799,17 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 	}
 	d[httpStoreRawBodyKey] = string(resBody)
 	d[httpStoreHeaderKey] = res.Header
+	d[httpStoreProtoKey] = res.Proto
+	if r.stream != nil {
+		d[httpStoreEventsKey] = events
+	}
+	if r.graphql {
+		// Surface top-level errors of GraphQL response.
+		d[httpStoreErrorsKey] = nil
+		if b, ok := d[httpStoreBodyKey].(map[string]any); ok {
+			d[httpStoreErrorsKey] = b[graphQLErrorsKey]
+		}
+	}
 
 	cookies := res.Cookies()
 
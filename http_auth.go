@@ -0,0 +1,746 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5" //#nosec G501
+	"crypto/rand"
+	"crypto/sha1" //#nosec G505
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k1LoW/maskedio"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	httpOAuth2GrantTypeClientCredentials = "client_credentials"
+	httpOAuth2GrantTypePassword          = "password"
+)
+
+const (
+	httpHMACDefaultCanonical       = "{method}\n{path}\n{query}\n{timestamp}\n{bodySHA256}"
+	httpHMACDefaultHeader          = "X-Signature"
+	httpHMACDefaultTimestampHeader = "X-Timestamp"
+)
+
+const awsSigV4Algorithm = "AWS4-HMAC-SHA256"
+
+var httpHMACPlaceholderRe = regexp.MustCompile(`\{([a-zA-Z0-9]+)(?::([^{}]+))?\}`)
+
+type httpSendFunc func(req *http.Request) (*http.Response, error)
+
+type httpAuthenticator interface {
+	// secrets returns the credentials to be masked.
+	secrets() []string
+	// do sends req with the credentials.
+	do(req *http.Request, body []byte, client *http.Client, send httpSendFunc, mask func(string)) (*http.Response, error)
+}
+
+// httpAuth authenticates requests of the HTTP runner and masks the credentials.
+type httpAuth struct {
+	authenticator httpAuthenticator
+	// masked - Keywords already set to each mask rule.
+	masked map[*maskedio.Rule]map[string]struct{}
+	mu     sync.Mutex
+}
+
+func newHTTPAuth(c *httpAuthConfig) (*httpAuth, error) {
+	var (
+		a   httpAuthenticator
+		err error
+		n   int
+	)
+	if c.Basic != nil {
+		n++
+		a, err = newHTTPBasicAuth(c.Basic)
+	}
+	if c.Digest != nil {
+		n++
+		a, err = newHTTPDigestAuth(c.Digest)
+	}
+	if c.OAuth2 != nil {
+		n++
+		a, err = newHTTPOAuth2Auth(c.OAuth2)
+	}
+	if c.AWSSigV4 != nil {
+		n++
+		a, err = newHTTPAWSSigV4Auth(c.AWSSigV4)
+	}
+	if c.HMAC != nil {
+		n++
+		a, err = newHTTPHMACAuth(c.HMAC)
+	}
+	switch {
+	case n == 0:
+		return nil, errors.New("invalid auth: one of basic, digest, oauth2, awsSigV4 or hmac is required")
+	case n > 1:
+		return nil, errors.New("invalid auth: only one of basic, digest, oauth2, awsSigV4 or hmac can be set")
+	case err != nil:
+		return nil, fmt.Errorf("invalid auth: %w", err)
+	}
+	return &httpAuth{
+		authenticator: a,
+		masked:        map[*maskedio.Rule]map[string]struct{}{},
+	}, nil
+}
+
+// setAuth sets the authentication helper of c to the runner.
+func (rnr *httpRunner) setAuth(c *httpRunnerConfig) error {
+	if c.Auth == nil {
+		return nil
+	}
+	a, err := newHTTPAuth(c.Auth)
+	if err != nil {
+		return err
+	}
+	rnr.auth = a
+	return nil
+}
+
+// do sends req with the credentials using send.
+func (a *httpAuth) do(mr *maskedio.Rule, client *http.Client, req *http.Request, send httpSendFunc) (*http.Response, error) {
+	mask := func(s string) {
+		if mr == nil || s == "" {
+			return
+		}
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if _, ok := a.masked[mr]; !ok {
+			a.masked[mr] = map[string]struct{}{}
+		}
+		if _, ok := a.masked[mr][s]; ok {
+			return
+		}
+		a.masked[mr][s] = struct{}{}
+		mr.SetKeyword(s)
+	}
+	for _, s := range a.authenticator.secrets() {
+		mask(s)
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return a.authenticator.do(req, body, client, send, mask)
+}
+
+// httpBasicAuth is the Basic authentication.
+type httpBasicAuth struct {
+	username string
+	password string
+}
+
+func newHTTPBasicAuth(c *httpBasicAuthConfig) (*httpBasicAuth, error) {
+	if c.Username == "" {
+		return nil, errors.New("basic: username is required")
+	}
+	return &httpBasicAuth{username: c.Username, password: c.Password}, nil
+}
+
+func (a *httpBasicAuth) secrets() []string {
+	return []string{
+		a.password,
+		base64.StdEncoding.EncodeToString([]byte(a.username + ":" + a.password)),
+	}
+}
+
+func (a *httpBasicAuth) do(req *http.Request, _ []byte, _ *http.Client, send httpSendFunc, _ func(string)) (*http.Response, error) {
+	req.SetBasicAuth(a.username, a.password)
+	return send(req)
+}
+
+// httpDigestAuth is the Digest authentication ( RFC 7616 ).
+type httpDigestAuth struct {
+	username string
+	password string
+	// challenge - The last challenge from the server.
+	challenge *digestChallenge
+	// nc - The nonce count of the challenge.
+	nc int
+	mu sync.Mutex
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+}
+
+func newHTTPDigestAuth(c *httpBasicAuthConfig) (*httpDigestAuth, error) {
+	if c.Username == "" {
+		return nil, errors.New("digest: username is required")
+	}
+	return &httpDigestAuth{username: c.Username, password: c.Password}, nil
+}
+
+func (a *httpDigestAuth) secrets() []string {
+	return []string{a.password}
+}
+
+func (a *httpDigestAuth) do(req *http.Request, body []byte, _ *http.Client, send httpSendFunc, _ func(string)) (*http.Response, error) {
+	// Authorize preemptively with the cached challenge.
+	if err := a.authorize(req, body); err != nil {
+		return nil, err
+	}
+	res, err := send(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusUnauthorized {
+		return res, nil
+	}
+	ch, err := parseDigestChallenge(res.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		// Not a Digest challenge.
+		return res, nil //nolint:nilerr
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+	a.mu.Lock()
+	a.challenge = ch
+	a.nc = 0
+	a.mu.Unlock()
+
+	retry := req.Clone(req.Context())
+	retry.Body = io.NopCloser(bytes.NewReader(body))
+	if err := a.authorize(retry, body); err != nil {
+		return nil, err
+	}
+	return send(retry)
+}
+
+func (a *httpDigestAuth) authorize(req *http.Request, body []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.challenge == nil {
+		return nil
+	}
+	a.nc++
+	cnonce, err := digestCnonce()
+	if err != nil {
+		return err
+	}
+	v, err := digestAuthorization(a.challenge, a.username, a.password, req.Method, req.URL.RequestURI(), body, a.nc, cnonce)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", v)
+	return nil
+}
+
+func digestCnonce() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func parseDigestChallenge(v string) (*digestChallenge, error) {
+	s, ok := strings.CutPrefix(strings.TrimSpace(v), "Digest ")
+	if !ok {
+		return nil, fmt.Errorf("not a digest challenge: %s", v)
+	}
+	params := parseAuthParams(s)
+	ch := &digestChallenge{
+		realm:     params["realm"],
+		nonce:     params["nonce"],
+		opaque:    params["opaque"],
+		algorithm: params["algorithm"],
+	}
+	if ch.nonce == "" {
+		return nil, fmt.Errorf("nonce not found in digest challenge: %s", v)
+	}
+	if qop, ok := params["qop"]; ok {
+		// Prefer auth to auth-int.
+		qops := strings.Split(qop, ",")
+		for _, q := range qops {
+			if strings.TrimSpace(q) == "auth" {
+				ch.qop = "auth"
+				break
+			}
+		}
+		if ch.qop == "" {
+			ch.qop = strings.TrimSpace(qops[0])
+		}
+	}
+	return ch, nil
+}
+
+// parseAuthParams parses comma-separated auth-params such as `realm="a", qop="auth,auth-int"`.
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for s != "" {
+		s = strings.TrimLeft(s, " \t,")
+		k, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		k = strings.ToLower(strings.TrimSpace(k))
+		rest = strings.TrimLeft(rest, " \t")
+		var v string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest); i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+					b.WriteByte(rest[i])
+					continue
+				}
+				if rest[i] == '"' {
+					break
+				}
+				b.WriteByte(rest[i])
+			}
+			v = b.String()
+			if i < len(rest) {
+				i++
+			}
+			s = rest[i:]
+		} else {
+			v, s, _ = strings.Cut(rest, ",")
+			v = strings.TrimSpace(v)
+		}
+		params[k] = v
+	}
+	return params
+}
+
+func digestAuthorization(ch *digestChallenge, username, password, method, uri string, body []byte, nc int, cnonce string) (string, error) {
+	var h func() hash.Hash
+	algorithm := strings.ToUpper(ch.algorithm)
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "", "MD5":
+		h = md5.New
+	case "SHA-256":
+		h = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm: %s", ch.algorithm)
+	}
+	hs := func(s string) string {
+		hh := h()
+		_, _ = hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+	ha1 := hs(username + ":" + ch.realm + ":" + password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = hs(ha1 + ":" + ch.nonce + ":" + cnonce)
+	}
+	var ha2 string
+	switch ch.qop {
+	case "", "auth":
+		ha2 = hs(method + ":" + uri)
+	case "auth-int":
+		ha2 = hs(method + ":" + uri + ":" + hs(string(body)))
+	default:
+		return "", fmt.Errorf("unsupported digest qop: %s", ch.qop)
+	}
+	ncs := fmt.Sprintf("%08x", nc)
+	var response string
+	if ch.qop == "" {
+		response = hs(ha1 + ":" + ch.nonce + ":" + ha2)
+	} else {
+		response = hs(ha1 + ":" + ch.nonce + ":" + ncs + ":" + cnonce + ":" + ch.qop + ":" + ha2)
+	}
+	params := []string{
+		fmt.Sprintf("username=%q", username),
+		fmt.Sprintf("realm=%q", ch.realm),
+		fmt.Sprintf("nonce=%q", ch.nonce),
+		fmt.Sprintf("uri=%q", uri),
+	}
+	if ch.algorithm != "" {
+		params = append(params, "algorithm="+ch.algorithm)
+	}
+	params = append(params, fmt.Sprintf("response=%q", response))
+	if ch.qop != "" {
+		params = append(params, "qop="+ch.qop, "nc="+ncs, fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	if ch.opaque != "" {
+		params = append(params, fmt.Sprintf("opaque=%q", ch.opaque))
+	}
+	return "Digest " + strings.Join(params, ", "), nil
+}
+
+// httpOAuth2Auth is the OAuth2 client credentials grant or resource owner password credentials grant.
+// The token is cached and refreshed.
+type httpOAuth2Auth struct {
+	c    *httpOAuth2Config
+	ts   oauth2.TokenSource
+	once sync.Once
+}
+
+func newHTTPOAuth2Auth(c *httpOAuth2Config) (*httpOAuth2Auth, error) {
+	if c.TokenURL == "" {
+		return nil, errors.New("oauth2: tokenURL is required")
+	}
+	cc := *c
+	if cc.GrantType == "" {
+		cc.GrantType = httpOAuth2GrantTypeClientCredentials
+		if cc.Username != "" {
+			cc.GrantType = httpOAuth2GrantTypePassword
+		}
+	}
+	switch cc.GrantType {
+	case httpOAuth2GrantTypeClientCredentials:
+		if cc.ClientID == "" {
+			return nil, errors.New("oauth2: clientID is required for client_credentials grant")
+		}
+	case httpOAuth2GrantTypePassword:
+		if cc.Username == "" {
+			return nil, errors.New("oauth2: username is required for password grant")
+		}
+		if len(cc.Params) > 0 {
+			return nil, errors.New("oauth2: params is not supported for password grant")
+		}
+	default:
+		return nil, fmt.Errorf("oauth2: unsupported grantType: %s", cc.GrantType)
+	}
+	return &httpOAuth2Auth{c: &cc}, nil
+}
+
+func (a *httpOAuth2Auth) secrets() []string {
+	return []string{a.c.ClientSecret, a.c.Password}
+}
+
+func (a *httpOAuth2Auth) do(req *http.Request, _ []byte, client *http.Client, send httpSendFunc, mask func(string)) (*http.Response, error) {
+	tok, err := a.tokenSource(client).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth2 token: %w", err)
+	}
+	mask(tok.AccessToken)
+	mask(tok.RefreshToken)
+	tok.SetAuthHeader(req)
+	return send(req)
+}
+
+func (a *httpOAuth2Auth) tokenSource(client *http.Client) oauth2.TokenSource {
+	a.once.Do(func() {
+		ctx := context.Background()
+		if client != nil {
+			// Request the token with the same transport as the runner.
+			ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+		}
+		switch a.c.GrantType {
+		case httpOAuth2GrantTypeClientCredentials:
+			params := url.Values{}
+			for k, v := range a.c.Params {
+				params.Set(k, v)
+			}
+			cfg := &clientcredentials.Config{
+				ClientID:       a.c.ClientID,
+				ClientSecret:   a.c.ClientSecret,
+				TokenURL:       a.c.TokenURL,
+				Scopes:         a.c.Scopes,
+				EndpointParams: params,
+			}
+			a.ts = cfg.TokenSource(ctx)
+		case httpOAuth2GrantTypePassword:
+			cfg := &oauth2.Config{
+				ClientID:     a.c.ClientID,
+				ClientSecret: a.c.ClientSecret,
+				Endpoint:     oauth2.Endpoint{TokenURL: a.c.TokenURL},
+				Scopes:       a.c.Scopes,
+			}
+			a.ts = oauth2.ReuseTokenSource(nil, &oauth2PasswordTokenSource{
+				ctx:      ctx,
+				cfg:      cfg,
+				username: a.c.Username,
+				password: a.c.Password,
+			})
+		}
+	})
+	return a.ts
+}
+
+// oauth2PasswordTokenSource gets the token by the password grant and refreshes it with the refresh token if possible.
+type oauth2PasswordTokenSource struct {
+	ctx      context.Context
+	cfg      *oauth2.Config
+	username string
+	password string
+	last     *oauth2.Token
+}
+
+func (s *oauth2PasswordTokenSource) Token() (*oauth2.Token, error) {
+	if s.last != nil && s.last.RefreshToken != "" {
+		tok, err := s.cfg.TokenSource(s.ctx, &oauth2.Token{RefreshToken: s.last.RefreshToken}).Token()
+		if err == nil {
+			s.last = tok
+			return tok, nil
+		}
+	}
+	tok, err := s.cfg.PasswordCredentialsToken(s.ctx, s.username, s.password)
+	if err != nil {
+		return nil, err
+	}
+	s.last = tok
+	return tok, nil
+}
+
+// httpAWSSigV4Auth signs requests with AWS Signature Version 4.
+type httpAWSSigV4Auth struct {
+	c *httpAWSSigV4Config
+}
+
+func newHTTPAWSSigV4Auth(c *httpAWSSigV4Config) (*httpAWSSigV4Auth, error) {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, errors.New("awsSigV4: accessKeyID and secretAccessKey are required")
+	}
+	if c.Region == "" || c.Service == "" {
+		return nil, errors.New("awsSigV4: region and service are required")
+	}
+	return &httpAWSSigV4Auth{c: c}, nil
+}
+
+func (a *httpAWSSigV4Auth) secrets() []string {
+	return []string{a.c.SecretAccessKey, a.c.SessionToken}
+}
+
+func (a *httpAWSSigV4Auth) do(req *http.Request, body []byte, _ *http.Client, send httpSendFunc, _ func(string)) (*http.Response, error) {
+	signAWSSigV4(req, body, a.c, time.Now())
+	return send(req)
+}
+
+func signAWSSigV4(req *http.Request, body []byte, c *httpAWSSigV4Config, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+	if c.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if !strings.HasPrefix(lk, "x-amz-") && lk != "content-type" {
+			continue
+		}
+		vs := make([]string, 0, len(v))
+		for _, vv := range v {
+			vs = append(vs, strings.Join(strings.Fields(vv), " "))
+		}
+		headers[lk] = strings.Join(vs, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalURI(req.URL, c.Service != "s3"),
+		awsCanonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{date, c.Region, c.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{awsSigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	k := hmacSum(sha256.New, []byte("AWS4"+c.SecretAccessKey), []byte(date))
+	k = hmacSum(sha256.New, k, []byte(c.Region))
+	k = hmacSum(sha256.New, k, []byte(c.Service))
+	k = hmacSum(sha256.New, k, []byte("aws4_request"))
+	signature := hex.EncodeToString(hmacSum(sha256.New, k, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", awsSigV4Algorithm, c.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func awsCanonicalURI(u *url.URL, doubleEscape bool) string {
+	p := u.Path
+	if p == "" {
+		return "/"
+	}
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		s = awsURIEscape(s)
+		if doubleEscape {
+			s = awsURIEscape(s)
+		}
+		segs[i] = s
+	}
+	return strings.Join(segs, "/")
+}
+
+func awsCanonicalQuery(u *url.URL) string {
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var kvs []string
+	for _, k := range keys {
+		vs := append([]string{}, q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			kvs = append(kvs, awsURIEscape(k)+"="+awsURIEscape(v))
+		}
+	}
+	return strings.Join(kvs, "&")
+}
+
+// awsURIEscape escapes s except for the unreserved characters of RFC 3986.
+func awsURIEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// httpHMACAuth signs requests with HMAC of the canonical string.
+type httpHMACAuth struct {
+	c *httpHMACConfig
+	h func() hash.Hash
+}
+
+func newHTTPHMACAuth(c *httpHMACConfig) (*httpHMACAuth, error) {
+	if c.Key == "" {
+		return nil, errors.New("hmac: key is required")
+	}
+	cc := *c
+	if cc.Canonical == "" {
+		cc.Canonical = httpHMACDefaultCanonical
+	}
+	if cc.Header == "" {
+		cc.Header = httpHMACDefaultHeader
+	}
+	if cc.TimestampHeader == "" {
+		cc.TimestampHeader = httpHMACDefaultTimestampHeader
+	}
+	a := &httpHMACAuth{c: &cc}
+	switch strings.ToLower(cc.Algorithm) {
+	case "", "sha256":
+		a.h = sha256.New
+	case "sha1":
+		a.h = sha1.New
+	case "sha512":
+		a.h = sha512.New
+	default:
+		return nil, fmt.Errorf("hmac: unsupported algorithm: %s", cc.Algorithm)
+	}
+	switch cc.Encoding {
+	case "", "hex", "base64":
+	default:
+		return nil, fmt.Errorf("hmac: unsupported encoding: %s", cc.Encoding)
+	}
+	for _, m := range httpHMACPlaceholderRe.FindAllStringSubmatch(cc.Canonical, -1) {
+		switch m[1] {
+		case "method", "host", "path", "query", "timestamp", "body", "bodySHA256":
+		case "header":
+			if m[2] == "" {
+				return nil, fmt.Errorf("hmac: invalid placeholder: %s", m[0])
+			}
+		default:
+			return nil, fmt.Errorf("hmac: unknown placeholder: %s", m[0])
+		}
+	}
+	return a, nil
+}
+
+func (a *httpHMACAuth) secrets() []string {
+	return []string{a.c.Key}
+}
+
+func (a *httpHMACAuth) do(req *http.Request, body []byte, _ *http.Client, send httpSendFunc, _ func(string)) (*http.Response, error) {
+	a.sign(req, body, time.Now())
+	return send(req)
+}
+
+func (a *httpHMACAuth) sign(req *http.Request, body []byte, now time.Time) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	if strings.Contains(a.c.Canonical, "{timestamp}") {
+		req.Header.Set(a.c.TimestampHeader, ts)
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	canonical := httpHMACPlaceholderRe.ReplaceAllStringFunc(a.c.Canonical, func(s string) string {
+		m := httpHMACPlaceholderRe.FindStringSubmatch(s)
+		switch m[1] {
+		case "method":
+			return req.Method
+		case "host":
+			return host
+		case "path":
+			return req.URL.EscapedPath()
+		case "query":
+			return req.URL.RawQuery
+		case "timestamp":
+			return ts
+		case "body":
+			return string(body)
+		case "bodySHA256":
+			return sha256Hex(body)
+		case "header":
+			return req.Header.Get(m[2])
+		}
+		return s
+	})
+	sum := hmacSum(a.h, []byte(a.c.Key), []byte(canonical))
+	var sig string
+	if a.c.Encoding == "base64" {
+		sig = base64.StdEncoding.EncodeToString(sum)
+	} else {
+		sig = hex.EncodeToString(sum)
+	}
+	req.Header.Set(a.c.Header, a.c.Prefix+sig)
+}
+
+func hmacSum(h func() hash.Hash, key, data []byte) []byte {
+	mac := hmac.New(h, key)
+	_, _ = mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func sha256Hex(b []byte) string {
+	s := sha256.Sum256(b)
+	return hex.EncodeToString(s[:])
+}
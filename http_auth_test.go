@@ -0,0 +1,241 @@
+package runn
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+func TestDigestAuthorization(t *testing.T) {
+	// RFC 2617 3.5 Example
+	ch, err := parseDigestChallenge(`Digest realm="testrealm@host.com", qop="auth,auth-int", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", opaque="5ccc069c403ebaf9f0171e9517f40e41"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := digestAuthorization(ch, "Mufasa", "Circle Of Life", http.MethodGet, "/dir/index.html", nil, 1, "0a4f113b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Digest username="Mufasa", realm="testrealm@host.com", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", uri="/dir/index.html", response="6629fae49393a05397450978507c4ef1", qop=auth, nc=00000001, cnonce="0a4f113b", opaque="5ccc069c403ebaf9f0171e9517f40e41"`
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestSignAWSSigV4(t *testing.T) {
+	// get-vanilla of the AWS Signature Version 4 test suite
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &httpAWSSigV4Config{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	signAWSSigV4(req, nil, c, now)
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestHTTPHMACSign(t *testing.T) {
+	sum := func(s string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write([]byte(s))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	bodySum := sha256.Sum256([]byte(`{"a":1}`))
+	tests := []struct {
+		c          *httpHMACConfig
+		wantHeader string
+		want       string
+	}{
+		{
+			&httpHMACConfig{Key: "secret"},
+			"X-Signature",
+			sum("POST\n/users\nq=1\n1700000000\n" + hex.EncodeToString(bodySum[:])),
+		},
+		{
+			&httpHMACConfig{Key: "secret", Canonical: "{method} {host}{path} {header:X-Id} {body}", Header: "Authorization", Prefix: "HMAC "},
+			"Authorization",
+			"HMAC " + sum(`POST example.com/users abc {"a":1}`),
+		},
+	}
+	for _, tt := range tests {
+		a, err := newHTTPHMACAuth(tt.c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, "https://example.com/users?q=1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Id", "abc")
+		a.sign(req, []byte(`{"a":1}`), time.Unix(1700000000, 0))
+		if got := req.Header.Get(tt.wantHeader); got != tt.want {
+			t.Errorf("got %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestNewHTTPAuth(t *testing.T) {
+	tests := []struct {
+		c       *httpAuthConfig
+		wantErr bool
+	}{
+		{&httpAuthConfig{Basic: &httpBasicAuthConfig{Username: "alice", Password: "pass"}}, false},
+		{&httpAuthConfig{}, true},
+		{&httpAuthConfig{Basic: &httpBasicAuthConfig{Username: "alice"}, Digest: &httpBasicAuthConfig{Username: "alice"}}, true},
+		{&httpAuthConfig{OAuth2: &httpOAuth2Config{TokenURL: "https://example.com/token", ClientID: "client"}}, false},
+		{&httpAuthConfig{OAuth2: &httpOAuth2Config{ClientID: "client"}}, true},
+		{&httpAuthConfig{OAuth2: &httpOAuth2Config{TokenURL: "https://example.com/token", GrantType: "implicit"}}, true},
+		{&httpAuthConfig{AWSSigV4: &httpAWSSigV4Config{AccessKeyID: "id", SecretAccessKey: "secret"}}, true},
+		{&httpAuthConfig{HMAC: &httpHMACConfig{Key: "secret", Algorithm: "md5"}}, true},
+		{&httpAuthConfig{HMAC: &httpHMACConfig{Key: "secret", Canonical: "{method}\n{unknown}"}}, true},
+	}
+	for _, tt := range tests {
+		_, err := newHTTPAuth(tt.c)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%#v: got %v, wantErr %v", tt.c, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHTTPRunnerAuth(t *testing.T) {
+	var tokenRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		token := "token-" + r.Form.Get("grant_type")
+		if r.Form.Get("grant_type") == "password" && (r.Form.Get("username") != "alice" || r.Form.Get("password") != "pass") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": token,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("/digest", func(w http.ResponseWriter, r *http.Request) {
+		ch := &digestChallenge{realm: "runn", nonce: "abcdef", qop: "auth", opaque: "xyz"}
+		got := r.Header.Get("Authorization")
+		params := parseAuthParams(strings.TrimPrefix(got, "Digest "))
+		nc := 0
+		for _, c := range params["nc"] {
+			nc = nc*16 + int(c-'0')
+		}
+		want, err := digestAuthorization(ch, "alice", "pass", r.Method, r.URL.RequestURI(), nil, nc, params["cnonce"])
+		if err != nil || got != want {
+			w.Header().Set("WWW-Authenticate", `Digest realm="runn", nonce="abcdef", qop="auth", opaque="xyz"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		name       string
+		c          *httpAuthConfig
+		path       string
+		wantBody   string
+		wantMasked []string
+	}{
+		{
+			"basic",
+			&httpAuthConfig{Basic: &httpBasicAuthConfig{Username: "alice", Password: "pass"}},
+			"/",
+			"Basic YWxpY2U6cGFzcw==",
+			[]string{"pass", "YWxpY2U6cGFzcw=="},
+		},
+		{
+			"digest",
+			&httpAuthConfig{Digest: &httpBasicAuthConfig{Username: "alice", Password: "pass"}},
+			"/digest",
+			"ok",
+			[]string{"pass"},
+		},
+		{
+			"oauth2 client credentials",
+			&httpAuthConfig{OAuth2: &httpOAuth2Config{TokenURL: ts.URL + "/token", ClientID: "client", ClientSecret: "clientsecret"}},
+			"/",
+			"Bearer token-client_credentials",
+			[]string{"clientsecret", "token-client_credentials"},
+		},
+		{
+			"oauth2 password",
+			&httpAuthConfig{OAuth2: &httpOAuth2Config{TokenURL: ts.URL + "/token", ClientID: "client", Username: "alice", Password: "pass"}},
+			"/",
+			"Bearer token-password",
+			[]string{"pass", "token-password"},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenRequests.Store(0)
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.setAuth(&httpRunnerConfig{Auth: tt.c}); err != nil {
+				t.Fatal(err)
+			}
+			// Run twice to use the cached token and challenge.
+			for range 2 {
+				req := &httpRequest{path: tt.path, method: http.MethodGet, headers: http.Header{}}
+				step := newStep(0, "stepKey", o, nil)
+				if err := r.run(ctx, req, step); err != nil {
+					t.Fatal(err)
+				}
+				res, ok := o.store.Latest()["res"].(map[string]any)
+				if !ok {
+					t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+				}
+				if got := res["rawBody"]; got != tt.wantBody {
+					t.Errorf("got %v\nwant %v", got, tt.wantBody)
+				}
+			}
+			if tt.c.OAuth2 != nil {
+				if got := tokenRequests.Load(); got != 1 {
+					t.Errorf("token requests: got %v, want 1", got)
+				}
+			}
+			for _, s := range tt.wantMasked {
+				if got := o.maskRule.Mask(s); got == s {
+					t.Errorf("%s is not masked", s)
+				}
+			}
+		})
+	}
+}
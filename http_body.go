@@ -0,0 +1,434 @@
+package runn
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/goccy/go-json"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	// xmlAttrPrefix is the prefix of the keys for XML attributes ( e.g. `-id` ).
+	xmlAttrPrefix = "-"
+	// xmlTextKey is the key for the character data of the XML element that has attributes or child elements.
+	xmlTextKey = "#text"
+)
+
+// protobufMessageTypeParam is the parameter of Content-Type to specify the message type of protobuf ( e.g. `application/protobuf; messageType=pkg.Message` ).
+const protobufMessageTypeParam = "messageType"
+
+var cborDecMode = func() cbor.DecMode {
+	dm, err := cbor.DecOptions{
+		DefaultMapType: reflect.TypeOf(map[string]any{}),
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return dm
+}()
+
+// cborEncMode sorts the keys of maps so that the same body is encoded into the same bytes.
+var cborEncMode = func() cbor.EncMode {
+	em, err := cbor.EncOptions{
+		Sort: cbor.SortCanonical,
+	}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return em
+}()
+
+// parseMediaType returns the media type without parameters and the parameters.
+func parseMediaType(v string) (string, map[string]string) {
+	mt, params, err := mime.ParseMediaType(v)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(v, ";")[0])), map[string]string{}
+	}
+	return mt, params
+}
+
+func isXMLMediaType(mt string) bool {
+	return mt == MediaTypeApplicationXML || mt == MediaTypeTextXML
+}
+
+func isMsgpackMediaType(mt string) bool {
+	return mt == MediaTypeApplicationMsgpack || mt == MediaTypeApplicationXMsgpack
+}
+
+func isCBORMediaType(mt string) bool {
+	return mt == MediaTypeApplicationCBOR
+}
+
+func isProtobufMediaType(mt string) bool {
+	return mt == MediaTypeApplicationProtobuf || mt == MediaTypeApplicationXProtobuf
+}
+
+// protobufMessageType returns the message type in the parameters of Content-Type.
+func protobufMessageType(params map[string]string) (string, bool) {
+	// The names of the parameters are lowercased by mime.ParseMediaType.
+	name, ok := params[strings.ToLower(protobufMessageTypeParam)]
+	return name, ok
+}
+
+// isEncodedBodyMediaType reports whether the body of contentType is encoded from the structured value other than JSON and form.
+func isEncodedBodyMediaType(contentType string) bool {
+	mt, params := parseMediaType(contentType)
+	switch mt {
+	case MediaTypeApplicationXML, MediaTypeTextXML, MediaTypeApplicationNDJSON,
+		MediaTypeApplicationMsgpack, MediaTypeApplicationXMsgpack, MediaTypeApplicationCBOR:
+		return true
+	case MediaTypeApplicationProtobuf, MediaTypeApplicationXProtobuf:
+		_, ok := protobufMessageType(params)
+		return ok
+	}
+	return false
+}
+
+// decodeBody decodes the response body into a structured value according to Content-Type.
+// It returns nil if Content-Type is not supported.
+func decodeBody(contentType string, b []byte) (any, error) {
+	mt, params := parseMediaType(contentType)
+	switch {
+	case mt == MediaTypeApplicationNDJSON:
+		return decodeNDJSON(b)
+	case isXMLMediaType(mt):
+		return decodeXML(b)
+	case isMsgpackMediaType(mt):
+		var v any
+		if err := msgpack.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return normalizeBodyValue(v)
+	case isCBORMediaType(mt):
+		var v any
+		if err := cborDecMode.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return normalizeBodyValue(v)
+	case isProtobufMediaType(mt):
+		name, ok := protobufMessageType(params)
+		if !ok {
+			// The message type is unknown.
+			return nil, nil
+		}
+		return decodeProtobuf(name, b)
+	case strings.Contains(contentType, "json"):
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return nil, nil
+}
+
+// decodeResponseBody decodes the response body according to Content-Type.
+// A JSON body that cannot be decoded is an error. If the body of the other types cannot be decoded ( e.g. XML with HTML entities or in a charset other than UTF-8 ), it returns nil so that only rawBody is available.
+func decodeResponseBody(contentType string, b []byte) (any, error) {
+	v, err := decodeBody(contentType, b)
+	if err != nil {
+		if mt, _ := parseMediaType(contentType); mt != MediaTypeApplicationNDJSON && strings.Contains(contentType, "json") {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return v, nil
+}
+
+// normalizeBodyValue converts v into the value of the same types as JSON body ( map[string]any, []any, float64, string, bool and nil ).
+func normalizeBodyValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var vv any
+	if err := json.Unmarshal(b, &vv); err != nil {
+		return nil, err
+	}
+	return vv, nil
+}
+
+func encodeMsgpack(body any) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	e := msgpack.NewEncoder(buf)
+	// Sort the keys of maps so that the same body is encoded into the same bytes.
+	e.SetSortMapKeys(true)
+	if err := e.Encode(body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeCBOR(body any) ([]byte, error) {
+	return cborEncMode.Marshal(body)
+}
+
+func encodeNDJSON(body any) ([]byte, error) {
+	switch v := body.(type) {
+	case string:
+		return []byte(v), nil
+	case []any:
+		buf := new(bytes.Buffer)
+		for _, vv := range v {
+			b, err := json.Marshal(vv)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("invalid body: %v", body)
+}
+
+func decodeNDJSON(b []byte) (any, error) {
+	values := []any{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(make([]byte, 0, 64*1024), len(b)+1)
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(line, &v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// encodeXML encodes the map that has only one key ( the root element ) into XML.
+// Keys prefixed with `-` are attributes and `#text` is the character data.
+func encodeXML(body any) ([]byte, error) {
+	switch v := body.(type) {
+	case string:
+		return []byte(v), nil
+	case map[string]any:
+		if len(v) != 1 {
+			return nil, fmt.Errorf("invalid body: XML requires only one root element: %v", body)
+		}
+		buf := new(bytes.Buffer)
+		buf.WriteString(xml.Header)
+		e := xml.NewEncoder(buf)
+		for k, vv := range v {
+			if err := encodeXMLElement(e, k, vv); err != nil {
+				return nil, err
+			}
+		}
+		if err := e.Flush(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("invalid body: %v", body)
+}
+
+func encodeXMLElement(e *xml.Encoder, name string, v any) error {
+	if vv, ok := v.([]any); ok {
+		// Repeated elements
+		for _, vvv := range vv {
+			if err := encodeXMLElement(e, name, vvv); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	m, ok := v.(map[string]any)
+	if !ok {
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		if v != nil {
+			if err := e.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.HasPrefix(k, xmlAttrPrefix) {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: strings.TrimPrefix(k, xmlAttrPrefix)}, Value: fmt.Sprint(m[k])})
+		}
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if t, ok := m[xmlTextKey]; ok && t != nil {
+		if err := e.EncodeToken(xml.CharData(fmt.Sprint(t))); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		if strings.HasPrefix(k, xmlAttrPrefix) || k == xmlTextKey {
+			continue
+		}
+		if err := encodeXMLElement(e, k, m[k]); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// decodeXML decodes XML into the map that has only one key ( the root element ).
+// The element that has neither attributes nor child elements is decoded into the string of the character data.
+// Repeated elements are decoded into the slice.
+func decodeXML(b []byte) (any, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		t, err := d.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("invalid XML: root element not found")
+			}
+			return nil, err
+		}
+		if start, ok := t.(xml.StartElement); ok {
+			v, err := decodeXMLElement(d, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{start.Name.Local: v}, nil
+		}
+	}
+}
+
+func decodeXMLElement(d *xml.Decoder, start xml.StartElement) (any, error) {
+	m := map[string]any{}
+	for _, a := range start.Attr {
+		m[xmlAttrPrefix+a.Name.Local] = a.Value
+	}
+	var (
+		text     strings.Builder
+		children bool
+	)
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tt := t.(type) {
+		case xml.StartElement:
+			children = true
+			v, err := decodeXMLElement(d, tt)
+			if err != nil {
+				return nil, err
+			}
+			k := tt.Name.Local
+			switch cur := m[k].(type) {
+			case nil:
+				m[k] = v
+			case []any:
+				m[k] = append(cur, v)
+			default:
+				m[k] = []any{cur, v}
+			}
+		case xml.CharData:
+			text.Write(tt)
+		case xml.EndElement:
+			if len(m) == 0 && !children {
+				return text.String(), nil
+			}
+			if s := strings.TrimSpace(text.String()); s != "" {
+				m[xmlTextKey] = s
+			}
+			return m, nil
+		}
+	}
+}
+
+// findProtoMessage finds the message descriptor from the descriptors loaded for gRPC runners.
+func findProtoMessage(name string) (protoreflect.MessageDescriptor, error) {
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		if errors.Is(err, protoregistry.NotFound) {
+			return nil, fmt.Errorf("cannot find protobuf message type: %s", name)
+		}
+		return nil, err
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("not a protobuf message type: %s", name)
+	}
+	return md, nil
+}
+
+func encodeProtobuf(name string, body any) ([]byte, error) {
+	md, err := findProtoMessage(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	m := dynamicpb.NewMessage(md)
+	if err := protojson.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(m)
+}
+
+func decodeProtobuf(name string, b []byte) (any, error) {
+	md, err := findProtoMessage(name)
+	if err != nil {
+		return nil, err
+	}
+	m := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	jb, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(jb, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// loadGRPCProtos loads the protos of gRPC runners that have not been resolved yet,
+// so that the HTTP runner can use the descriptors for protobuf bodies.
+func (o *operator) loadGRPCProtos(ctx context.Context) error {
+	for _, r := range o.grpcRunners {
+		if len(r.mds) > 0 {
+			continue
+		}
+		if len(r.importPaths) == 0 && len(r.protos) == 0 && len(r.bufDirs) == 0 && len(r.bufLocks) == 0 && len(r.bufConfigs) == 0 && len(r.bufModules) == 0 {
+			continue
+		}
+		if err := r.resolveAllMethodsUsingProtos(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package runn
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestEncodeXML(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    string
+		wantErr bool
+	}{
+		{
+			map[string]any{
+				"user": map[string]any{
+					"-id":  uint64(1),
+					"name": "alice",
+					"tags": []any{"a", "b"},
+				},
+			},
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<user id="1"><name>alice</name><tags>a</tags><tags>b</tags></user>`,
+			false,
+		},
+		{
+			map[string]any{
+				"note": map[string]any{"-lang": "en", "#text": "a & b"},
+			},
+			`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<note lang="en">a &amp; b</note>`,
+			false,
+		},
+		{"<raw/>", "<raw/>", false},
+		{map[string]any{"a": "1", "b": "2"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := encodeXML(tt.in)
+		if err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+		}
+		if string(got) != tt.want {
+			t.Errorf("got %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	mb, err := msgpack.Marshal(map[string]any{"name": "alice", "age": 20, "tags": []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb, err := cbor.Marshal(map[string]any{"name": "alice", "age": 20, "tags": []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		contentType string
+		in          []byte
+		want        any
+	}{
+		{"application/json", []byte(`{"name":"alice"}`), map[string]any{"name": "alice"}},
+		{"application/problem+json", []byte(`{"title":"error"}`), map[string]any{"title": "error"}},
+		{"application/x-ndjson", []byte("{\"n\":1}\n\n{\"n\":2}\n"), []any{map[string]any{"n": float64(1)}, map[string]any{"n": float64(2)}}},
+		{
+			"application/xml; charset=utf-8",
+			[]byte(`<?xml version="1.0"?><users count="2"><user>alice</user><user><name>bob</name></user><empty/></users>`),
+			map[string]any{"users": map[string]any{
+				"-count": "2",
+				"user":   []any{"alice", map[string]any{"name": "bob"}},
+				"empty":  "",
+			}},
+		},
+		{"application/msgpack", mb, map[string]any{"name": "alice", "age": float64(20), "tags": []any{"a"}}},
+		{"application/cbor", cb, map[string]any{"name": "alice", "age": float64(20), "tags": []any{"a"}}},
+		{"application/protobuf", []byte{0x0a, 0x05}, nil},
+		{"text/plain", []byte("hello"), nil},
+	}
+	for _, tt := range tests {
+		got, err := decodeBody(tt.contentType, tt.in)
+		if err != nil {
+			t.Errorf("%s: %v", tt.contentType, err)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Errorf("%s: %s", tt.contentType, diff)
+		}
+	}
+}
+
+func TestDecodeResponseBody(t *testing.T) {
+	tests := []struct {
+		contentType string
+		in          []byte
+		want        any
+		wantErr     bool
+	}{
+		{"application/xml", []byte(`<user>alice</user>`), map[string]any{"user": "alice"}, false},
+		{"application/xml", []byte(`<p>a&nbsp;b</p>`), nil, false},
+		{"text/xml; charset=Shift_JIS", []byte("<?xml version=\"1.0\" encoding=\"Shift_JIS\"?><name>\x83\x65\x83\x58\x83\x67</name>"), nil, false},
+		{"application/xhtml+xml", []byte(`<html><body>a&nbsp;b</body></html>`), nil, false},
+		{"image/svg+xml", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), nil, false},
+		{"application/cbor", []byte{0xff}, nil, false},
+		{"application/msgpack", []byte{0xc1}, nil, false},
+		{"application/x-ndjson", []byte("{\"n\":1}\n{"), nil, false},
+		{"application/json", []byte(`{"n":`), nil, true},
+		{"application/problem+json", []byte(`{"n":`), nil, true},
+	}
+	for _, tt := range tests {
+		got, err := decodeResponseBody(tt.contentType, tt.in)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("%s: %v", tt.contentType, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("%s: want error", tt.contentType)
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Errorf("%s: %s", tt.contentType, diff)
+		}
+	}
+}
+
+func TestHTTPRunnerBodyEncodings(t *testing.T) {
+	// echo returns the request body with the same Content-Type.
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(echo.Close)
+
+	tests := []struct {
+		mediaType string
+		body      any
+		want      any
+	}{
+		{
+			MediaTypeApplicationXML,
+			map[string]any{"user": map[string]any{"-id": uint64(1), "name": "alice"}},
+			map[string]any{"user": map[string]any{"-id": "1", "name": "alice"}},
+		},
+		{
+			MediaTypeApplicationNDJSON,
+			[]any{map[string]any{"n": uint64(1)}, map[string]any{"n": uint64(2)}},
+			[]any{map[string]any{"n": float64(1)}, map[string]any{"n": float64(2)}},
+		},
+		{
+			MediaTypeApplicationMsgpack,
+			map[string]any{"name": "alice", "n": uint64(1)},
+			map[string]any{"name": "alice", "n": float64(1)},
+		},
+		{
+			MediaTypeApplicationCBOR,
+			map[string]any{"name": "alice", "n": uint64(1)},
+			map[string]any{"name": "alice", "n": float64(1)},
+		},
+		{
+			"application/protobuf; messageType=fixturetest.HelloRequest",
+			map[string]any{"name": "alice"},
+			map[string]any{"name": "alice"},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.mediaType, func(t *testing.T) {
+			// The descriptors of protobuf are loaded from the protos of the gRPC runner.
+			o, err := New(GrpcRunnerWithOptions("greq", "localhost:1", Protos([]string{"testdata/fixturetest.proto"})))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", echo.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &httpRequest{path: "/", method: http.MethodPost, headers: http.Header{}, mediaType: tt.mediaType, body: tt.body}
+			if err := req.validate(); err != nil {
+				t.Fatal(err)
+			}
+			step := newStep(0, "stepKey", o, nil)
+			if err := r.run(ctx, req, step); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.Latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+			}
+			if diff := cmp.Diff(res["body"], tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestHTTPRequestValidateMediaType(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		wantErr   bool
+	}{
+		{MediaTypeApplicationXML, false},
+		{MediaTypeTextXML, false},
+		{MediaTypeApplicationNDJSON, false},
+		{MediaTypeApplicationMsgpack, false},
+		{MediaTypeApplicationCBOR, false},
+		{"application/x-protobuf; messageType=pkg.Message", false},
+		{MediaTypeApplicationProtobuf, true},
+		{"application/yaml", true},
+	}
+	for _, tt := range tests {
+		r := &httpRequest{method: http.MethodPost, mediaType: tt.mediaType, body: map[string]any{}}
+		if err := r.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: got %v, wantErr %v", tt.mediaType, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEncodeBodyDeterministic(t *testing.T) {
+	body := map[string]any{}
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		body[k] = map[string]any{"x": k, "y": uint64(1), "z": []any{k}}
+	}
+	for _, enc := range []func(any) ([]byte, error){encodeMsgpack, encodeCBOR, encodeXMLRoot} {
+		want, err := enc(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 10 {
+			got, err := enc(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("got %x\nwant %x", got, want)
+			}
+		}
+	}
+}
+
+func encodeXMLRoot(body any) ([]byte, error) {
+	return encodeXML(map[string]any{"root": body})
+}
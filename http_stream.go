@@ -0,0 +1,168 @@
+package runn
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+)
+
+const (
+	httpEventIDKey    = "id"
+	httpEventEventKey = "event"
+	httpEventDataKey  = "data"
+	httpEventRetryKey = "retry"
+	httpEventJSONKey  = "json"
+)
+
+const httpStreamDefaultTimeout = 30 * time.Second
+
+const mediaTypeTextEventStream = "text/event-stream"
+
+// HTTPEvent is an event of the streaming response of the HTTP runner.
+// It is a Server-Sent Event or a line of the other ( e.g. chunked ) response.
+type HTTPEvent struct {
+	ID    string
+	Event string
+	Data  string
+	Retry int
+}
+
+type httpStream struct {
+	// count - Stop reading after receiving count events.
+	count int
+	// timeout - Stop reading after timeout.
+	timeout time.Duration
+	// until - Stop reading when the received event matches the condition.
+	until string
+}
+
+// readStream reads the events of the streaming response until the count, the timeout or the until condition is met.
+// It returns the bytes read and the events.
+func (rnr *httpRunner) readStream(res *http.Response, st *httpStream, s *step, timedOut func() bool) ([]byte, []map[string]any, error) {
+	o := s.parent
+	sse := false
+	if mt, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil && mt == mediaTypeTextEventStream {
+		sse = true
+	}
+	var (
+		raw  bytes.Buffer
+		ev   *HTTPEvent
+		data []string
+	)
+	events := []map[string]any{}
+	// dispatch records the event and reports whether to stop reading.
+	dispatch := func(ev *HTTPEvent) (bool, error) {
+		o.capturers.captureHTTPEvent(rnr.name, ev)
+		m := httpEventToMap(ev, sse)
+		events = append(events, m)
+		if st.count > 0 && len(events) >= st.count {
+			return true, nil
+		}
+		if st.until == "" {
+			return false, nil
+		}
+		sm := o.store.ToMap()
+		sm[store.RootKeyIncluded] = o.included
+		if !s.deferred {
+			sm[store.RootKeyPrevious] = o.store.Latest()
+		}
+		// `current` in `until:` is the received event.
+		sm[store.RootKeyCurrent] = m
+		return expr.EvalCond(st.until, sm)
+	}
+
+	br := bufio.NewReader(res.Body)
+	for {
+		line, err := br.ReadString('\n')
+		raw.WriteString(line)
+		if err != nil {
+			if errors.Is(err, io.EOF) || timedOut() {
+				// The incomplete event of Server-Sent Events is discarded.
+				if !sse && line != "" {
+					if _, err := dispatch(&HTTPEvent{Data: strings.TrimRight(line, "\r\n")}); err != nil {
+						return raw.Bytes(), events, err
+					}
+				}
+				return raw.Bytes(), events, nil
+			}
+			return raw.Bytes(), events, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if !sse {
+			if line == "" {
+				continue
+			}
+			stop, err := dispatch(&HTTPEvent{Data: line})
+			if err != nil || stop {
+				return raw.Bytes(), events, err
+			}
+			continue
+		}
+		// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+		if line == "" {
+			if ev == nil || data == nil {
+				ev, data = nil, nil
+				continue
+			}
+			ev.Data = strings.Join(data, "\n")
+			if ev.Event == "" {
+				ev.Event = "message"
+			}
+			stop, err := dispatch(ev)
+			if err != nil || stop {
+				return raw.Bytes(), events, err
+			}
+			ev, data = nil, nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// Comment
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		if ev == nil {
+			ev = &HTTPEvent{}
+		}
+		switch field {
+		case "id":
+			ev.ID = value
+		case "event":
+			ev.Event = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if r, err := strconv.Atoi(value); err == nil {
+				ev.Retry = r
+			}
+		}
+	}
+}
+
+func httpEventToMap(ev *HTTPEvent, sse bool) map[string]any {
+	m := map[string]any{
+		httpEventDataKey: ev.Data,
+	}
+	if sse {
+		m[httpEventIDKey] = ev.ID
+		m[httpEventEventKey] = ev.Event
+		if ev.Retry > 0 {
+			m[httpEventRetryKey] = ev.Retry
+		}
+	}
+	var v any
+	if err := json.Unmarshal([]byte(ev.Data), &v); err == nil {
+		m[httpEventJSONKey] = v
+	}
+	return m
+}
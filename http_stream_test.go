@@ -0,0 +1,148 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHTTPRunnerStream(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Error("streaming is not supported")
+			return
+		}
+		switch r.URL.Path {
+		case "/sse":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, ": comment\n\nretry: 1000\n\n")
+			for i := 1; ; i++ {
+				_, _ = fmt.Fprintf(w, "id: %d\nevent: tick\ndata: {\"n\": %d}\n\n", i, i)
+				f.Flush()
+				if r.URL.Query().Get("end") == fmt.Sprint(i) {
+					_, _ = fmt.Fprint(w, "data: line1\ndata: line2\n\n")
+					return
+				}
+				select {
+				case <-r.Context().Done():
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+		case "/chunked":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			for i := 1; i <= 3; i++ {
+				_, _ = fmt.Fprintf(w, "{\"n\": %d}\n", i)
+				f.Flush()
+			}
+			_, _ = fmt.Fprint(w, "last")
+		}
+	})
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		name       string
+		req        *httpRequest
+		wantEvents []map[string]any
+	}{
+		{
+			"count",
+			&httpRequest{path: "/sse", method: http.MethodGet, headers: http.Header{}, stream: &httpStream{count: 2}},
+			[]map[string]any{
+				{"id": "1", "event": "tick", "data": `{"n": 1}`, "json": map[string]any{"n": float64(1)}},
+				{"id": "2", "event": "tick", "data": `{"n": 2}`, "json": map[string]any{"n": float64(2)}},
+			},
+		},
+		{
+			"until",
+			&httpRequest{path: "/sse", method: http.MethodGet, headers: http.Header{}, stream: &httpStream{until: "current.json.n == 2"}},
+			[]map[string]any{
+				{"id": "1", "event": "tick", "data": `{"n": 1}`, "json": map[string]any{"n": float64(1)}},
+				{"id": "2", "event": "tick", "data": `{"n": 2}`, "json": map[string]any{"n": float64(2)}},
+			},
+		},
+		{
+			"end of stream",
+			&httpRequest{path: "/sse?end=1", method: http.MethodGet, headers: http.Header{}, stream: &httpStream{}},
+			[]map[string]any{
+				{"id": "1", "event": "tick", "data": `{"n": 1}`, "json": map[string]any{"n": float64(1)}},
+				{"id": "", "event": "message", "data": "line1\nline2"},
+			},
+		},
+		{
+			"chunked",
+			&httpRequest{path: "/chunked", method: http.MethodGet, headers: http.Header{}, stream: &httpStream{}},
+			[]map[string]any{
+				{"data": `{"n": 1}`, "json": map[string]any{"n": float64(1)}},
+				{"data": `{"n": 2}`, "json": map[string]any{"n": float64(2)}},
+				{"data": `{"n": 3}`, "json": map[string]any{"n": float64(3)}},
+				{"data": "last"},
+			},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			step := newStep(0, "stepKey", o, nil)
+			if err := r.run(ctx, tt.req, step); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.Latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+			}
+			got, ok := res["events"].([]map[string]any)
+			if !ok {
+				t.Fatalf("invalid events: %#v", res["events"])
+			}
+			if diff := cmp.Diff(got, tt.wantEvents); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		o, err := New(Debug(true), Stderr(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := newHTTPRunner("req", ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := &httpRequest{path: "/sse", method: http.MethodGet, headers: http.Header{}, stream: &httpStream{timeout: 100 * time.Millisecond}}
+		step := newStep(0, "stepKey", o, nil)
+		if err := r.run(ctx, req, step); err != nil {
+			t.Fatal(err)
+		}
+		res, ok := o.store.Latest()["res"].(map[string]any)
+		if !ok {
+			t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+		}
+		events, ok := res["events"].([]map[string]any)
+		if !ok || len(events) == 0 {
+			t.Errorf("want events: %#v", res["events"])
+		}
+		if got := strings.Count(buf.String(), "-----START HTTP EVENT-----"); got != len(events) {
+			t.Errorf("captured events: got %d, want %d", got, len(events))
+		}
+	})
+}
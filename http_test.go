@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"strconv"
@@ -13,9 +14,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/goccy/go-json"
 	"github.com/goccy/go-yaml"
 	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/duration"
 	"github.com/k1LoW/runn/testutil"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func TestHTTPRunnerRunUsingGitHubAPI(t *testing.T) {
@@ -631,6 +636,23 @@ func TestHTTPRunnerWithHandler(t *testing.T) {
 	}
 }
 
+func TestHTTPRunnerWithHandlerTimeout(t *testing.T) {
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newHTTPRunnerWithHandler(t.Name(), http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &httpRequest{path: "/", method: http.MethodGet, headers: http.Header{}, timeout: time.Second}
+	step := newStep(0, "stepKey", o, nil)
+	if err := r.run(ctx, req, step); err == nil {
+		t.Error("want error")
+	}
+}
+
 func TestNotFollowRedirect(t *testing.T) {
 	tests := []struct {
 		req               *httpRequest
@@ -739,6 +761,34 @@ func TestHTTPCerts(t *testing.T) {
 	}
 }
 
+func TestHTTPCertsWithCustomClient(t *testing.T) {
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs := testutil.HTTPSServer(t)
+	r, err := newHTTPRunner("req", hs.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The transport of the client is nil until the first request.
+	r.client = &http.Client{}
+	r.cacert = testutil.Cacert
+	r.cert = testutil.Cert
+	r.key = testutil.Key
+	req := &httpRequest{
+		path:    "/users/1",
+		method:  http.MethodGet,
+		headers: http.Header{},
+		timeout: 10 * time.Second,
+	}
+	step := newStep(0, "stepKey", o, nil)
+	if err := r.run(ctx, req, step); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestHTTPRunnerInitializeWithCerts(t *testing.T) {
 	tests := []struct {
 		setCacert       bool
@@ -982,3 +1032,268 @@ func TestSetTraceHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPRunnerGraphQL(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if req.Variables["id"] == "1" {
+			_, _ = w.Write([]byte(`{"data":{"user":{"name":"alice"}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"user":null},"errors":[{"message":"user not found","path":["user"]}]}`))
+	})
+	tests := []struct {
+		book    string
+		wantErr string
+	}{
+		{"testdata/book/graphql.yml", ""},
+		{"testdata/book/graphql_invalid_query.yml", "graphql validation error"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			o, err := New(Book(tt.book), HTTPRunnerWithHandler("req", h, GraphQLSchema("testdata/graphql/schema.graphql")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v\nwant %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerTransport(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Header().Set("X-Host", r.Host)
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	h2cs := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	t.Cleanup(h2cs.Close)
+	h2s := httptest.NewUnstartedServer(handler)
+	h2s.EnableHTTP2 = true
+	h2s.StartTLS()
+	t.Cleanup(h2s.Close)
+	h1s := httptest.NewTLSServer(handler)
+	t.Cleanup(h1s.Close)
+	// proxy returns the requested URL instead of forwarding the request.
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Host", r.Host)
+		_, _ = w.Write([]byte("proxied " + r.URL.String()))
+	}))
+	t.Cleanup(proxy.Close)
+
+	tests := []struct {
+		name      string
+		endpoint  string
+		c         *httpRunnerConfig
+		req       *httpRequest
+		wantErr   bool
+		wantProto string
+		wantBody  string
+	}{
+		{
+			"default",
+			h2cs.URL,
+			&httpRunnerConfig{},
+			&httpRequest{path: "/", method: http.MethodGet, headers: http.Header{}},
+			false,
+			"HTTP/1.1",
+			"HTTP/1.1",
+		},
+		{
+			"h2c",
+			h2cs.URL,
+			&httpRunnerConfig{HTTP2: true},
+			&httpRequest{path: "/", method: http.MethodGet, headers: http.Header{}},
+			false,
+			"HTTP/2.0",
+			"HTTP/2.0",
+		},
+		{
+			"HTTP/2 over TLS",
+			h2s.URL,
+			&httpRunnerConfig{HTTP2: true, SkipVerify: true},
+			&httpRequest{path: "/", method: http.MethodGet, headers: http.Header{}},
+			false,
+			"HTTP/2.0",
+			"HTTP/2.0",
+		},
+		{
+			"HTTP/2 is not supported by the server",
+			h1s.URL,
+			&httpRunnerConfig{HTTP2: true, SkipVerify: true},
+			&httpRequest{path: "/", method: http.MethodGet, headers: http.Header{}},
+			true,
+			"",
+			"",
+		},
+		{
+			"proxy",
+			"http://runn.test",
+			&httpRunnerConfig{Proxy: proxy.URL},
+			&httpRequest{path: "/users", method: http.MethodGet, headers: http.Header{}},
+			false,
+			"HTTP/1.1",
+			"proxied http://runn.test/users",
+		},
+		{
+			"request timeout",
+			h2cs.URL,
+			&httpRunnerConfig{},
+			&httpRequest{path: "/slow", method: http.MethodGet, headers: http.Header{}, timeout: 50 * time.Millisecond},
+			true,
+			"",
+			"",
+		},
+		{
+			"request timeout overrides runner timeout",
+			h2cs.URL,
+			&httpRunnerConfig{Timeout: "50ms"},
+			&httpRequest{path: "/slow", method: http.MethodGet, headers: http.Header{}, timeout: time.Second},
+			false,
+			"HTTP/1.1",
+			"HTTP/1.1",
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newHTTPRunner("req", tt.endpoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.skipVerify = tt.c.SkipVerify
+			if tt.c.Timeout != "" {
+				r.client.Timeout, err = duration.Parse(tt.c.Timeout)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := r.setTransport(tt.c); err != nil {
+				t.Fatal(err)
+			}
+			step := newStep(0, "stepKey", o, nil)
+			err = r.run(ctx, tt.req, step)
+			if err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("want error")
+			}
+			res, ok := o.store.Latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+			}
+			if got := res["proto"]; got != tt.wantProto {
+				t.Errorf("got %v\nwant %v", got, tt.wantProto)
+			}
+			if got := res["rawBody"]; got != tt.wantBody {
+				t.Errorf("got %v\nwant %v", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerH2CWithHostRules(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	ts := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	t.Cleanup(ts.Close)
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	o, err := New(Runner("req", "http://h2c.example.com", HTTP2(true)), HostRules(fmt.Sprintf("h2c.example.com %s", u.Host)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := o.httpRunners["req"]
+	if !ok {
+		t.Fatal("http runner not found")
+	}
+	req := &httpRequest{path: "/", method: http.MethodGet, headers: http.Header{}}
+	step := newStep(0, "stepKey", o, nil)
+	if err := r.run(ctx, req, step); err != nil {
+		t.Fatal(err)
+	}
+	res, ok := o.store.Latest()["res"].(map[string]any)
+	if !ok {
+		t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+	}
+	if got := res["rawBody"]; got != "HTTP/2.0" {
+		t.Errorf("got %v\nwant %v", got, "HTTP/2.0")
+	}
+}
+
+func TestHTTPRunnerSetTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		c        *httpRunnerConfig
+		wantErr  bool
+	}{
+		{"no options", "https://example.com", &httpRunnerConfig{}, false},
+		{"pool", "https://example.com", &httpRunnerConfig{MaxIdleConns: 10, MaxIdleConnsPerHost: 5, MaxConnsPerHost: 3, DisableKeepAlives: true}, false},
+		{"socks5 proxy", "https://example.com", &httpRunnerConfig{Proxy: "socks5://localhost:1080"}, false},
+		{"invalid proxy scheme", "https://example.com", &httpRunnerConfig{Proxy: "ftp://localhost:21"}, true},
+		{"h2c with proxy", "http://example.com", &httpRunnerConfig{HTTP2: true, Proxy: "http://localhost:8080"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newHTTPRunner("req", tt.endpoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			orig := r.client
+			if err := r.setTransport(tt.c); err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("want error")
+			}
+			if tt.c.MaxIdleConns == 0 {
+				return
+			}
+			if r.client == orig {
+				t.Error("the original client should not be modified")
+			}
+			ts, ok := r.client.Transport.(*http.Transport)
+			if !ok {
+				t.Fatalf("invalid transport: %#v", r.client.Transport)
+			}
+			if ts.MaxIdleConns != 10 || ts.MaxIdleConnsPerHost != 5 || ts.MaxConnsPerHost != 3 || !ts.DisableKeepAlives {
+				t.Errorf("transport options are not set: %#v", ts)
+			}
+		})
+	}
+}
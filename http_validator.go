@@ -41,10 +41,63 @@ func (e *UnsupportedError) Unwrap() error {
 }
 
 func newHttpValidator(c *httpRunnerConfig) (httpValidator, error) {
+	var vs httpValidators
 	if c.OpenAPI3DocLocation != "" || c.openAPI3Doc != nil {
-		return newOpenAPI3Validator(c)
+		v, err := newOpenAPI3Validator(c)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	if c.GraphQLSchemaLocation != "" {
+		v, err := newGraphQLValidator(c)
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	switch len(vs) {
+	case 0:
+		return newNopValidator(), nil
+	case 1:
+		return vs[0], nil
+	default:
+		return vs, nil
+	}
+}
+
+// httpValidators - validators applied in order.
+type httpValidators []httpValidator
+
+func (vs httpValidators) ValidateRequest(ctx context.Context, req *http.Request) error {
+	for _, v := range vs {
+		if err := v.ValidateRequest(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vs httpValidators) ValidateResponse(ctx context.Context, req *http.Request, res *http.Response) error {
+	for _, v := range vs {
+		if err := v.ValidateResponse(ctx, req, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findHTTPValidator returns the validator of type T from v ( including httpValidators ).
+func findHTTPValidator[T httpValidator](v httpValidator) (T, bool) {
+	if vs, ok := v.(httpValidators); ok {
+		for _, vv := range vs {
+			if t, ok := vv.(T); ok {
+				return t, true
+			}
+		}
 	}
-	return newNopValidator(), nil
+	t, ok := v.(T)
+	return t, ok
 }
 
 type nopValidator struct{}
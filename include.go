@@ -213,6 +213,7 @@ func (o *operator) newNestedOperator(parent *step, opts ...Option) (*operator, e
 	oo.thisT = o.thisT
 	oo.sw = o.sw
 	oo.capturers = o.capturers
+	oo.fixture = o.fixture
 	oo.parent = parent
 	oo.store.SetParentVars(o.store.ToMap())
 	oo.store.SetKV(o.store.KV())
@@ -240,6 +241,21 @@ func (o *operator) exportOptionsToBePropagated() []Option {
 	for k, r := range o.sshRunners {
 		opts = append(opts, reuseSSHRunner(k, r))
 	}
+	for k, r := range o.wsRunners {
+		opts = append(opts, reuseWSRunner(k, r))
+	}
+	for k, r := range o.kafkaRunners {
+		opts = append(opts, reuseKafkaRunner(k, r))
+	}
+	for k, r := range o.redisRunners {
+		opts = append(opts, reuseRedisRunner(k, r))
+	}
+	for k, r := range o.smtpRunners {
+		opts = append(opts, reuseSMTPRunner(k, r))
+	}
+	for k, r := range o.mockRunners {
+		opts = append(opts, reuseMockRunner(k, r))
+	}
 
 	opts = append(opts, Debug(o.debug))
 	opts = append(opts, Profile(o.profile))
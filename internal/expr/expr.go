@@ -11,6 +11,7 @@ import (
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/ast"
 	"github.com/expr-lang/expr/file"
+	"github.com/expr-lang/expr/parser"
 	"github.com/expr-lang/expr/parser/lexer"
 	"github.com/goccy/go-yaml"
 	"github.com/k1LoW/expand"
@@ -181,6 +182,68 @@ func EvalExpand(in any, store exprtrace.EvalEnv) (any, error) {
 	return out, nil
 }
 
+// Parse parses the expression without evaluating it.
+func Parse(e string) (*parser.Tree, error) {
+	tree, err := parser.Parse(trimDeprecatedComment(e))
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %w", err)
+	}
+	return tree, nil
+}
+
+// References returns the properties of the root variable (e.g. `steps`) referred to by literal in the expression.
+// The property is string for `root.key` or `root['key']` and int for `root[0]`.
+func References(e, root string) ([]any, error) {
+	tree, err := Parse(e)
+	if err != nil {
+		return nil, err
+	}
+	v := &referenceVisitor{root: root}
+	ast.Walk(&tree.Node, v)
+	return v.refs, nil
+}
+
+// Expansions returns the expressions enclosed in `{{ }}` in the string.
+func Expansions(in string) []string {
+	var exprs []string
+	for {
+		start := strings.Index(in, delimStart)
+		if start < 0 {
+			return exprs
+		}
+		in = in[start+len(delimStart):]
+		end := strings.Index(in, delimEnd)
+		if end < 0 {
+			return exprs
+		}
+		exprs = append(exprs, strings.TrimSpace(in[:end]))
+		in = in[end+len(delimEnd):]
+	}
+}
+
+type referenceVisitor struct {
+	root string
+	refs []any
+}
+
+// Visit implements ast.Visitor interface.
+func (v *referenceVisitor) Visit(node *ast.Node) {
+	m, ok := (*node).(*ast.MemberNode)
+	if !ok {
+		return
+	}
+	id, ok := m.Node.(*ast.IdentifierNode)
+	if !ok || id.Value != v.root {
+		return
+	}
+	switch p := m.Property.(type) {
+	case *ast.StringNode:
+		v.refs = append(v.refs, p.Value)
+	case *ast.IntegerNode:
+		v.refs = append(v.refs, p.Value)
+	}
+}
+
 func trimDeprecatedComment(cond string) string {
 	const commentToken = "#"
 	s := file.NewSource(cond)
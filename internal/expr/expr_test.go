@@ -815,3 +815,51 @@ func TestEvalExpand(t *testing.T) {
 		})
 	}
 }
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []any
+		wantErr bool
+	}{
+		{"steps.login.res.status == 200", []any{"login"}, false},
+		{"steps['login'].res.status == steps[1].res.status", []any{"login", 1}, false},
+		{"steps?.login.res", []any{"login"}, false},
+		{"len(steps[i]) > 0 && vars.steps.a == 1", nil, false},
+		{"current.res.status ==", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := References(tt.in, "steps")
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.in)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestExpansions(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"no expansion", nil},
+		{"/users/{{ steps.login.res.body.id }}", []string{"steps.login.res.body.id"}},
+		{"{{ vars.a }}-{{vars.b}}", []string{"vars.a", "vars.b"}},
+		{"{{ unclosed", nil},
+	}
+	for _, tt := range tests {
+		got := Expansions(tt.in)
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
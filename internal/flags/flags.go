@@ -23,56 +23,66 @@ var intRe = regexp.MustCompile(`^\-?[0-9]+$`)
 var floatRe = regexp.MustCompile(`^\-?[0-9.]+$`)
 
 type Flags struct {
-	Debug           bool     `usage:"debug"`
-	Long            bool     `usage:"long format"`
-	FailFast        bool     `usage:"fail fast"`
-	SkipTest        bool     `usage:"skip \"test:\" section"`
-	SkipIncluded    bool     `usage:"skip running the included runbook by itself"`
-	RunMatch        string   `usage:"run all runbooks with a matching file path, treating the value passed to the option as an unanchored regular expression"`
-	RunIDs          []string `usage:"run the matching runbooks in order if there is only one runbook with a forward matching ID"`
-	RunLabels       []string `usage:"run all runbooks matching the label specification"`
-	HTTPOpenApi3s   []string `usage:"set the path to the OpenAPI v3 document for HTTP runners (\"path/to/spec.yml\" or \"key:path/to/spec.yml\")"`
-	GRPCNoTLS       bool     `usage:"disable TLS use in all gRPC runners"`
-	GRPCProtos      []string `usage:"set the name of proto source for gRPC runners"`
-	GRPCImportPaths []string `usage:"set the path to the directory where proto sources can be imported for gRPC runners"`
-	GRPCBufDirs     []string `usage:"set the path to the buf directory for gRPC runners"`
-	GRPCBufLocks    []string `usage:"set the path to buf.lock for gRPC runners"`
-	GRPCBufConfigs  []string `usage:"set the path to buf.yaml for gRPC runners"`
-	GRPCBufModules  []string `usage:"set the buf modules for gRPC runners (\"buf.build/owner/repository\" or \"buf.build/owner/repository/tree/branch-or-commit\")"`
-	CaptureDir      string   `usage:"destination of runbook run capture results"`
-	Vars            []string `usage:"set var to runbook (\"key:value\")"`
-	Runners         []string `usage:"set runner to runbook (\"key:dsn\")"`
-	Overlays        []string `usage:"overlay values on the runbook"`
-	Underlays       []string `usage:"lay values under the runbook"`
-	Sample          int      `usage:"sample the specified number of runbooks"`
-	Shuffle         string   `usage:"randomize the order of running runbooks (\"on\",\"off\",N)"`
-	Concurrent      string   `usage:"run runbooks concurrently (\"on\",\"off\",N)"`
-	ShardIndex      int      `usage:"index of distributed runbooks"`
-	ShardN          int      `usage:"number of shards for distributing runbooks"`
-	Random          int      `usage:"run the specified number of runbooks at random"`
-	Desc            string   `usage:"description of runbook"`
-	Out             string   `usage:"target path of runbook"`
-	Format          string   `usage:"format of result output"`
-	AndRun          bool     `usage:"run created runbook and capture the response for test"`
-	LoadTConcurrent int      `usage:"number of concurrent load test runs. 0 means unlimited"`
-	LoadTDuration   string   `usage:"load test running duration"`
-	LoadTWarmUp     string   `usage:"warn-up time for load test"`
-	LoadTThreshold  string   `usage:"if this threshold condition is not met, loadt command returns exit status 1 (EXIT_FAILURE)"`
-	LoadTMaxRPS     int      `usage:"max RunN per second for load test. 0 means unlimited"`
-	Profile         bool     `usage:"profile runs of runbooks"`
-	ProfileOut      string   `usage:"profile output path"`
-	ProfileDepth    int      `usage:"depth of profile"`
-	ProfileUnit     string   `usage:"-"`
-	ProfileSort     string   `usage:"-"`
-	Attach          bool     `usage:"attach to runn process"`
-	CacheDir        string   `usage:"specify cache directory for remote runbooks"`
-	RetainCacheDir  bool     `usage:"retain cache directory for remote runbooks"`
-	Scopes          []string `usage:"additional scopes for runn"`
-	HostRules       []string `usage:"host rules for runn. (\"host rule,host rule,...\")"`
-	WaitTimeout     string   `usage:"timeout for waiting for cleanup process after running runbooks"`
-	EnvFile         string   `usage:"load environment variables from a file"`
-	ForceColor      bool     `usage:"force colorized output even in non-tty output streams"`
-	Verbose         bool     `usage:"verbose"`
+	Debug            bool     `usage:"debug"`
+	Long             bool     `usage:"long format"`
+	FailFast         bool     `usage:"fail fast"`
+	SkipTest         bool     `usage:"skip \"test:\" section"`
+	SkipIncluded     bool     `usage:"skip running the included runbook by itself"`
+	RunMatch         string   `usage:"run all runbooks with a matching file path, treating the value passed to the option as an unanchored regular expression"`
+	RunIDs           []string `usage:"run the matching runbooks in order if there is only one runbook with a forward matching ID"`
+	RunLabels        []string `usage:"run all runbooks matching the label specification"`
+	HTTPOpenApi3s    []string `usage:"set the path to the OpenAPI v3 document for HTTP runners (\"path/to/spec.yml\" or \"key:path/to/spec.yml\")"`
+	GRPCNoTLS        bool     `usage:"disable TLS use in all gRPC runners"`
+	GRPCProtos       []string `usage:"set the name of proto source for gRPC runners"`
+	GRPCImportPaths  []string `usage:"set the path to the directory where proto sources can be imported for gRPC runners"`
+	GRPCBufDirs      []string `usage:"set the path to the buf directory for gRPC runners"`
+	GRPCBufLocks     []string `usage:"set the path to buf.lock for gRPC runners"`
+	GRPCBufConfigs   []string `usage:"set the path to buf.yaml for gRPC runners"`
+	GRPCBufModules   []string `usage:"set the buf modules for gRPC runners (\"buf.build/owner/repository\" or \"buf.build/owner/repository/tree/branch-or-commit\")"`
+	CaptureDir       string   `usage:"destination of runbook run capture results"`
+	FixtureDir       string   `usage:"directory of fixtures of HTTP and gRPC exchanges"`
+	FixtureMode      string   `usage:"fixture mode (\"record\",\"replay\",\"drift\"). \"drift\" fails steps whose responses differ from the fixtures"`
+	Vars             []string `usage:"set var to runbook (\"key:value\")"`
+	Runners          []string `usage:"set runner to runbook (\"key:dsn\")"`
+	Overlays         []string `usage:"overlay values on the runbook"`
+	Underlays        []string `usage:"lay values under the runbook"`
+	Sample           int      `usage:"sample the specified number of runbooks"`
+	Shuffle          string   `usage:"randomize the order of running runbooks (\"on\",\"off\",N)"`
+	Concurrent       string   `usage:"run runbooks concurrently (\"on\",\"off\",N)"`
+	ShardIndex       int      `usage:"index of distributed runbooks"`
+	ShardN           int      `usage:"number of shards for distributing runbooks"`
+	Random           int      `usage:"run the specified number of runbooks at random"`
+	Desc             string   `usage:"description of runbook"`
+	Out              string   `usage:"target path of runbook"`
+	Format           string   `usage:"format of result output"`
+	AndRun           bool     `usage:"run created runbook and capture the response for test"`
+	FromOpenAPI      string   `usage:"generate runbooks from the OpenAPI v3 document"`
+	OpenAPIGroupBy   string   `usage:"unit of runbooks generated from the OpenAPI v3 document (\"tag\",\"operation\"). all operations are in one runbook by default"`
+	LoadTConcurrent  int      `usage:"number of concurrent load test runs. 0 means unlimited"`
+	LoadTDuration    string   `usage:"load test running duration"`
+	LoadTWarmUp      string   `usage:"warn-up time for load test"`
+	LoadTThreshold   string   `usage:"if this threshold condition is not met, loadt command returns exit status 1 (EXIT_FAILURE)"`
+	LoadTMaxRPS      int      `usage:"max RunN per second for load test. 0 means unlimited"`
+	LoadTCounters    []string `usage:"add a custom counter that counts the runs of runbooks meeting the condition, available as counters['NAME'] in the threshold (\"NAME:CONDITION\")"`
+	LoadTCoordinator string   `usage:"run load test as the coordinator listening for the workers on the address. the concurrency and max RunN per second are split across the workers"`
+	LoadTWorkers     int      `usage:"number of the workers the coordinator waits for"`
+	LoadTWorker      string   `usage:"run load test as a worker connecting to the coordinator on the address"`
+	LoadTStages      []string `usage:"stages of load test (\"DURATION:CONCURRENT[:MAX_RPS],...\"). the concurrency and max RunN per second change linearly toward the targets of each stage"`
+	Profile          bool     `usage:"profile runs of runbooks"`
+	ProfileOut       string   `usage:"profile output path"`
+	ProfileDepth     int      `usage:"depth of profile"`
+	ProfileUnit      string   `usage:"-"`
+	ProfileSort      string   `usage:"-"`
+	Attach           bool     `usage:"attach to runn process"`
+	Watch            bool     `usage:"watch the runbooks and the files they refer to, and rerun the affected runbooks when they change"`
+	CacheDir         string   `usage:"specify cache directory for remote runbooks"`
+	RetainCacheDir   bool     `usage:"retain cache directory for remote runbooks"`
+	Scopes           []string `usage:"additional scopes for runn"`
+	HostRules        []string `usage:"host rules for runn. (\"host rule,host rule,...\")"`
+	WaitTimeout      string   `usage:"timeout for waiting for cleanup process after running runbooks"`
+	EnvFile          string   `usage:"load environment variables from a file"`
+	ForceColor       bool     `usage:"force colorized output even in non-tty output streams"`
+	Verbose          bool     `usage:"verbose"`
 }
 
 func (f *Flags) ToOpts() ([]runn.Option, error) {
@@ -195,6 +205,13 @@ func (f *Flags) ToOpts() ([]runn.Option, error) {
 		vv := strings.Join(splitted[1:], keyValueSep)
 		opts = append(opts, runn.Runner(vk, vv))
 	}
+	for _, v := range f.LoadTCounters {
+		splitted := strings.Split(v, keyValueSep)
+		if len(splitted) < 2 {
+			return nil, fmt.Errorf("invalid counter: %s", v)
+		}
+		opts = append(opts, runn.LoadtCounter(splitted[0], strings.Join(splitted[1:], keyValueSep)))
+	}
 	for _, o := range f.Overlays {
 		opts = append(opts, runn.Overlay(o))
 	}
@@ -214,6 +231,13 @@ func (f *Flags) ToOpts() ([]runn.Option, error) {
 		}
 		opts = append(opts, runn.Capture(capture.Runbook(f.CaptureDir)))
 	}
+	if f.FixtureDir != "" {
+		mode := runn.FixtureReplay
+		if f.FixtureMode != "" {
+			mode = runn.FixtureMode(f.FixtureMode)
+		}
+		opts = append(opts, runn.Fixtures(f.FixtureDir, mode))
+	}
 	if f.Format == "" {
 		opts = append(opts, runn.Capture(runn.NewCmdOut(os.Stdout, f.Verbose)))
 	}
@@ -0,0 +1,351 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type KafkaOp string
+
+const (
+	KafkaOpProduce KafkaOp = "produce"
+	KafkaOpConsume KafkaOp = "consume"
+)
+
+const (
+	kafkaStoreMessagesKey = "messages"
+	kafkaStoreResponseKey = "res"
+
+	kafkaMessageTopicKey     = "topic"
+	kafkaMessagePartitionKey = "partition"
+	kafkaMessageOffsetKey    = "offset"
+	kafkaMessageKeyKey       = "key"
+	kafkaMessageValueKey     = "value"
+	kafkaMessageJSONKey      = "json"
+	kafkaMessageHeadersKey   = "headers"
+)
+
+const (
+	kafkaOffsetStart = "start"
+	kafkaOffsetEnd   = "end"
+)
+
+const kafkaDefaultConsumeTimeout = 5 * time.Second
+
+// KafkaMessage is a message produced or consumed by the Kafka runner.
+type KafkaMessage struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+}
+
+type kafkaRunner struct {
+	name    string
+	dsn     string
+	brokers []string
+	// topic - Default topic specified in DSN.
+	topic string
+	// client - Client for producing. It is created at the first use.
+	client    *kgo.Client
+	hostRules hostRules
+	// operatorID - The id of the operator for which the runner is defined.
+	operatorID string
+}
+
+type kafkaProduceMessage struct {
+	key     string
+	headers map[string]string
+	// value - Payload of the message. A value other than string is encoded to JSON.
+	value any
+}
+
+type kafkaRequest struct {
+	op    KafkaOp
+	topic string
+	// for produce
+	messages []*kafkaProduceMessage
+	// for consume
+	partition *int32
+	offset    kgo.Offset
+	timeout   time.Duration
+	count     int
+	match     string
+}
+
+func newKafkaRunner(name, dsn string) (*kafkaRunner, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "kafka" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Kafka DSN: %s", dsn)
+	}
+	return &kafkaRunner{
+		name:    name,
+		dsn:     dsn,
+		brokers: strings.Split(u.Host, ","),
+		topic:   strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+func (rnr *kafkaRunner) Close() error {
+	if rnr.client == nil {
+		return nil
+	}
+	rnr.client.Close()
+	rnr.client = nil
+	return nil
+}
+
+func (rnr *kafkaRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	e, err := o.expandBeforeRecord(s.kafkaRequest, s)
+	if err != nil {
+		return err
+	}
+	v, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid Kafka request: %v", e)
+	}
+	req, err := parseKafkaRequest(v)
+	if err != nil {
+		return fmt.Errorf("invalid Kafka request: %w", err)
+	}
+	if req.topic == "" {
+		req.topic = rnr.topic
+	}
+	if req.topic == "" {
+		return errors.New("invalid Kafka request: topic is not specified")
+	}
+	var messages []*KafkaMessage
+	switch req.op {
+	case KafkaOpProduce:
+		messages, err = rnr.produce(ctx, req, s)
+	case KafkaOpConsume:
+		messages, err = rnr.consume(ctx, req, s)
+	default:
+		return fmt.Errorf("invalid op: %v", req.op)
+	}
+	if err != nil {
+		return err
+	}
+	ms := make([]map[string]any, 0, len(messages))
+	for _, m := range messages {
+		ms = append(ms, kafkaMessageToMap(m))
+	}
+	o.record(s.idx, map[string]any{
+		kafkaStoreResponseKey: map[string]any{
+			kafkaStoreMessagesKey: ms,
+		},
+	})
+	return nil
+}
+
+func (rnr *kafkaRunner) opts() []kgo.Opt {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(rnr.brokers...),
+	}
+	if len(rnr.hostRules) > 0 {
+		opts = append(opts, kgo.Dialer(rnr.hostRules.dialContextFunc()))
+	}
+	return opts
+}
+
+func (rnr *kafkaRunner) produce(ctx context.Context, r *kafkaRequest, s *step) ([]*KafkaMessage, error) {
+	o := s.parent
+	// The client is kept across steps until the end of the runbook.
+	if rnr.client == nil {
+		cl, err := kgo.NewClient(rnr.opts()...)
+		if err != nil {
+			return nil, err
+		}
+		rnr.client = cl
+		if err := donegroup.Cleanup(ctx, func() error {
+			// In the case of Reused runners, leave the cleanup to the main cleanup
+			if o.id != rnr.operatorID {
+				return nil
+			}
+			return rnr.Close()
+		}); err != nil {
+			return nil, err
+		}
+	}
+	var records []*kgo.Record
+	for _, m := range r.messages {
+		rec, err := rnr.encodeMessage(r.topic, m)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	if err := rnr.client.ProduceSync(ctx, records...).FirstErr(); err != nil {
+		return nil, err
+	}
+	var messages []*KafkaMessage
+	for _, rec := range records {
+		m := recordToKafkaMessage(rec)
+		o.capturers.captureKafkaProduce(rnr.name, m)
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+func (rnr *kafkaRunner) encodeMessage(topic string, m *kafkaProduceMessage) (*kgo.Record, error) {
+	rec := &kgo.Record{
+		Topic: topic,
+	}
+	if m.key != "" {
+		rec.Key = []byte(m.key)
+	}
+	keys := make([]string, 0, len(m.headers))
+	for k := range m.headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		rec.Headers = append(rec.Headers, kgo.RecordHeader{Key: k, Value: []byte(m.headers[k])})
+	}
+	switch v := m.value.(type) {
+	case nil:
+	case string:
+		rec.Value = []byte(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		rec.Value = b
+	}
+	return rec, nil
+}
+
+// consume consumes messages until the specified number of messages match the condition.
+func (rnr *kafkaRunner) consume(ctx context.Context, r *kafkaRequest, s *step) ([]*KafkaMessage, error) {
+	o := s.parent
+	opts := rnr.opts()
+	if r.partition != nil {
+		opts = append(opts, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{
+			r.topic: {*r.partition: r.offset},
+		}))
+	} else {
+		opts = append(opts, kgo.ConsumeTopics(r.topic), kgo.ConsumeResetOffset(r.offset))
+	}
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cl.Close()
+
+	timeout := r.timeout
+	if timeout == 0 {
+		timeout = kafkaDefaultConsumeTimeout
+	}
+	count := r.count
+	if count == 0 {
+		count = 1
+	}
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var messages []*KafkaMessage
+	for {
+		fetches := cl.PollFetches(cctx)
+		if err := cctx.Err(); err != nil {
+			if ctx.Err() != nil {
+				return messages, ctx.Err()
+			}
+			if r.match != "" {
+				return messages, fmt.Errorf("%d of %d messages matched %q within %s", len(messages), count, r.match, timeout)
+			}
+			return messages, fmt.Errorf("%d of %d messages consumed within %s", len(messages), count, timeout)
+		}
+		if errs := fetches.Errors(); len(errs) > 0 {
+			var err error
+			for _, e := range errs {
+				err = errors.Join(err, fmt.Errorf("%s[%d]: %w", e.Topic, e.Partition, e.Err))
+			}
+			return messages, err
+		}
+		var recs []*kgo.Record
+		fetches.EachRecord(func(rec *kgo.Record) {
+			recs = append(recs, rec)
+		})
+		for _, rec := range recs {
+			m := recordToKafkaMessage(rec)
+			o.capturers.captureKafkaConsume(rnr.name, m)
+			if r.match != "" {
+				tf, err := rnr.evalMatch(r.match, m, s)
+				if err != nil {
+					return messages, err
+				}
+				if !tf {
+					continue
+				}
+			}
+			messages = append(messages, m)
+			if len(messages) >= count {
+				return messages, nil
+			}
+		}
+	}
+}
+
+func (rnr *kafkaRunner) evalMatch(cond string, m *KafkaMessage, s *step) (bool, error) {
+	o := s.parent
+	sm := o.store.ToMap()
+	sm[store.RootKeyIncluded] = o.included
+	if !s.deferred {
+		sm[store.RootKeyPrevious] = o.store.Latest()
+	}
+	// `current` in `match:` is the consumed message.
+	sm[store.RootKeyCurrent] = kafkaMessageToMap(m)
+	return expr.EvalCond(cond, sm)
+}
+
+func recordToKafkaMessage(rec *kgo.Record) *KafkaMessage {
+	m := &KafkaMessage{
+		Topic:     rec.Topic,
+		Partition: rec.Partition,
+		Offset:    rec.Offset,
+		Key:       rec.Key,
+		Value:     rec.Value,
+		Headers:   map[string]string{},
+	}
+	for _, h := range rec.Headers {
+		m.Headers[h.Key] = string(h.Value)
+	}
+	return m
+}
+
+func kafkaMessageToMap(m *KafkaMessage) map[string]any {
+	headers := map[string]any{}
+	for k, v := range m.Headers {
+		headers[k] = v
+	}
+	mm := map[string]any{
+		kafkaMessageTopicKey:     m.Topic,
+		kafkaMessagePartitionKey: int64(m.Partition),
+		kafkaMessageOffsetKey:    m.Offset,
+		kafkaMessageKeyKey:       string(m.Key),
+		kafkaMessageValueKey:     string(m.Value),
+		kafkaMessageHeadersKey:   headers,
+	}
+	var v any
+	if err := json.Unmarshal(m.Value, &v); err == nil {
+		mm[kafkaMessageJSONKey] = v
+	}
+	return mm
+}
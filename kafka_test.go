@@ -0,0 +1,132 @@
+package runn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestKafkaRunner(t *testing.T) {
+	ctx := context.Background()
+	dsn := testutil.KafkaCluster(t, "events", "notifications")
+	o, err := New(Book("testdata/book/kafka.yml"), KafkaRunner("mq", dsn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKafkaRunnerConsume(t *testing.T) {
+	tests := []struct {
+		name    string
+		consume map[string]any
+		wantErr string
+		want    int
+	}{
+		{
+			"consume one message",
+			map[string]any{},
+			"",
+			1,
+		},
+		{
+			"consume messages",
+			map[string]any{"count": uint64(3)},
+			"",
+			3,
+		},
+		{
+			"consume matched messages",
+			map[string]any{"match": `current.json.seq >= 1`, "count": uint64(2)},
+			"",
+			2,
+		},
+		{
+			"consume from offset",
+			map[string]any{"partition": uint64(0), "offset": uint64(2), "count": uint64(1)},
+			"",
+			1,
+		},
+		{
+			"timeout",
+			map[string]any{"count": uint64(4), "timeout": "500msec"},
+			"3 of 4 messages consumed within 500ms",
+			0,
+		},
+		{
+			"no message matched",
+			map[string]any{"match": `current.json.seq == 10`, "timeout": "500msec"},
+			`0 of 1 messages matched "current.json.seq == 10" within 500ms`,
+			0,
+		},
+		{
+			"consume from end",
+			map[string]any{"offset": "end", "timeout": "500msec"},
+			"0 of 1 messages consumed within 500ms",
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			dsn := testutil.KafkaCluster(t, "events")
+			o, err := New(KafkaRunner("mq", dsn))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, ok := o.kafkaRunners["mq"]
+			if !ok {
+				t.Fatal("kafka runner not found")
+			}
+			ps := newStep(0, "produce", o, nil)
+			ps.kafkaRequest = map[string]any{
+				"produce": map[string]any{
+					"messages": []any{
+						map[string]any{"value": `{"seq":0}`},
+						map[string]any{"value": `{"seq":1}`},
+						map[string]any{"value": `{"seq":2}`},
+					},
+				},
+			}
+			if err := r.Run(ctx, ps); err != nil {
+				t.Fatal(err)
+			}
+			s := newStep(1, "consume", o, nil)
+			s.kafkaRequest = map[string]any{"consume": tt.consume}
+			if err := r.Run(ctx, s); err != nil {
+				if tt.wantErr == "" {
+					t.Fatal(err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("want error %q", tt.wantErr)
+			}
+			sm := o.store.ToMap()
+			sl, ok := sm["steps"].([]map[string]any)
+			if !ok {
+				t.Fatal("steps not found")
+			}
+			res, ok := sl[1]["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %v", sl[1])
+			}
+			got, ok := res["messages"].([]map[string]any)
+			if !ok {
+				t.Fatalf("invalid messages: %v", res["messages"])
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %v, want %v", len(got), tt.want)
+			}
+		})
+	}
+}
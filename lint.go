@@ -0,0 +1,559 @@
+package runn
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+)
+
+// LintProblem is a problem of a runbook found by Lint.
+type LintProblem struct {
+	Path string
+	// Line - Line number of the problem. 0 means unknown.
+	Line    int
+	Message string
+}
+
+func (p *LintProblem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.Path, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", p.Path, p.Line, p.Message)
+}
+
+// runbookLinter finds problems of a runbook without running it.
+type runbookLinter struct {
+	op *operator
+	bk *book
+	// included - Whether the runbook is included by other runbooks, which may provide the runners.
+	included bool
+	lines    []string
+	areas    *areas
+	problems []*LintProblem
+}
+
+// Lint statically validates the selected runbooks.
+// It validates the runner configs, the sections and the requests of steps, the syntax of expressions and the references to `steps`.
+func (opn *operatorN) Lint() ([]*LintProblem, error) {
+	var problems []*LintProblem
+	for _, op := range opn.ops {
+		l, err := newRunbookLinter(op)
+		if err != nil {
+			return nil, err
+		}
+		_, l.included = opn.included[op.bookPath]
+		problems = append(problems, l.lint()...)
+	}
+	return problems, nil
+}
+
+func newRunbookLinter(op *operator) (*runbookLinter, error) {
+	b, err := os.ReadFile(op.bookPath)
+	if err != nil {
+		return nil, err
+	}
+	return &runbookLinter{
+		op:    op,
+		lines: strings.Split(string(b), "\n"),
+		areas: detectRunbookAreas(string(b)),
+	}, nil
+}
+
+func (l *runbookLinter) lint() []*LintProblem {
+	// Reload the runbook, because the steps of the operator loaded with LoadOnly() skip invalid steps.
+	bk, err := loadBook(l.op.bookPath, nil)
+	if err != nil {
+		l.report(nil, nil, err.Error())
+		return l.problems
+	}
+	l.bk = bk
+	l.lintRunners()
+	whole := &area{Start: &position{Line: 1}, End: &position{Line: len(l.lines)}}
+	if bk.ifCond != "" {
+		l.lintExpr(whole, bk.ifCond)
+	}
+	if bk.loop != nil {
+		l.lintLoop(whole, bk.loop)
+	}
+	l.lintSteps()
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		if l.problems[i].Line != l.problems[j].Line {
+			return l.problems[i].Line < l.problems[j].Line
+		}
+		return l.problems[i].Message < l.problems[j].Message
+	})
+	return l.problems
+}
+
+func (l *runbookLinter) lintRunners() {
+	keys := make([]string, 0, len(l.bk.runnerErrs))
+	for k := range l.bk.runnerErrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if l.op.hasRunner(k) {
+			// Overridden by the options.
+			continue
+		}
+		line := 0
+		if l.areas.Runners != nil {
+			line = l.findLine(l.areas.Runners, regexp.MustCompile(fmt.Sprintf(`^\s*%s\s*:`, regexp.QuoteMeta(k))))
+		}
+		l.problems = append(l.problems, &LintProblem{
+			Path:    l.op.bookPath,
+			Line:    line,
+			Message: fmt.Sprintf("invalid runner %s: %s", k, firstLine(l.bk.runnerErrs[k].Error())),
+		})
+	}
+
+	// Unknown keys of the runner configs are ignored by runn, so check them using the schema.
+	names := make([]string, 0, len(l.bk.runners))
+	for k := range l.bk.runners {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if _, ok := l.bk.runnerErrs[k]; ok {
+			continue
+		}
+		cs := runnerConfigSchema(l.runnerConfigDef(k))
+		if cs == nil {
+			continue
+		}
+		for _, uk := range unknownKeys(l.bk.runners[k], cs) {
+			l.report(l.areas.Runners, keyPattern(uk), fmt.Sprintf("invalid runner %s: unknown key %q", k, uk))
+		}
+	}
+}
+
+// runnerConfigDef returns the schema definition of the config of the runner parsed from the map.
+func (l *runbookLinter) runnerConfigDef(k string) string {
+	if _, ok := l.bk.runners[k].(map[string]any); !ok {
+		// DSN
+		return ""
+	}
+	if _, ok := l.bk.httpRunners[k]; ok {
+		return schemaDefHTTPRunnerConfig
+	}
+	if _, ok := l.bk.mockRunners[k]; ok {
+		return schemaDefMockRunnerConfig
+	}
+	if _, ok := l.bk.grpcRunners[k]; ok {
+		return schemaDefGRPCRunnerConfig
+	}
+	if _, ok := l.bk.dbRunners[k]; ok {
+		return schemaDefDBRunnerConfig
+	}
+	if _, ok := l.bk.sshRunners[k]; ok {
+		return schemaDefSSHRunnerConfig
+	}
+	if _, ok := l.bk.includeRunners[k]; ok {
+		return schemaDefIncludeRunnerConfig
+	}
+	return ""
+}
+
+func (l *runbookLinter) lintSteps() {
+	// Parse the steps again on the scratch operator that has the same runners to detect the runner of each step.
+	sop := &operator{
+		httpRunners:    l.op.httpRunners,
+		dbRunners:      l.op.dbRunners,
+		grpcRunners:    l.op.grpcRunners,
+		cdpRunners:     l.op.cdpRunners,
+		sshRunners:     l.op.sshRunners,
+		wsRunners:      l.op.wsRunners,
+		kafkaRunners:   l.op.kafkaRunners,
+		redisRunners:   l.op.redisRunners,
+		smtpRunners:    l.op.smtpRunners,
+		mockRunners:    l.op.mockRunners,
+		includeRunners: l.op.includeRunners,
+		debug:          l.op.debug,
+	}
+	schemas := requestSchemas()
+	schemas[execRunnerKey] = stepSchema().Properties[execRunnerKey]
+	for i, s := range l.bk.rawSteps {
+		a := l.stepArea(i)
+		key := strconv.Itoa(i)
+		if l.bk.useMap {
+			key = l.bk.stepKeys[i]
+		}
+		raw := copyStepMap(s)
+		if err := sop.appendStep(i, key, s); err != nil {
+			if errors.Is(err, errClientNotFound) && (l.included || l.declaresRunner(raw)) {
+				// The runner is provided by the including runbook, or its problem is already reported.
+				continue
+			}
+			l.report(a, nil, fmt.Sprintf("invalid steps[%s]: %s", key, firstLine(err.Error())))
+			continue
+		}
+		st := sop.steps[len(sop.steps)-1]
+		if st.ifCond != "" {
+			l.lintExpr(a, st.ifCond)
+		}
+		if st.loop != nil {
+			l.lintLoop(a, st.loop)
+		}
+		if st.testRunner != nil {
+			l.lintExpr(a, st.testCond)
+		}
+		if st.dumpRunner != nil {
+			l.lintExpr(a, st.dumpRequest.expr)
+		}
+		if st.bindRunner != nil {
+			l.lintExpansions(a, st.bindCond, true)
+		}
+		if st.runnerKey != "" {
+			l.lintExpansions(a, raw[st.runnerKey], false)
+		}
+		l.lintRequest(a, key, st, schemas)
+	}
+}
+
+// lintRequest lints the unknown keys of the request of the step and parses it in the same way as the runner.
+func (l *runbookLinter) lintRequest(a *area, key string, st *step, schemas map[string]*jsonSchema) {
+	def, req, parse := stepRequest(st)
+	if parse == nil {
+		return
+	}
+	if keys := unknownKeys(req, schemas[def]); len(keys) > 0 {
+		for _, k := range keys {
+			l.report(a, keyPattern(k), fmt.Sprintf("invalid steps[%s]: unknown key %q", key, k))
+		}
+		return
+	}
+	if hasExpansions(req) {
+		// The request may be valid after expanding `{{ }}` at run time.
+		return
+	}
+	if err := parse(dcopy(req).(map[string]any)); err != nil {
+		l.report(a, nil, fmt.Sprintf("invalid steps[%s]: %s", key, firstLine(err.Error())))
+	}
+}
+
+// stepRequest returns the schema definition, the request and the parser of the request of the runner of the step.
+func stepRequest(st *step) (string, map[string]any, func(map[string]any) error) {
+	// Values are not expanded at lint time.
+	noExpand := func(v any, _ *step) (any, error) {
+		return v, nil
+	}
+	switch {
+	case st.httpRunner != nil:
+		return schemaDefHTTPRequest, st.httpRequest, func(v map[string]any) error {
+			_, err := parseHTTPRequest(v)
+			return err
+		}
+	case st.dbRunner != nil:
+		return schemaDefDBQuery, st.dbQuery, func(v map[string]any) error {
+			_, err := parseDBQuery(v)
+			return err
+		}
+	case st.grpcRunner != nil:
+		return schemaDefGRPCRequest, st.grpcRequest, func(v map[string]any) error {
+			_, err := parseGrpcRequest(v, st, noExpand)
+			return err
+		}
+	case st.cdpRunner != nil:
+		return schemaDefCDPActions, st.cdpActions, func(v map[string]any) error {
+			_, err := parseCDPActions(v, st, noExpand)
+			return err
+		}
+	case st.sshRunner != nil:
+		return schemaDefSSHCommand, st.sshCommand, func(v map[string]any) error {
+			_, err := parseSSHCommand(v, st, noExpand)
+			return err
+		}
+	case st.wsRunner != nil:
+		return schemaDefWebSocketRequest, st.wsRequest, func(v map[string]any) error {
+			_, err := parseWebSocketRequest(v, st, noExpand)
+			return err
+		}
+	case st.kafkaRunner != nil:
+		return schemaDefKafkaRequest, st.kafkaRequest, func(v map[string]any) error {
+			_, err := parseKafkaRequest(v)
+			return err
+		}
+	case st.redisRunner != nil:
+		return schemaDefRedisRequest, st.redisRequest, func(v map[string]any) error {
+			_, err := parseRedisRequest(v)
+			return err
+		}
+	case st.smtpRunner != nil:
+		return schemaDefSMTPRequest, st.smtpRequest, func(v map[string]any) error {
+			_, err := parseSMTPRequest(v)
+			return err
+		}
+	case st.mockRunner != nil:
+		return schemaDefMockRequest, st.mockRequest, func(v map[string]any) error {
+			_, err := parseMockRequest(v)
+			return err
+		}
+	case st.execRunner != nil:
+		return execRunnerKey, st.execCommand, func(v map[string]any) error {
+			_, err := parseExecCommand(v)
+			return err
+		}
+	}
+	return "", nil, nil
+}
+
+// unknownKeys returns the keys of the value that are not allowed by the schema.
+func unknownKeys(v any, s *jsonSchema) []string {
+	if s == nil {
+		return nil
+	}
+	var keys []string
+	switch vv := v.(type) {
+	case map[string]any:
+		if ms := schemaAlternative(s, "object"); ms != nil {
+			s = ms
+		}
+		for k, vvv := range vv {
+			if strings.Contains(k, "{{") {
+				// The key is expanded at run time.
+				continue
+			}
+			if s.PropertyNames != nil && len(s.PropertyNames.Enum) > 0 && !slices.Contains(s.PropertyNames.Enum, any(k)) {
+				keys = append(keys, k)
+				continue
+			}
+			if p, ok := s.Properties[k]; ok {
+				keys = append(keys, unknownKeys(vvv, p)...)
+				continue
+			}
+			switch ap := s.AdditionalProperties.(type) {
+			case *jsonSchema:
+				keys = append(keys, unknownKeys(vvv, ap)...)
+			case bool:
+				if !ap {
+					keys = append(keys, k)
+				}
+			}
+		}
+	case []any:
+		as := schemaAlternative(s, "array")
+		if as == nil {
+			return nil
+		}
+		for _, vvv := range vv {
+			keys = append(keys, unknownKeys(vvv, as.Items)...)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// schemaAlternative returns the schema of the type in the schema or its `anyOf`.
+func schemaAlternative(s *jsonSchema, typ string) *jsonSchema {
+	if s.Type == typ {
+		return s
+	}
+	for _, ss := range s.AnyOf {
+		if ss.Type == typ {
+			return ss
+		}
+	}
+	return nil
+}
+
+// hasExpansions reports whether the value includes `{{ }}` that is expanded at run time.
+func hasExpansions(v any) bool {
+	switch vv := v.(type) {
+	case string:
+		return strings.Contains(vv, "{{")
+	case map[string]any:
+		for k, vvv := range vv {
+			if hasExpansions(k) || hasExpansions(vvv) {
+				return true
+			}
+		}
+	case []any:
+		for _, vvv := range vv {
+			if hasExpansions(vvv) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keyPattern returns the pattern of the line of the key.
+func keyPattern(k string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`^\s*-?\s*%s\s*:`, regexp.QuoteMeta(k)))
+}
+
+func (l *runbookLinter) lintLoop(a *area, loop *Loop) {
+	if _, err := strconv.Atoi(loop.Count); err != nil {
+		l.lintExpr(a, loop.Count)
+	}
+	if loop.Until != "" {
+		l.lintExpr(a, loop.Until)
+	}
+}
+
+// lintExpansions lints the expressions in `{{ }}` of the values.
+// If bare is true, the string values are also linted as expressions.
+func (l *runbookLinter) lintExpansions(a *area, v any, bare bool) {
+	switch vv := v.(type) {
+	case string:
+		if bare && !strings.Contains(vv, "{{") {
+			l.lintExpr(a, vv)
+			return
+		}
+		for _, e := range expr.Expansions(vv) {
+			l.lintExpr(a, e)
+		}
+	case map[string]any:
+		for k, vvv := range vv {
+			l.lintExpansions(a, k, false)
+			l.lintExpansions(a, vvv, bare)
+		}
+	case []any:
+		for _, vvv := range vv {
+			l.lintExpansions(a, vvv, bare)
+		}
+	}
+}
+
+// lintExpr lints the syntax of the expression and the references to `steps`.
+func (l *runbookLinter) lintExpr(a *area, e string) {
+	refs, err := expr.References(e, store.RootKeySteps)
+	if err != nil {
+		l.report(a, regexp.MustCompile(regexp.QuoteMeta(firstLine(e))), fmt.Sprintf("invalid expression %q: %s", firstLine(e), firstLine(err.Error())))
+		return
+	}
+	for _, ref := range refs {
+		switch v := ref.(type) {
+		case string:
+			re := regexp.MustCompile(fmt.Sprintf(`%s\??\.%s\b|%s\[["']%s["']\]`, store.RootKeySteps, regexp.QuoteMeta(v), store.RootKeySteps, regexp.QuoteMeta(v)))
+			if !l.bk.useMap {
+				l.report(a, re, fmt.Sprintf("invalid reference steps.%s: steps are not defined as map", v))
+				continue
+			}
+			found := false
+			for _, k := range l.bk.stepKeys {
+				if k == v {
+					found = true
+					break
+				}
+			}
+			if !found {
+				l.report(a, re, fmt.Sprintf("invalid reference steps.%s: step %q is not found", v, v))
+			}
+		case int:
+			re := regexp.MustCompile(fmt.Sprintf(`%s\??\[%d\]`, store.RootKeySteps, v))
+			if l.bk.useMap {
+				l.report(a, re, fmt.Sprintf("invalid reference steps[%d]: steps are defined as map", v))
+				continue
+			}
+			if v >= len(l.bk.rawSteps) {
+				l.report(a, re, fmt.Sprintf("invalid reference steps[%d]: out of range (%d steps)", v, len(l.bk.rawSteps)))
+			}
+		}
+	}
+}
+
+// report reports the problem at the first line matching the pattern in the area.
+func (l *runbookLinter) report(a *area, re *regexp.Regexp, msg string) {
+	line := 0
+	if a != nil {
+		line = a.Start.Line
+		if re != nil {
+			line = l.findLine(a, re)
+		}
+	}
+	for _, p := range l.problems {
+		if p.Line == line && p.Message == msg {
+			return
+		}
+	}
+	l.problems = append(l.problems, &LintProblem{
+		Path:    l.op.bookPath,
+		Line:    line,
+		Message: msg,
+	})
+}
+
+// declaresRunner reports whether the step uses a runner declared in `runners:`.
+func (l *runbookLinter) declaresRunner(s map[string]any) bool {
+	for k := range s {
+		if _, ok := l.bk.runners[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// findLine returns the first line matching the pattern in the area, or the start line of the area.
+func (l *runbookLinter) findLine(a *area, re *regexp.Regexp) int {
+	for i := a.Start.Line; i <= a.End.Line && i <= len(l.lines); i++ {
+		if re.MatchString(l.lines[i-1]) {
+			return i
+		}
+	}
+	return a.Start.Line
+}
+
+func (l *runbookLinter) stepArea(idx int) *area {
+	if idx < len(l.areas.Steps) {
+		return l.areas.Steps[idx]
+	}
+	return nil
+}
+
+func (op *operator) hasRunner(k string) bool {
+	if _, ok := op.httpRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.dbRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.grpcRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.cdpRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.sshRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.wsRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.kafkaRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.redisRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.smtpRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.mockRunners[k]; ok {
+		return true
+	}
+	if _, ok := op.includeRunners[k]; ok {
+		return true
+	}
+	return false
+}
+
+func copyStepMap(s map[string]any) map[string]any {
+	c := make(map[string]any, len(s))
+	for k, v := range s {
+		c[k] = v
+	}
+	return c
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(strings.TrimSpace(s), "\n", 2)[0])
+}
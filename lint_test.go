@@ -0,0 +1,79 @@
+package runn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		book string
+		want []string
+	}{
+		{"testdata/book/always_success.yml", nil},
+		{"testdata/book/include_main.yml", nil},
+		{
+			"testdata/lint_problems.yml",
+			[]string{
+				`testdata/lint_problems.yml:4: invalid runner invalid: cannot detect runner: unknown: value`,
+				`testdata/lint_problems.yml:16: invalid reference steps.logni: step "logni" is not found`,
+				`testdata/lint_problems.yml:18: invalid expression "current.res.status ==": parse error: unexpected token EOF (1:21)`,
+				`testdata/lint_problems.yml:22: invalid expression "steps.login.res.body.id)": parse error: unexpected token Bracket(")") (1:24)`,
+				`testdata/lint_problems.yml:25: invalid steps[unknown]: cannot find client: notfound`,
+				`testdata/lint_problems.yml:29: invalid reference steps[0]: steps are defined as map`,
+			},
+		},
+		{
+			"testdata/lint_unknown_keys.yml",
+			[]string{
+				`testdata/lint_unknown_keys.yml:5: invalid runner req: unknown key "opnapi3"`,
+				`testdata/lint_unknown_keys.yml:9: invalid runner db: unknown key "transacton"`,
+				`testdata/lint_unknown_keys.yml:15: invalid steps[0]: unknown key "timeuot"`,
+				`testdata/lint_unknown_keys.yml:20: invalid steps[1]: unknown key "parms"`,
+				`testdata/lint_unknown_keys.yml:25: invalid steps[2]: unknown key "gett"`,
+				`testdata/lint_unknown_keys.yml:27: invalid steps[3]: invalid request: graphql requires POST method: /users:`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			opn, err := Load(tt.book, LoadOnly())
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems, err := opn.Lint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.String())
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestLintWithoutSideEffects(t *testing.T) {
+	opn, err := Load("testdata/lint_problems.yml", LoadOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := opn.ops[0]
+	want := make([]*step, len(op.steps))
+	copy(want, op.steps)
+	if _, err := opn.Lint(); err != nil {
+		t.Fatal(err)
+	}
+	if len(op.steps) != len(want) {
+		t.Fatalf("got %d steps, want %d", len(op.steps), len(want))
+	}
+	for i := range want {
+		if op.steps[i] != want[i] {
+			t.Errorf("steps[%d] is modified", i)
+		}
+	}
+}
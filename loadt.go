@@ -1,22 +1,34 @@
 package runn
 
 import (
+	"encoding/csv"
 	"fmt"
 	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/goccy/go-json"
 	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/exprtrace"
 	or "github.com/ryo-yamaoka/otchkiss/result"
 )
 
 const reportTemplate = `
 Number of runbooks per RunN....: {{ .NumberOfRunbooks }}
 Warm up time (--warm-up).......: {{ .WarmUpTime }}
+{{- if .Stages }}
+Stages (--stages)..............: {{ .StageSpecs }}
+Duration.......................: {{ .Duration }}
+{{- else }}
 Duration (--duration)..........: {{ .Duration }}
 Concurrent (--load-concurrent).: {{ .MaxConcurrent }}
 Max RunN per second (--max-rps): {{ .MaxRPS }}
+{{- end }}
 
 Total..........................: {{ .TotalRequests }}
 Succeeded......................: {{ .Succeeded }}
@@ -24,9 +36,35 @@ Failed.........................: {{ .Failed }}
 Error rate.....................: {{ .ErrorRate }}%
 RunN per second................: {{ .RPS }}
 Latency .......................: max={{ .MaxLatency }}ms min={{ .MinLatency }}ms avg={{ .AvgLatency }}ms med={{ .MedLatency }}ms p(90)={{ .Latency90p }}ms p(99)={{ .Latency99p }}ms
+{{- if .Counters }}
+
+Counters.......................:
+{{- range .Counters }}
+  {{ .Name }}: {{ .Value }}
+{{- end }}
+{{- end }}
+{{- if .Stages }}
+
+Result per stage...............:
+{{- range .Stages }}
+  [{{ .Index }}] {{ .Spec }}: total={{ .Total }} failed={{ .Failed }} error_rate={{ .ErrorRate }}% rps={{ .RPS }} {{ .Latency }}
+{{- end }}
+{{- end }}
+{{- if .Runbooks }}
+
+Latency per runbook and step...:
+{{- range .Runbooks }}
+  {{ .Path }}: total={{ .Total }} failed={{ .Failed }} {{ .Latency }}
+  {{- range .Steps }}
+    {{ .Name }}: total={{ .Total }} failed={{ .Failed }} {{ .Latency }}
+  {{- end }}
+{{- end }}
+{{- end }}
 
 `
 
+var loadtCSVHeader = []string{"type", "id", "path", "step", "desc", "total", "failed", "max", "mid", "min", "p90", "p95", "p99", "avg"}
+
 type loadtResult struct {
 	runbookCount int64
 	warmUp       time.Duration
@@ -41,12 +79,112 @@ type loadtResult struct {
 	max          float64
 	min          float64
 	p99          float64
+	p95          float64
 	p90          float64
 	p50          float64
 	avg          float64
+	// runbooks - Latencies of each runbook and its steps.
+	runbooks []*loadtRunbookResult
+	// stages - Results of each stage.
+	stages []*loadtStageResult
+	// counters - Values of the custom counters.
+	counters map[string]int64
+}
+
+// loadtLatency is the latency percentiles in milliseconds.
+type loadtLatency struct {
+	Max float64 `json:"max"`
+	Mid float64 `json:"mid"`
+	Min float64 `json:"min"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Avg float64 `json:"avg"`
+}
+
+type loadtRunbookResult struct {
+	ID      string             `json:"id"`
+	Path    string             `json:"path"`
+	Desc    string             `json:"desc"`
+	Total   int64              `json:"total"`
+	Failed  int64              `json:"failed"`
+	Latency loadtLatency       `json:"latency"`
+	Steps   []*loadtStepResult `json:"steps"`
+}
+
+type loadtStepResult struct {
+	Key     string       `json:"key"`
+	Desc    string       `json:"desc"`
+	Total   int64        `json:"total"`
+	Failed  int64        `json:"failed"`
+	Latency loadtLatency `json:"latency"`
+	// name - Name of step for the text report.
+	name string
+}
+
+type loadtStageResult struct {
+	Duration   string       `json:"duration"`
+	Concurrent int          `json:"concurrent"`
+	MaxRPS     int          `json:"max_rps"`
+	Total      int64        `json:"total"`
+	Succeeded  int64        `json:"succeeded"`
+	Failed     int64        `json:"failed"`
+	ErrorRate  float64      `json:"error_rate"`
+	RPS        float64      `json:"rps"`
+	Latency    loadtLatency `json:"latency"`
+	// spec - Stage spec for the text report.
+	spec string
+}
+
+type loadtReport struct {
+	NumberOfRunbooks int64                 `json:"number_of_runbooks"`
+	WarmUp           string                `json:"warm_up"`
+	Duration         string                `json:"duration"`
+	Concurrent       int64                 `json:"concurrent"`
+	MaxRPS           int64                 `json:"max_rps"`
+	Total            int64                 `json:"total"`
+	Succeeded        int64                 `json:"succeeded"`
+	Failed           int64                 `json:"failed"`
+	ErrorRate        float64               `json:"error_rate"`
+	RPS              float64               `json:"rps"`
+	Latency          loadtLatency          `json:"latency"`
+	Counters         map[string]int64      `json:"counters,omitempty"`
+	Stages           []*loadtStageResult   `json:"stages,omitempty"`
+	Runbooks         []*loadtRunbookResult `json:"runbooks"`
+}
+
+// loadtSample is the elapsed time of a runbook or a step in a RunN of the load test.
+type loadtSample struct {
+	stoppedAt time.Time
+	elapsed   time.Duration
+	failed    bool
+}
+
+type loadtRunbookSamples struct {
+	path    string
+	desc    string
+	samples []loadtSample
+	steps   []*loadtStepSamples
+}
+
+type loadtStepSamples struct {
+	key     string
+	desc    string
+	name    string
+	samples []loadtSample
 }
 
-func NewLoadtResult(rc int, w, d time.Duration, c, m int, r *or.Result) (*loadtResult, error) {
+// loadtCounter is a custom counter of the load test that counts the runs of runbooks meeting the condition.
+type loadtCounter struct {
+	name string
+	cond string
+	// counted - Stopped times of the RunNs in which the condition is met.
+	counted []time.Time
+}
+
+// NewLoadtResult returns the result of the load test.
+// If opn is not nil, the latencies of each runbook and its steps recorded by opn are also included.
+func NewLoadtResult(rc int, w, d time.Duration, c, m int, r *or.Result, opn *operatorN) (*loadtResult, error) {
 	succeeded := r.Succeeded()
 	failed := r.Failed()
 	total := succeeded + failed
@@ -65,6 +203,10 @@ func NewLoadtResult(rc int, w, d time.Duration, c, m int, r *or.Result) (*loadtR
 	if err != nil {
 		return nil, err
 	}
+	p95, err := r.PercentileLatency(95)
+	if err != nil {
+		return nil, err
+	}
 	p90, err := r.PercentileLatency(90)
 	if err != nil {
 		return nil, err
@@ -81,6 +223,15 @@ func NewLoadtResult(rc int, w, d time.Duration, c, m int, r *or.Result) (*loadtR
 	}
 	avg = avg / float64(len(ll))
 
+	var (
+		runbooks []*loadtRunbookResult
+		counters map[string]int64
+	)
+	if opn != nil {
+		runbooks = opn.loadtRunbookResults(w)
+		counters = opn.loadtCounterValues(w)
+	}
+
 	return &loadtResult{
 		runbookCount: int64(rc),
 		warmUp:       w,
@@ -95,18 +246,117 @@ func NewLoadtResult(rc int, w, d time.Duration, c, m int, r *or.Result) (*loadtR
 		max:          max,
 		min:          min,
 		p99:          p99,
+		p95:          p95,
 		p90:          p90,
 		p50:          p50,
 		avg:          avg,
+		runbooks:     runbooks,
+		counters:     counters,
 	}, nil
 }
 
+// NewLoadtResultFromStager returns the result of the load test run by the stager.
+func NewLoadtResultFromStager(rc int, w time.Duration, st *loadtStager, opn *operatorN) (*loadtResult, error) {
+	lr, err := NewLoadtResult(rc, w, st.Duration(), st.MaxConcurrent(), st.MaxRPS(), st.Result, opn)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range st.stages {
+		lr.stages = append(lr.stages, newLoadtStageResult(s, st.StageResults[i]))
+	}
+	return lr, nil
+}
+
+func newLoadtStageResult(s *loadtStage, r *or.Result) *loadtStageResult {
+	succeeded := r.Succeeded()
+	failed := r.Failed()
+	total := succeeded + failed
+	sr := &loadtStageResult{
+		Duration:   s.duration.String(),
+		Concurrent: s.concurrent,
+		MaxRPS:     s.maxRPS,
+		Total:      total,
+		Succeeded:  succeeded,
+		Failed:     failed,
+		RPS:        float64(total) / s.duration.Seconds(),
+		spec:       s.String(),
+	}
+	if total == 0 {
+		return sr
+	}
+	sr.ErrorRate = float64(failed) / float64(total) * 100
+	var (
+		ll  []float64
+		sum float64
+	)
+	for _, l := range r.Latencies() {
+		ll = append(ll, l*1000)
+		sum += l * 1000
+	}
+	sort.Float64s(ll)
+	sr.Latency = loadtLatency{
+		Max: percentileLatency(ll, 100),
+		Mid: percentileLatency(ll, 50),
+		Min: percentileLatency(ll, 0),
+		P90: percentileLatency(ll, 90),
+		P95: percentileLatency(ll, 95),
+		P99: percentileLatency(ll, 99),
+		Avg: sum / float64(len(ll)),
+	}
+	return sr
+}
+
 func (r *loadtResult) Report(w io.Writer) error {
 	tmpl, err := template.New("report").Parse(reportTemplate)
 	if err != nil {
 		return err
 	}
 
+	var runbooks []map[string]any
+	for _, rb := range r.runbooks {
+		var steps []map[string]any
+		for _, st := range rb.Steps {
+			steps = append(steps, map[string]any{
+				"Name":    st.name,
+				"Total":   st.Total,
+				"Failed":  st.Failed,
+				"Latency": st.Latency.String(),
+			})
+		}
+		runbooks = append(runbooks, map[string]any{
+			"Path":    rb.Path,
+			"Total":   rb.Total,
+			"Failed":  rb.Failed,
+			"Latency": rb.Latency.String(),
+			"Steps":   steps,
+		})
+	}
+
+	var (
+		stages     []map[string]any
+		stageSpecs []string
+	)
+	for i, st := range r.stages {
+		stages = append(stages, map[string]any{
+			"Index":     i + 1,
+			"Spec":      st.spec,
+			"Total":     st.Total,
+			"Failed":    st.Failed,
+			"ErrorRate": humanize.CommafWithDigits(st.ErrorRate, 1),
+			"RPS":       humanize.CommafWithDigits(st.RPS, 1),
+			"Latency":   st.Latency.String(),
+		})
+		stageSpecs = append(stageSpecs, st.spec)
+	}
+
+	var counters []map[string]any
+	for _, name := range r.counterNames() {
+		counters = append(counters, map[string]any{
+			"Name":  name,
+			"Value": r.counters[name],
+		})
+	}
+
 	data := map[string]any{
 		"NumberOfRunbooks": r.runbookCount,
 		"WarmUpTime":       r.warmUp.String(),
@@ -124,6 +374,10 @@ func (r *loadtResult) Report(w io.Writer) error {
 		"MedLatency":       humanize.CommafWithDigits(r.p50*1000, 1),
 		"Latency90p":       humanize.CommafWithDigits(r.p90*1000, 1),
 		"Latency99p":       humanize.CommafWithDigits(r.p99*1000, 1),
+		"Runbooks":         runbooks,
+		"Stages":           stages,
+		"Counters":         counters,
+		"StageSpecs":       strings.Join(stageSpecs, ","),
 	}
 	if err := tmpl.Execute(w, data); err != nil {
 		return err
@@ -131,11 +385,97 @@ func (r *loadtResult) Report(w io.Writer) error {
 	return nil
 }
 
+// ReportJSON writes the result of the load test in JSON.
+func (r *loadtResult) ReportJSON(w io.Writer) error {
+	runbooks := r.runbooks
+	if runbooks == nil {
+		runbooks = []*loadtRunbookResult{}
+	}
+	report := &loadtReport{
+		NumberOfRunbooks: r.runbookCount,
+		WarmUp:           r.warmUp.String(),
+		Duration:         r.duration.String(),
+		Concurrent:       r.concurrent,
+		MaxRPS:           r.maxRPS,
+		Total:            r.total,
+		Succeeded:        r.succeeded,
+		Failed:           r.failed,
+		ErrorRate:        r.errorRate,
+		RPS:              r.rps,
+		Latency:          r.latency(),
+		Counters:         r.counters,
+		Stages:           r.stages,
+		Runbooks:         runbooks,
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, string(b)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReportCSV writes the latencies of the whole RunN, each stage, each runbook and each step in CSV.
+func (r *loadtResult) ReportCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(loadtCSVHeader); err != nil {
+		return err
+	}
+	if err := cw.Write(loadtCSVRecord("total", "", "", "", "", r.total, r.failed, r.latency())); err != nil {
+		return err
+	}
+	for _, name := range r.counterNames() {
+		rec := loadtCSVRecord("counter", name, "", "", "", r.counters[name], 0, loadtLatency{})
+		// Counters have neither failures nor latencies.
+		for i := 6; i < len(rec); i++ {
+			rec[i] = ""
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	for i, st := range r.stages {
+		if err := cw.Write(loadtCSVRecord("stage", strconv.Itoa(i+1), "", "", st.spec, st.Total, st.Failed, st.Latency)); err != nil {
+			return err
+		}
+	}
+	for _, rb := range r.runbooks {
+		if err := cw.Write(loadtCSVRecord("runbook", rb.ID, rb.Path, "", rb.Desc, rb.Total, rb.Failed, rb.Latency)); err != nil {
+			return err
+		}
+		for _, st := range rb.Steps {
+			if err := cw.Write(loadtCSVRecord("step", rb.ID, rb.Path, st.Key, st.Desc, st.Total, st.Failed, st.Latency)); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
 func (r *loadtResult) CheckThreshold(threshold string) error {
 	if threshold == "" {
 		return nil
 	}
-	store := map[string]any{
+	tf, err := expr.EvalWithTrace(threshold, r.thresholdEnv())
+	if err != nil {
+		return err
+	}
+	if !tf.OutputAsBool() {
+		bt, err := tf.FormatTraceTree()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("threshold is not met: %w", newCondFalseError(threshold, bt))
+	}
+	return nil
+}
+
+// thresholdEnv returns the variables for the threshold.
+func (r *loadtResult) thresholdEnv() exprtrace.EvalEnv {
+	env := exprtrace.EvalEnv{
 		"total":      r.total,
 		"succeeded":  r.succeeded,
 		"failed":     r.failed,
@@ -145,19 +485,307 @@ func (r *loadtResult) CheckThreshold(threshold string) error {
 		"mid":        r.p50 * 1000,
 		"min":        r.min * 1000,
 		"p90":        r.p90 * 1000,
+		"p95":        r.p95 * 1000,
 		"p99":        r.p99 * 1000,
 		"avg":        r.avg * 1000,
 	}
-	tf, err := expr.EvalWithTrace(threshold, store)
-	if err != nil {
-		return err
+
+	counters := map[string]any{}
+	for k, v := range r.counters {
+		counters[k] = v
 	}
-	if !tf.OutputAsBool() {
-		bt, err := tf.FormatTraceTree()
-		if err != nil {
-			return err
+	env["counters"] = counters
+
+	var stages []any
+	for _, st := range r.stages {
+		m := st.Latency.toMap()
+		m["total"] = st.Total
+		m["succeeded"] = st.Succeeded
+		m["failed"] = st.Failed
+		m["error_rate"] = st.ErrorRate
+		m["rps"] = st.RPS
+		stages = append(stages, m)
+	}
+	env["stages"] = stages
+
+	runbooks := map[string]any{}
+	// steps - Metrics of steps whose keys are unique across runbooks.
+	steps := map[string]any{}
+	dupSteps := map[string]struct{}{}
+	baseNames := map[string]int{}
+	for _, rb := range r.runbooks {
+		baseNames[filepath.Base(rb.Path)]++
+	}
+	for _, rb := range r.runbooks {
+		rbSteps := map[string]any{}
+		for _, st := range rb.Steps {
+			m := loadtMetrics(st.Total, st.Failed, st.Latency)
+			rbSteps[st.Key] = m
+			if _, ok := steps[st.Key]; ok {
+				dupSteps[st.Key] = struct{}{}
+			}
+			steps[st.Key] = m
+		}
+		m := loadtMetrics(rb.Total, rb.Failed, rb.Latency)
+		m["steps"] = rbSteps
+		runbooks[rb.Path] = m
+		// The base name of the runbook path is also available if it is unique.
+		if b := filepath.Base(rb.Path); baseNames[b] == 1 {
+			runbooks[b] = m
 		}
-		return fmt.Errorf("(%s) is not true\n%s", threshold, bt)
 	}
-	return nil
+	for k := range dupSteps {
+		delete(steps, k)
+	}
+	env["runbooks"] = runbooks
+	env["steps"] = steps
+	return env
+}
+
+func (r *loadtResult) counterNames() []string {
+	var names []string
+	for name := range r.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// latency returns the latency percentiles of the whole RunN.
+func (r *loadtResult) latency() loadtLatency {
+	return loadtLatency{
+		Max: r.max * 1000,
+		Mid: r.p50 * 1000,
+		Min: r.min * 1000,
+		P90: r.p90 * 1000,
+		P95: r.p95 * 1000,
+		P99: r.p99 * 1000,
+		Avg: r.avg * 1000,
+	}
+}
+
+func (l loadtLatency) toMap() map[string]any {
+	return map[string]any{
+		"max": l.Max,
+		"mid": l.Mid,
+		"min": l.Min,
+		"p90": l.P90,
+		"p95": l.P95,
+		"p99": l.P99,
+		"avg": l.Avg,
+	}
+}
+
+func loadtMetrics(total, failed int64, l loadtLatency) map[string]any {
+	m := l.toMap()
+	m["total"] = total
+	m["failed"] = failed
+	m["error_rate"] = float64(0)
+	if total > 0 {
+		m["error_rate"] = float64(failed) / float64(total) * 100
+	}
+	return m
+}
+
+func (l loadtLatency) String() string {
+	return fmt.Sprintf("max=%sms min=%sms avg=%sms med=%sms p(90)=%sms p(99)=%sms",
+		humanize.CommafWithDigits(l.Max, 1),
+		humanize.CommafWithDigits(l.Min, 1),
+		humanize.CommafWithDigits(l.Avg, 1),
+		humanize.CommafWithDigits(l.Mid, 1),
+		humanize.CommafWithDigits(l.P90, 1),
+		humanize.CommafWithDigits(l.P99, 1),
+	)
+}
+
+func loadtCSVRecord(typ, id, path, key, desc string, total, failed int64, l loadtLatency) []string {
+	f := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return []string{
+		typ, id, path, key, desc,
+		strconv.FormatInt(total, 10), strconv.FormatInt(failed, 10),
+		f(l.Max), f(l.Mid), f(l.Min), f(l.P90), f(l.P95), f(l.P99), f(l.Avg),
+	}
+}
+
+// recordLoadtSamples records the elapsed times of runbooks and steps and the custom counters in a RunN.
+func (opn *operatorN) recordLoadtSamples(result *runNResult) {
+	stoppedAt := time.Now()
+	opn.mu.Lock()
+	defer opn.mu.Unlock()
+	if opn.loadtSamples == nil {
+		opn.loadtSamples = map[string]*loadtRunbookSamples{}
+	}
+	for _, rr := range result.RunResults {
+		if rr == nil || rr.Skipped {
+			continue
+		}
+		opn.countLoadtCounters(rr, stoppedAt)
+		rs, ok := opn.loadtSamples[rr.ID]
+		if !ok {
+			rs = &loadtRunbookSamples{
+				path: rr.Path,
+				desc: rr.Desc,
+			}
+			opn.loadtSamples[rr.ID] = rs
+		}
+		rs.samples = append(rs.samples, loadtSample{stoppedAt: stoppedAt, elapsed: rr.Elapsed, failed: rr.Err != nil})
+		for i, sr := range rr.StepResults {
+			if sr == nil || sr.Skipped {
+				continue
+			}
+			for len(rs.steps) <= i {
+				rs.steps = append(rs.steps, nil)
+			}
+			if rs.steps[i] == nil {
+				name := fmt.Sprintf("steps.%s", sr.Key)
+				if sr.Key == strconv.Itoa(i) {
+					name = fmt.Sprintf("steps[%d]", i)
+				}
+				rs.steps[i] = &loadtStepSamples{
+					key:  sr.Key,
+					desc: sr.Desc,
+					name: name,
+				}
+			}
+			rs.steps[i].samples = append(rs.steps[i].samples, loadtSample{stoppedAt: stoppedAt, elapsed: sr.Elapsed, failed: sr.Err != nil})
+		}
+	}
+}
+
+// countLoadtCounters counts the custom counters whose conditions are met by the run of the runbook.
+func (opn *operatorN) countLoadtCounters(rr *RunResult, stoppedAt time.Time) {
+	if len(opn.loadtCounters) == 0 || rr.store == nil {
+		return
+	}
+	sm := rr.store.ToMap()
+	for _, c := range opn.loadtCounters {
+		tf, err := expr.EvalCond(c.cond, sm)
+		if err != nil || !tf {
+			// The condition may refer to the steps of other runbooks.
+			continue
+		}
+		c.counted = append(c.counted, stoppedAt)
+	}
+}
+
+// loadtCounterValues returns the values of the custom counters excluding counts during the warm-up.
+func (opn *operatorN) loadtCounterValues(warmUp time.Duration) map[string]int64 {
+	if len(opn.loadtCounters) == 0 {
+		return nil
+	}
+	opn.mu.Lock()
+	defer opn.mu.Unlock()
+	since := opn.loadtStartedAt.Add(warmUp)
+	values := map[string]int64{}
+	for _, c := range opn.loadtCounters {
+		values[c.name] = 0
+		for _, t := range c.counted {
+			if !t.Before(since) {
+				values[c.name]++
+			}
+		}
+	}
+	return values
+}
+
+// loadtRunbookResults returns the latencies of each runbook and its steps excluding samples during the warm-up.
+func (opn *operatorN) loadtRunbookResults(warmUp time.Duration) []*loadtRunbookResult {
+	opn.mu.Lock()
+	defer opn.mu.Unlock()
+	return newLoadtRunbookResults(opn.loadtSamples, opn.loadtStartedAt.Add(warmUp))
+}
+
+// newLoadtRunbookResults returns the latencies of each runbook and its steps using the samples stopped since the time.
+func newLoadtRunbookResults(samples map[string]*loadtRunbookSamples, since time.Time) []*loadtRunbookResult {
+	var results []*loadtRunbookResult
+	for id, rs := range samples {
+		total, failed, latency := aggregateLoadtSamples(rs.samples, since)
+		if total == 0 {
+			continue
+		}
+		rr := &loadtRunbookResult{
+			ID:      id,
+			Path:    rs.path,
+			Desc:    rs.desc,
+			Total:   total,
+			Failed:  failed,
+			Latency: latency,
+			Steps:   []*loadtStepResult{},
+		}
+		for _, ss := range rs.steps {
+			if ss == nil {
+				continue
+			}
+			total, failed, latency := aggregateLoadtSamples(ss.samples, since)
+			if total == 0 {
+				continue
+			}
+			rr.Steps = append(rr.Steps, &loadtStepResult{
+				Key:     ss.key,
+				Desc:    ss.desc,
+				Total:   total,
+				Failed:  failed,
+				Latency: latency,
+				name:    ss.name,
+			})
+		}
+		results = append(results, rr)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Path == results[j].Path {
+			return results[i].ID < results[j].ID
+		}
+		return results[i].Path < results[j].Path
+	})
+	return results
+}
+
+func aggregateLoadtSamples(samples []loadtSample, since time.Time) (int64, int64, loadtLatency) {
+	var (
+		failed int64
+		ll     []float64
+		sum    float64
+	)
+	for _, s := range samples {
+		if s.stoppedAt.Before(since) {
+			continue
+		}
+		if s.failed {
+			failed++
+		}
+		l := float64(s.elapsed) / float64(time.Millisecond)
+		ll = append(ll, l)
+		sum += l
+	}
+	if len(ll) == 0 {
+		return 0, 0, loadtLatency{}
+	}
+	sort.Float64s(ll)
+	return int64(len(ll)), failed, loadtLatency{
+		Max: percentileLatency(ll, 100),
+		Mid: percentileLatency(ll, 50),
+		Min: percentileLatency(ll, 0),
+		P90: percentileLatency(ll, 90),
+		P95: percentileLatency(ll, 95),
+		P99: percentileLatency(ll, 99),
+		Avg: sum / float64(len(ll)),
+	}
+}
+
+// percentileLatency returns the p-th percentile of the sorted latencies in the same way as otchkiss.
+func percentileLatency(sorted []float64, p int) float64 {
+	switch {
+	case p == 0:
+		return sorted[0]
+	case p == 100:
+		return sorted[len(sorted)-1]
+	default:
+		idx := int(float64(len(sorted)) * (float64(p) / 100))
+		if idx < 1 {
+			idx = 1
+		}
+		return sorted[idx-1]
+	}
 }
@@ -0,0 +1,439 @@
+package runn
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/ryo-yamaoka/otchkiss"
+	or "github.com/ryo-yamaoka/otchkiss/result"
+	"github.com/ryo-yamaoka/otchkiss/setting"
+)
+
+const (
+	loadtMessageTypeReady  = "ready"
+	loadtMessageTypeStart  = "start"
+	loadtMessageTypeResult = "result"
+	loadtMessageTypeError  = "error"
+)
+
+// loadtStartDelay is the delay until all workers start the load test at the same time.
+const loadtStartDelay = time.Second
+
+// loadtMessage is a message of the control channel between the coordinator and the workers.
+type loadtMessage struct {
+	Type string `json:"type"`
+	// NumberOfRunbooks - Number of runbooks per RunN of the worker (ready).
+	NumberOfRunbooks int `json:"number_of_runbooks,omitempty"`
+	// Concurrent, MaxRPS, Duration, WarmUp and StartAt - Load test setting of the worker (start).
+	Concurrent int           `json:"concurrent,omitempty"`
+	MaxRPS     int           `json:"max_rps,omitempty"`
+	Duration   time.Duration `json:"duration,omitempty"`
+	WarmUp     time.Duration `json:"warm_up,omitempty"`
+	StartAt    time.Time     `json:"start_at,omitempty"`
+	// Result - Result of the worker (result).
+	Result *loadtWorkerResult `json:"result,omitempty"`
+	// Error - Error of the worker (error).
+	Error string `json:"error,omitempty"`
+}
+
+type loadtWorkerResult struct {
+	Succeeded int64                 `json:"succeeded"`
+	Failed    int64                 `json:"failed"`
+	Latencies []float64             `json:"latencies"`
+	Errors    []string              `json:"errors"`
+	Runbooks  []*loadtWorkerRunbook `json:"runbooks"`
+	Counters  map[string]int64      `json:"counters,omitempty"`
+}
+
+type loadtWorkerRunbook struct {
+	ID      string              `json:"id"`
+	Path    string              `json:"path"`
+	Desc    string              `json:"desc"`
+	Samples []loadtWorkerSample `json:"samples"`
+	Steps   []*loadtWorkerStep  `json:"steps"`
+}
+
+type loadtWorkerStep struct {
+	Index   int                 `json:"index"`
+	Key     string              `json:"key"`
+	Desc    string              `json:"desc"`
+	Name    string              `json:"name"`
+	Samples []loadtWorkerSample `json:"samples"`
+}
+
+type loadtWorkerSample struct {
+	Elapsed time.Duration `json:"elapsed"`
+	Failed  bool          `json:"failed"`
+}
+
+// loadtCoordinator is a coordinator that splits the load test across the workers and merges their results.
+type loadtCoordinator struct {
+	listener net.Listener
+	workers  int
+	// Result - Merged result of the workers.
+	Result           *or.Result
+	numberOfRunbooks int
+	samples          map[string]*loadtRunbookSamples
+	counters         map[string]int64
+	mu               sync.Mutex
+}
+
+// NewLoadtCoordinator returns a coordinator that listens for the workers on the address.
+func NewLoadtCoordinator(addr string, workers int) (*loadtCoordinator, error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("invalid number of workers: %d", workers)
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for workers on %s: %w", addr, err)
+	}
+	r, err := or.WithCapacity(0)
+	if err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return &loadtCoordinator{
+		listener: l,
+		workers:  workers,
+		Result:   r,
+		samples:  map[string]*loadtRunbookSamples{},
+	}, nil
+}
+
+// Addr returns the address for the workers.
+func (c *loadtCoordinator) Addr() string {
+	return c.listener.Addr().String()
+}
+
+func (c *loadtCoordinator) Close() error {
+	return c.listener.Close()
+}
+
+// Start waits for the workers to connect, starts the load test on all workers at the same time and merges their results.
+// The concurrency and max RPS are split across the workers.
+func (c *loadtCoordinator) Start(ctx context.Context, concurrent, maxRPS int, d, w time.Duration) error {
+	cs, err := splitLoadt(concurrent, c.workers)
+	if err != nil {
+		return fmt.Errorf("invalid concurrency: %w", err)
+	}
+	rs, err := splitLoadt(maxRPS, c.workers)
+	if err != nil {
+		return fmt.Errorf("invalid max RPS: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.listener.Close()
+	})
+	defer stop()
+
+	var conns []*loadtConn
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+	for len(conns) < c.workers {
+		nc, err := c.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		conn := newLoadtConn(nc)
+		conns = append(conns, conn)
+		m, err := conn.receive(loadtMessageTypeReady)
+		if err != nil {
+			return fmt.Errorf("worker %s is not ready: %w", conn.RemoteAddr(), err)
+		}
+		c.numberOfRunbooks = m.NumberOfRunbooks
+	}
+	stopConns := context.AfterFunc(ctx, func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
+	defer stopConns()
+
+	startAt := time.Now().Add(loadtStartDelay)
+	for i, conn := range conns {
+		if err := conn.send(&loadtMessage{
+			Type:       loadtMessageTypeStart,
+			Concurrent: cs[i],
+			MaxRPS:     rs[i],
+			Duration:   d,
+			WarmUp:     w,
+			StartAt:    startAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs error
+	)
+	for _, conn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := conn.receive(loadtMessageTypeResult)
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("worker %s failed: %w", conn.RemoteAddr(), err))
+				return
+			}
+			c.merge(m.Result)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// merge merges the result of the worker.
+func (c *loadtCoordinator) merge(r *loadtWorkerResult) {
+	// Which latency failed is not needed for the report, so the first latencies are appended as succeeded.
+	for i, l := range r.Latencies {
+		if int64(i) < r.Succeeded {
+			c.Result.AppendSuccess(l)
+			continue
+		}
+		err := errors.New("result has failure")
+		if j := i - int(r.Succeeded); j < len(r.Errors) {
+			err = errors.New(r.Errors[j])
+		}
+		c.Result.AppendFail(l, err)
+	}
+	for _, wr := range r.Runbooks {
+		rs, ok := c.samples[wr.ID]
+		if !ok {
+			rs = &loadtRunbookSamples{
+				path: wr.Path,
+				desc: wr.Desc,
+			}
+			c.samples[wr.ID] = rs
+		}
+		rs.samples = append(rs.samples, fromLoadtWorkerSamples(wr.Samples)...)
+		for _, ws := range wr.Steps {
+			for len(rs.steps) <= ws.Index {
+				rs.steps = append(rs.steps, nil)
+			}
+			if rs.steps[ws.Index] == nil {
+				rs.steps[ws.Index] = &loadtStepSamples{
+					key:  ws.Key,
+					desc: ws.Desc,
+					name: ws.Name,
+				}
+			}
+			rs.steps[ws.Index].samples = append(rs.steps[ws.Index].samples, fromLoadtWorkerSamples(ws.Samples)...)
+		}
+	}
+	for k, v := range r.Counters {
+		if c.counters == nil {
+			c.counters = map[string]int64{}
+		}
+		c.counters[k] += v
+	}
+}
+
+// NewLoadtResultFromCoordinator returns the merged result of the load test run by the workers.
+func NewLoadtResultFromCoordinator(w, d time.Duration, concurrent, maxRPS int, c *loadtCoordinator) (*loadtResult, error) {
+	lr, err := NewLoadtResult(c.numberOfRunbooks, w, d, concurrent, maxRPS, c.Result, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The samples of the workers are already filtered by the warm-up.
+	lr.runbooks = newLoadtRunbookResults(c.samples, time.Time{})
+	lr.counters = c.counters
+	return lr, nil
+}
+
+// RunLoadtWorker connects to the coordinator and runs the load test as a worker.
+func RunLoadtWorker(ctx context.Context, addr string, opn *operatorN) error {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the coordinator %s: %w", addr, err)
+	}
+	conn := newLoadtConn(nc)
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	selected, err := opn.SelectedOperators()
+	if err != nil {
+		return err
+	}
+	if err := conn.send(&loadtMessage{
+		Type:             loadtMessageTypeReady,
+		NumberOfRunbooks: len(selected),
+	}); err != nil {
+		return err
+	}
+	m, err := conn.receive(loadtMessageTypeStart)
+	if err != nil {
+		return err
+	}
+	r, err := runLoadtWorker(ctx, m, opn)
+	if err != nil {
+		_ = conn.send(&loadtMessage{
+			Type:  loadtMessageTypeError,
+			Error: err.Error(),
+		})
+		return err
+	}
+	return conn.send(&loadtMessage{
+		Type:   loadtMessageTypeResult,
+		Result: r,
+	})
+}
+
+func runLoadtWorker(ctx context.Context, m *loadtMessage, opn *operatorN) (*loadtWorkerResult, error) {
+	s, err := setting.New(m.Concurrent, m.MaxRPS, m.Duration, m.WarmUp)
+	if err != nil {
+		return nil, err
+	}
+	ot, err := otchkiss.FromConfig(opn, s, 100_000_000)
+	if err != nil {
+		return nil, err
+	}
+	// Start at the same time as the other workers.
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Until(m.StartAt)):
+	}
+	if err := ot.Start(ctx); err != nil {
+		return nil, err
+	}
+	r := &loadtWorkerResult{
+		Succeeded: ot.Result.Succeeded(),
+		Failed:    ot.Result.Failed(),
+		Latencies: ot.Result.Latencies(),
+		Errors:    []string{},
+		Runbooks:  opn.loadtWorkerRunbooks(m.WarmUp),
+		Counters:  opn.loadtCounterValues(m.WarmUp),
+	}
+	for _, err := range ot.Result.Errors() {
+		r.Errors = append(r.Errors, err.Error())
+	}
+	return r, nil
+}
+
+// loadtWorkerRunbooks returns the samples of each runbook and its steps excluding samples during the warm-up.
+func (opn *operatorN) loadtWorkerRunbooks(warmUp time.Duration) []*loadtWorkerRunbook {
+	opn.mu.Lock()
+	defer opn.mu.Unlock()
+	since := opn.loadtStartedAt.Add(warmUp)
+	runbooks := []*loadtWorkerRunbook{}
+	for id, rs := range opn.loadtSamples {
+		wr := &loadtWorkerRunbook{
+			ID:      id,
+			Path:    rs.path,
+			Desc:    rs.desc,
+			Samples: toLoadtWorkerSamples(rs.samples, since),
+			Steps:   []*loadtWorkerStep{},
+		}
+		for i, ss := range rs.steps {
+			if ss == nil {
+				continue
+			}
+			wr.Steps = append(wr.Steps, &loadtWorkerStep{
+				Index:   i,
+				Key:     ss.key,
+				Desc:    ss.desc,
+				Name:    ss.name,
+				Samples: toLoadtWorkerSamples(ss.samples, since),
+			})
+		}
+		runbooks = append(runbooks, wr)
+	}
+	return runbooks
+}
+
+func toLoadtWorkerSamples(samples []loadtSample, since time.Time) []loadtWorkerSample {
+	ws := []loadtWorkerSample{}
+	for _, s := range samples {
+		if s.stoppedAt.Before(since) {
+			continue
+		}
+		ws = append(ws, loadtWorkerSample{Elapsed: s.elapsed, Failed: s.failed})
+	}
+	return ws
+}
+
+func fromLoadtWorkerSamples(ws []loadtWorkerSample) []loadtSample {
+	samples := make([]loadtSample, 0, len(ws))
+	for _, s := range ws {
+		samples = append(samples, loadtSample{elapsed: s.Elapsed, failed: s.Failed})
+	}
+	return samples
+}
+
+// splitLoadt splits the total into n parts. 0 means unlimited for each part.
+func splitLoadt(total, n int) ([]int, error) {
+	parts := make([]int, n)
+	if total == 0 {
+		return parts, nil
+	}
+	if total < n {
+		return nil, fmt.Errorf("%d is less than the number of workers (%d)", total, n)
+	}
+	for i := range parts {
+		parts[i] = total / n
+		if i < total%n {
+			parts[i]++
+		}
+	}
+	return parts, nil
+}
+
+// loadtConn is a connection of the control channel that sends and receives JSON lines.
+type loadtConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func newLoadtConn(nc net.Conn) *loadtConn {
+	return &loadtConn{
+		Conn: nc,
+		r:    bufio.NewReader(nc),
+	}
+}
+
+func (c *loadtConn) send(m *loadtMessage) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = c.Write(append(b, '\n'))
+	return err
+}
+
+// receive receives a message of the type. A message of the error type is returned as an error.
+func (c *loadtConn) receive(typ string) (*loadtMessage, error) {
+	b, err := c.r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	m := &loadtMessage{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	switch m.Type {
+	case typ:
+		return m, nil
+	case loadtMessageTypeError:
+		return nil, errors.New(m.Error)
+	default:
+		return nil, fmt.Errorf("unexpected message: %s", m.Type)
+	}
+}
@@ -0,0 +1,120 @@
+package runn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitLoadt(t *testing.T) {
+	tests := []struct {
+		total   int
+		n       int
+		want    []int
+		wantErr bool
+	}{
+		{10, 2, []int{5, 5}, false},
+		{10, 3, []int{4, 3, 3}, false},
+		{0, 3, []int{0, 0, 0}, false},
+		{3, 3, []int{1, 1, 1}, false},
+		{2, 3, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := splitLoadt(tt.total, tt.n)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %d/%d", tt.total, tt.n)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestLoadtCoordinator(t *testing.T) {
+	const workers = 2
+	c, err := NewLoadtCoordinator("127.0.0.1:0", workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	ctx := context.Background()
+	errc := make(chan error, workers)
+	var opns []*operatorN
+	for i := 0; i < workers; i++ {
+		opn, err := Load("testdata/book/always_success.yml", Profile(true), LoadtCounter("all", "true"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opns = append(opns, opn)
+		go func() {
+			errc <- RunLoadtWorker(ctx, c.Addr(), opn)
+		}()
+	}
+	if err := c.Start(ctx, 2, 0, 300*time.Millisecond, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < workers; i++ {
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+	}
+	lr, err := NewLoadtResultFromCoordinator(100*time.Millisecond, 300*time.Millisecond, 2, 0, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lr.runbookCount != 1 {
+		t.Errorf("want %d, got %d", 1, lr.runbookCount)
+	}
+	if lr.total == 0 {
+		t.Error("no runs")
+	}
+	if lr.failed != 0 {
+		t.Errorf("want %d, got %d", 0, lr.failed)
+	}
+	var total int64
+	for _, opn := range opns {
+		total += opn.loadtCounterValues(100 * time.Millisecond)["all"]
+	}
+	if got := lr.counters["all"]; got != total {
+		t.Errorf("want %d, got %d", total, got)
+	}
+	if len(lr.runbooks) != 1 {
+		t.Fatalf("want %d, got %d", 1, len(lr.runbooks))
+	}
+	var samples int64
+	for _, opn := range opns {
+		for _, rb := range opn.loadtRunbookResults(100 * time.Millisecond) {
+			samples += rb.Total
+		}
+	}
+	if got := lr.runbooks[0].Total; got != samples {
+		t.Errorf("want %d, got %d", samples, got)
+	}
+	if len(lr.runbooks[0].Steps) == 0 {
+		t.Error("no steps")
+	}
+}
+
+func TestLoadtCoordinatorInvalidSplit(t *testing.T) {
+	c, err := NewLoadtCoordinator("127.0.0.1:0", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	if err := c.Start(context.Background(), 2, 0, time.Second, 0); err == nil {
+		t.Error("want error")
+	}
+}
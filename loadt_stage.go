@@ -0,0 +1,247 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k1LoW/duration"
+	"github.com/ryo-yamaoka/otchkiss"
+	or "github.com/ryo-yamaoka/otchkiss/result"
+)
+
+// loadtStagerInterval is the interval for checking the number of running RunNs and the stage.
+const loadtStagerInterval = 10 * time.Millisecond
+
+// loadtStage is a stage of the load test.
+// The concurrency (and max RPS) changes linearly from the target of the previous stage to the target of the stage.
+type loadtStage struct {
+	duration   time.Duration
+	concurrent int
+	maxRPS     int // 0 means unlimited.
+}
+
+// loadtStager is a load generator that changes the concurrency and max RPS according to the stages.
+type loadtStager struct {
+	requester otchkiss.Requester
+	warmUp    time.Duration
+	stages    []*loadtStage
+	// Result - Result of all stages.
+	Result *or.Result
+	// StageResults - Result of each stage.
+	StageResults []*or.Result
+}
+
+// ParseLoadtStages parses the stage specs ("DURATION:CONCURRENT" or "DURATION:CONCURRENT:MAX_RPS").
+func ParseLoadtStages(specs []string) ([]*loadtStage, error) {
+	var stages []*loadtStage
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		splitted := strings.Split(spec, ":")
+		if len(splitted) != 2 && len(splitted) != 3 {
+			return nil, fmt.Errorf("invalid stage: %q", spec)
+		}
+		d, err := duration.Parse(splitted[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid stage duration: %q: %w", spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid stage duration: %q", spec)
+		}
+		c, err := strconv.Atoi(splitted[1])
+		if err != nil || c < 1 {
+			return nil, fmt.Errorf("invalid stage concurrency: %q", spec)
+		}
+		var rps int
+		if len(splitted) == 3 {
+			rps, err = strconv.Atoi(splitted[2])
+			if err != nil || rps < 0 {
+				return nil, fmt.Errorf("invalid stage max RPS: %q", spec)
+			}
+		}
+		stages = append(stages, &loadtStage{
+			duration:   d,
+			concurrent: c,
+			maxRPS:     rps,
+		})
+	}
+	if len(stages) == 0 {
+		return nil, errors.New("no stages")
+	}
+	return stages, nil
+}
+
+// NewLoadtStager returns a load generator that runs the requester according to the stages after the warm-up.
+func NewLoadtStager(requester otchkiss.Requester, warmUp time.Duration, stages []*loadtStage) (*loadtStager, error) {
+	if requester == nil {
+		return nil, errors.New("nil requester")
+	}
+	if len(stages) == 0 {
+		return nil, errors.New("no stages")
+	}
+	// Results grow as needed, because the number of RunNs in each stage is unknown.
+	r, err := or.WithCapacity(0)
+	if err != nil {
+		return nil, err
+	}
+	st := &loadtStager{
+		requester: requester,
+		warmUp:    warmUp,
+		stages:    stages,
+		Result:    r,
+	}
+	for range stages {
+		sr, err := or.WithCapacity(0)
+		if err != nil {
+			return nil, err
+		}
+		st.StageResults = append(st.StageResults, sr)
+	}
+	return st, nil
+}
+
+// Start runs the load test.
+// RunNs started during the warm-up are not counted as the result.
+func (st *loadtStager) Start(ctx context.Context) error {
+	if err := st.requester.Init(); err != nil {
+		return fmt.Errorf("failed to initialize requester: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, st.warmUp+st.Duration())
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		running int
+		next    time.Time
+	)
+	startedAt := time.Now()
+	for ctx.Err() == nil {
+		now := time.Now()
+		idx, c, rps := st.levelAt(now.Sub(startedAt))
+		if rps == 0 {
+			next = time.Time{}
+		}
+		mu.Lock()
+		full := running >= c
+		mu.Unlock()
+		if full || now.Before(next) {
+			select {
+			case <-ctx.Done():
+			case <-time.After(loadtStagerInterval):
+			}
+			continue
+		}
+		if rps > 0 {
+			if next.Before(now) {
+				next = now
+			}
+			next = next.Add(time.Second / time.Duration(rps))
+		}
+		mu.Lock()
+		running++
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := st.requester.RequestOne(ctx)
+			elapsed := time.Since(start)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			if idx < 0 {
+				// warm-up
+				return
+			}
+			for _, r := range []*or.Result{st.Result, st.StageResults[idx]} {
+				if err != nil {
+					r.AppendFail(elapsed.Seconds(), err)
+					continue
+				}
+				r.AppendSuccess(elapsed.Seconds())
+			}
+		}()
+	}
+
+	wg.Wait()
+	return st.requester.Terminate()
+}
+
+// Duration returns the total duration of the stages.
+func (st *loadtStager) Duration() time.Duration {
+	var d time.Duration
+	for _, s := range st.stages {
+		d += s.duration
+	}
+	return d
+}
+
+// MaxConcurrent returns the max concurrency of the stages.
+func (st *loadtStager) MaxConcurrent() int {
+	var c int
+	for _, s := range st.stages {
+		if s.concurrent > c {
+			c = s.concurrent
+		}
+	}
+	return c
+}
+
+// MaxRPS returns the max RPS of the stages. 0 means unlimited.
+func (st *loadtStager) MaxRPS() int {
+	var rps int
+	for _, s := range st.stages {
+		if s.maxRPS == 0 {
+			return 0
+		}
+		if s.maxRPS > rps {
+			rps = s.maxRPS
+		}
+	}
+	return rps
+}
+
+// levelAt returns the index of the stage, the concurrency and the max RPS at the elapsed time since the start.
+// The index is -1 during the warm-up.
+func (st *loadtStager) levelAt(elapsed time.Duration) (int, int, int) {
+	if elapsed < st.warmUp {
+		return -1, 1, st.stages[0].maxRPS
+	}
+	elapsed -= st.warmUp
+	// Ramp up from 1
+	prevC := 1
+	prevRPS := st.stages[0].maxRPS
+	for i, s := range st.stages {
+		if elapsed >= s.duration && i < len(st.stages)-1 {
+			elapsed -= s.duration
+			prevC = s.concurrent
+			prevRPS = s.maxRPS
+			continue
+		}
+		frac := math.Min(float64(elapsed)/float64(s.duration), 1)
+		c := prevC + int(math.Round(float64(s.concurrent-prevC)*frac))
+		if c < 1 {
+			c = 1
+		}
+		rps := s.maxRPS
+		if prevRPS > 0 && s.maxRPS > 0 {
+			// Ramp only between limited RPSs
+			rps = prevRPS + int(math.Round(float64(s.maxRPS-prevRPS)*frac))
+		}
+		return i, c, rps
+	}
+	return len(st.stages) - 1, st.stages[len(st.stages)-1].concurrent, st.stages[len(st.stages)-1].maxRPS
+}
+
+func (s *loadtStage) String() string {
+	return fmt.Sprintf("%s:%d:%d", s.duration, s.concurrent, s.maxRPS)
+}
@@ -0,0 +1,197 @@
+package runn
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseLoadtStages(t *testing.T) {
+	tests := []struct {
+		in      []string
+		want    []*loadtStage
+		wantErr bool
+	}{
+		{
+			[]string{"30s:10", "1min:10:100", "30sec:1"},
+			[]*loadtStage{
+				{duration: 30 * time.Second, concurrent: 10, maxRPS: 0},
+				{duration: time.Minute, concurrent: 10, maxRPS: 100},
+				{duration: 30 * time.Second, concurrent: 1, maxRPS: 0},
+			},
+			false,
+		},
+		{[]string{}, nil, true},
+		{[]string{"30s"}, nil, true},
+		{[]string{"30s:10:100:1"}, nil, true},
+		{[]string{"invalid:10"}, nil, true},
+		{[]string{"0s:10"}, nil, true},
+		{[]string{"30s:0"}, nil, true},
+		{[]string{"30s:10:-1"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLoadtStages(tt.in)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %v", tt.in)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want, cmp.AllowUnexported(loadtStage{})); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestLoadtStagerLevelAt(t *testing.T) {
+	st := &loadtStager{
+		warmUp: 5 * time.Second,
+		stages: []*loadtStage{
+			{duration: 10 * time.Second, concurrent: 11, maxRPS: 10},
+			{duration: 10 * time.Second, concurrent: 11, maxRPS: 20},
+			{duration: 10 * time.Second, concurrent: 1, maxRPS: 0},
+		},
+	}
+	tests := []struct {
+		elapsed        time.Duration
+		wantIdx        int
+		wantConcurrent int
+		wantRPS        int
+	}{
+		{0, -1, 1, 10},
+		{5 * time.Second, 0, 1, 10},
+		{10 * time.Second, 0, 6, 10},
+		{15 * time.Second, 1, 11, 10},
+		{20 * time.Second, 1, 11, 15},
+		{25 * time.Second, 2, 11, 0},
+		{30 * time.Second, 2, 6, 0},
+		{35 * time.Second, 2, 1, 0},
+		{40 * time.Second, 2, 1, 0},
+	}
+	for _, tt := range tests {
+		idx, c, rps := st.levelAt(tt.elapsed)
+		if idx != tt.wantIdx || c != tt.wantConcurrent || rps != tt.wantRPS {
+			t.Errorf("levelAt(%s): want (%d, %d, %d), got (%d, %d, %d)", tt.elapsed, tt.wantIdx, tt.wantConcurrent, tt.wantRPS, idx, c, rps)
+		}
+	}
+}
+
+func TestLoadtStager(t *testing.T) {
+	stages, err := ParseLoadtStages([]string{"300ms:1", "300ms:4:4", "300ms:4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &concurrencyRequester{sleep: 20 * time.Millisecond}
+	st, err := NewLoadtStager(r, 100*time.Millisecond, stages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !r.initialized || !r.terminated {
+		t.Error("Init() and Terminate() should be called")
+	}
+	if r.max > 4 {
+		t.Errorf("concurrency exceeded: %d", r.max)
+	}
+	var total int64
+	for i, sr := range st.StageResults {
+		if sr.Succeeded() == 0 {
+			t.Errorf("stage %d: no succeeded", i)
+		}
+		total += sr.Succeeded()
+	}
+	if got := st.Result.Succeeded(); got != total {
+		t.Errorf("want %d, got %d", total, got)
+	}
+	// The RPS of the 2nd stage is limited to 4.
+	if got := st.StageResults[1].Succeeded(); got > 3 {
+		t.Errorf("RPS exceeded in the 2nd stage: %d", got)
+	}
+	// The 3rd stage runs with the concurrency of 4 without the RPS limit.
+	if st.StageResults[2].Succeeded() <= st.StageResults[0].Succeeded() {
+		t.Errorf("the 3rd stage should run more than the 1st stage: %d <= %d", st.StageResults[2].Succeeded(), st.StageResults[0].Succeeded())
+	}
+}
+
+func TestLoadtResultFromStager(t *testing.T) {
+	opn, err := Load("testdata/book/always_success.yml", Profile(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stages, err := ParseLoadtStages([]string{"100ms:1", "100ms:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := NewLoadtStager(opn, 0, stages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lr, err := NewLoadtResultFromStager(1, 0, st, opn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lr.duration != 200*time.Millisecond {
+		t.Errorf("want %s, got %s", 200*time.Millisecond, lr.duration)
+	}
+	if lr.concurrent != 2 {
+		t.Errorf("want %d, got %d", 2, lr.concurrent)
+	}
+	if len(lr.stages) != 2 {
+		t.Fatalf("want %d, got %d", 2, len(lr.stages))
+	}
+	var total int64
+	for _, s := range lr.stages {
+		total += s.Total
+	}
+	if total != lr.total {
+		t.Errorf("want %d, got %d", lr.total, total)
+	}
+	if len(lr.runbooks) != 1 {
+		t.Errorf("want %d, got %d", 1, len(lr.runbooks))
+	}
+}
+
+type concurrencyRequester struct {
+	sleep       time.Duration
+	running     int
+	max         int
+	initialized bool
+	terminated  bool
+	mu          sync.Mutex
+}
+
+func (r *concurrencyRequester) Init() error {
+	r.initialized = true
+	return nil
+}
+
+func (r *concurrencyRequester) RequestOne(ctx context.Context) error {
+	r.mu.Lock()
+	r.running++
+	if r.running > r.max {
+		r.max = r.running
+	}
+	r.mu.Unlock()
+	time.Sleep(r.sleep)
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *concurrencyRequester) Terminate() error {
+	r.terminated = true
+	return nil
+}
@@ -1,15 +1,20 @@
 package runn
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"net/http"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/k1LoW/runn/testutil"
 	"github.com/ryo-yamaoka/otchkiss"
 	"github.com/ryo-yamaoka/otchkiss/setting"
 	"github.com/samber/lo"
+	"github.com/tenntenn/golden"
 )
 
 func TestLoadt(t *testing.T) {
@@ -101,7 +106,250 @@ func TestLoadtIndex(t *testing.T) {
 	}
 }
 
+func TestLoadtStepLatencies(t *testing.T) {
+	tests := []struct {
+		concurrent int
+	}{
+		{1},
+		{4},
+	}
+	hs := testutil.HTTPServer(t)
+	t.Setenv("TEST_HTTP_ENDPOINT", hs.URL)
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("concurrent %d", tt.concurrent), func(t *testing.T) {
+			t.Parallel()
+			opn, err := Load("testdata/book/http_sleep.yml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := setting.New(tt.concurrent, 0, 100*time.Millisecond, 0) // zero warmup
+			if err != nil {
+				t.Fatal(err)
+			}
+			ot, err := otchkiss.FromConfig(opn, s, 100_000_000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ot.Start(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			lr, err := NewLoadtResult(1, 0, 100*time.Millisecond, tt.concurrent, 0, ot.Result, opn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lr.runbooks) != 1 {
+				t.Fatalf("got %d runbooks", len(lr.runbooks))
+			}
+			rb := lr.runbooks[0]
+			if rb.Path != "testdata/book/http_sleep.yml" {
+				t.Errorf("got %v", rb.Path)
+			}
+			if rb.Total != lr.total {
+				t.Errorf("want %d, got %d", lr.total, rb.Total)
+			}
+			if len(rb.Steps) != 2 {
+				t.Fatalf("got %d steps", len(rb.Steps))
+			}
+			if rb.Steps[0].Latency.Min < 3000 || rb.Steps[0].Latency.Max >= 4000 {
+				t.Errorf("steps[0] should take between 3000ms and 4000ms: %v", rb.Steps[0].Latency)
+			}
+			if rb.Steps[1].Latency.Min < 1000 || rb.Steps[1].Latency.Max >= 2000 {
+				t.Errorf("steps[1] should take between 1000ms and 2000ms: %v", rb.Steps[1].Latency)
+			}
+			if rb.Latency.Min < rb.Steps[0].Latency.Min+rb.Steps[1].Latency.Min || rb.Latency.Max >= 5000 {
+				t.Errorf("runbook latency should be the sum of step latencies: %v", rb.Latency)
+			}
+		})
+	}
+}
+
+func TestLoadtCheckThresholdConcurrent(t *testing.T) {
+	hs := testutil.HTTPServer(t)
+	t.Setenv("TEST_HTTP_ENDPOINT", hs.URL)
+	opn, err := Load("testdata/book/http_sleep.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := setting.New(3, 0, 100*time.Millisecond, 0) // zero warmup
+	if err != nil {
+		t.Fatal(err)
+	}
+	ot, err := otchkiss.FromConfig(opn, s, 100_000_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ot.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lr, err := NewLoadtResult(1, 0, 100*time.Millisecond, 3, 0, ot.Result, opn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		threshold string
+		wantErr   bool
+	}{
+		{"steps['1'].min >= 1000 && steps['1'].max < 2000", false},
+		{"runbooks['http_sleep.yml'].steps['0'].min >= 3000 && runbooks['http_sleep.yml'].steps['0'].max < 4000", false},
+		{"runbooks['http_sleep.yml'].max < 5000", false},
+		{"steps['1'].max < 1000", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.threshold, func(t *testing.T) {
+			err := lr.CheckThreshold(tt.threshold)
+			if err != nil {
+				if tt.wantErr {
+					return
+				}
+				t.Errorf("got err: %s", err)
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestLoadtCounters(t *testing.T) {
+	opts := []Option{
+		LoadtCounter("three_steps", "len(steps) == 3"),
+		LoadtCounter("never", "false"),
+	}
+	opn, err := Load("testdata/book/always_success.yml", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := setting.New(1, 0, 100*time.Millisecond, 0) // zero warmup
+	if err != nil {
+		t.Fatal(err)
+	}
+	ot, err := otchkiss.FromConfig(opn, s, 100_000_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ot.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lr, err := NewLoadtResult(1, 0, 100*time.Millisecond, 1, 0, ot.Result, opn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"three_steps": lr.total, "never": 0}
+	if diff := cmp.Diff(lr.counters, want); diff != "" {
+		t.Error(diff)
+	}
+	if err := lr.CheckThreshold("counters['three_steps'] == total && counters['never'] == 0"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadtResultReport(t *testing.T) {
+	lr := &loadtResult{
+		runbookCount: 2,
+		warmUp:       5 * time.Second,
+		duration:     10 * time.Second,
+		concurrent:   2,
+		maxRPS:       0,
+		total:        20,
+		succeeded:    19,
+		failed:       1,
+		errorRate:    5,
+		rps:          2,
+		max:          1.5,
+		min:          0.25,
+		p99:          1.4,
+		p95:          1.3,
+		p90:          1.2,
+		p50:          0.9,
+		avg:          0.95,
+		runbooks: []*loadtRunbookResult{
+			{
+				ID:      "a1b2c3",
+				Path:    "testdata/book/login.yml",
+				Desc:    "Login",
+				Total:   20,
+				Failed:  1,
+				Latency: loadtLatency{Max: 1500, Mid: 900, Min: 250, P90: 1200, P95: 1300, P99: 1400, Avg: 950},
+				Steps: []*loadtStepResult{
+					{Key: "0", Desc: "Get token", Total: 20, Failed: 0, Latency: loadtLatency{Max: 300, Mid: 100, Min: 50, P90: 200, P95: 240, P99: 280, Avg: 120}, name: "steps[0]"},
+					{Key: "1", Desc: "Login, then redirect", Total: 20, Failed: 1, Latency: loadtLatency{Max: 1200, Mid: 800, Min: 200, P90: 1000, P95: 1050, P99: 1100, Avg: 830}, name: "steps[1]"},
+				},
+			},
+		},
+	}
+	staged := *lr
+	staged.duration = 3 * time.Second
+	staged.counters = map[string]int64{"rate_limited": 3, "redirected": 19}
+	staged.stages = []*loadtStageResult{
+		{Duration: "1s", Concurrent: 2, MaxRPS: 0, Total: 5, Succeeded: 5, Failed: 0, ErrorRate: 0, RPS: 5, Latency: loadtLatency{Max: 400, Mid: 300, Min: 250, P90: 400, P95: 400, P99: 400, Avg: 310}, spec: "1s:2:0"},
+		{Duration: "2s", Concurrent: 8, MaxRPS: 0, Total: 15, Succeeded: 14, Failed: 1, ErrorRate: 6.666666666666667, RPS: 7.5, Latency: loadtLatency{Max: 1500, Mid: 1000, Min: 300, P90: 1200, P95: 1300, P99: 1400, Avg: 1163.3333333333333}, spec: "2s:8:0"},
+	}
+	results := []struct {
+		name string
+		lr   *loadtResult
+	}{
+		{"loadt_report", lr},
+		{"loadt_report_stages", &staged},
+	}
+	for _, rr := range results {
+		tests := []struct {
+			format string
+			report func(w *bytes.Buffer) error
+		}{
+			{"txt", func(w *bytes.Buffer) error { return rr.lr.Report(w) }},
+			{"json", func(w *bytes.Buffer) error { return rr.lr.ReportJSON(w) }},
+			{"csv", func(w *bytes.Buffer) error { return rr.lr.ReportCSV(w) }},
+		}
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s.%s", rr.name, tt.format), func(t *testing.T) {
+				out := new(bytes.Buffer)
+				if err := tt.report(out); err != nil {
+					t.Fatal(err)
+				}
+				f := fmt.Sprintf("%s.%s", rr.name, tt.format)
+				got := out.String()
+				if os.Getenv("UPDATE_GOLDEN") != "" {
+					golden.Update(t, "testdata", f, got)
+					return
+				}
+				if diff := golden.Diff(t, "testdata", f, got); diff != "" {
+					t.Error(diff)
+				}
+			})
+		}
+	}
+}
+
 func TestCheckThreshold(t *testing.T) {
+	lr := &loadtResult{
+		total:     20,
+		succeeded: 19,
+		failed:    1,
+		runbooks: []*loadtRunbookResult{
+			{
+				Path:    "testdata/book/login.yml",
+				Total:   20,
+				Failed:  1,
+				Latency: loadtLatency{Max: 1500, Mid: 900, Min: 250, P90: 1200, P95: 1300, P99: 1400, Avg: 950},
+				Steps: []*loadtStepResult{
+					{Key: "login", Total: 20, Failed: 0, Latency: loadtLatency{Max: 300, Mid: 100, Min: 50, P90: 200, P95: 150, P99: 280, Avg: 120}},
+					{Key: "1", Total: 20, Failed: 1, Latency: loadtLatency{Max: 1200, Mid: 800, Min: 200, P90: 1000, P95: 1050, P99: 1100, Avg: 830}},
+				},
+			},
+			{
+				Path:  "testdata/book/logout.yml",
+				Total: 20,
+				Steps: []*loadtStepResult{
+					{Key: "1", Total: 20},
+				},
+			},
+		},
+		stages: []*loadtStageResult{
+			{Total: 5},
+			{Total: 15, Failed: 1, ErrorRate: 6.666666666666667},
+		},
+		counters: map[string]int64{"rate_limited": 3},
+	}
 	tests := []struct {
 		lr        *loadtResult
 		threshold string
@@ -110,6 +358,15 @@ func TestCheckThreshold(t *testing.T) {
 		{&loadtResult{}, "", false},
 		{&loadtResult{succeeded: 11}, "succeeded > 10", false},
 		{&loadtResult{failed: 10}, "failed < 10", true},
+		{&loadtResult{p95: 0.2}, "p95 < 300", false},
+		{lr, "runbooks['testdata/book/login.yml'].error_rate < 10", false},
+		{lr, "runbooks['login.yml'].p95 < 1000", true},
+		{lr, "runbooks['login.yml'].steps['1'].failed == 1", false},
+		{lr, "steps['login'].p95 < 200", false},
+		{lr, "steps['login'].p95 < 100", true},
+		{lr, "steps['1'].total > 0", true}, // Duplicate keys are only available via runbooks
+		{lr, "counters['rate_limited'] < 3", true},
+		{lr, "counters['rate_limited'] < 5 && stages[1].error_rate < 10", false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.threshold, func(t *testing.T) {
@@ -0,0 +1,761 @@
+package runn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/k1LoW/runn/internal/store"
+	"github.com/k1LoW/runn/version"
+)
+
+const lspSource = "runn"
+
+// lspRecordedKeys is the keys of the values recorded by each type of runner ( e.g. `steps[0].res.status` ).
+var lspRecordedKeys = map[string]lspKeys{
+	"http": {string(httpStoreResponseKey): {
+		string(httpStoreStatusKey):  nil,
+		string(httpStoreHeaderKey):  nil,
+		string(httpStoreBodyKey):    nil,
+		string(httpStoreRawBodyKey): nil,
+		string(httpStoreCookieKey):  nil,
+		string(httpStoreProtoKey):   nil,
+		string(httpStoreEventsKey):  nil,
+	}},
+	"grpc": {string(grpcStoreResponseKey): {
+		string(grpcStoreStatusKey):   nil,
+		string(grpcStoreHeaderKey):   nil,
+		string(grpcStoreTrailerKey):  nil,
+		string(grpcStoreMessageKey):  nil,
+		string(grpcStoreMessagesKey): nil,
+	}},
+	"db": {
+		string(dbStoreRowsKey):         nil,
+		string(dbStoreLastInsertIDKey): nil,
+		string(dbStoreRowsAffectedKey): nil,
+	},
+	"ssh": {
+		string(sshStoreStdoutKey): nil,
+		string(sshStoreStderrKey): nil,
+	},
+	"ws": {string(wsStoreResponseKey): {
+		string(wsStoreMessageKey):  nil,
+		string(wsStoreMessagesKey): nil,
+	}},
+	"kafka": {kafkaStoreResponseKey: {
+		kafkaStoreMessagesKey: nil,
+	}},
+	"redis": {redisStoreResponseKey: {
+		redisStoreResultKey:  nil,
+		redisStoreResultsKey: nil,
+	}},
+	"smtp": {smtpStoreResponseKey: {
+		smtpMessageFromKey:    nil,
+		smtpMessageToKey:      nil,
+		smtpMessageSubjectKey: nil,
+		smtpMessageHeadersKey: nil,
+		smtpMessageTextKey:    nil,
+		smtpMessageHTMLKey:    nil,
+		smtpMessageLinksKey:   nil,
+	}},
+	"mock": {mockStoreResponseKey: {
+		mockStoreRequestsKey: nil,
+	}},
+	execRunnerKey: {
+		string(execStoreStdoutKey):   nil,
+		string(execStoreStderrKey):   nil,
+		string(execStoreExitCodeKey): nil,
+	},
+}
+
+// lspKeys is the tree of the keys of the recorded values.
+type lspKeys map[string]lspKeys
+
+var (
+	lspPathRe       = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*|\.|\[[0-9]*\]?|\[(?:'[^']*'|"[^"]*")\])*$`)
+	lspSegmentRe    = regexp.MustCompile(`^(?:\.?([A-Za-z_][A-Za-z0-9_]*)|\[([0-9]+)\]|\[(?:'([^']*)'|"([^"]*)")\])`)
+	lspKeyRe        = regexp.MustCompile(`^\s*(?:-\s+)?([^\s:#'"][^:#]*?|'[^']*'|"[^"]*")\s*:(?:\s|$)`)
+	lspKeyValueRe   = regexp.MustCompile(`^\s*(?:-\s+)?([A-Za-z]+)\s*:\s*(.+?)\s*$`)
+	lspYAMLErrorRe  = regexp.MustCompile(`\[(\d+):(\d+)\]`)
+	lspWordRe       = regexp.MustCompile(`[A-Za-z0-9_]+`)
+	errLSPNotOpened = errors.New("document is not opened")
+)
+
+// lspServer is a Language Server Protocol server for runbooks.
+type lspServer struct {
+	conn        *lspConn
+	opts        []Option
+	docs        map[string]*lspDocument
+	funcs       []string
+	initialized bool
+	shutdown    bool
+	mu          sync.Mutex
+}
+
+// lspDocument is an opened runbook.
+type lspDocument struct {
+	uri   string
+	path  string
+	lines []string
+	// bk - The last parsed runbook. It is kept while the runbook is being edited and can not be parsed.
+	bk *book
+}
+
+// ServeLSP serves the Language Server Protocol for runbooks over r and w ( e.g. stdin and stdout ) until the client requests to exit.
+// It provides diagnostics, completion of paths and built-in functions in expressions, go-to-definition for `include:` and hover docs for CDP functions.
+// The options are used when loading the runbooks for diagnostics.
+func ServeLSP(ctx context.Context, r io.Reader, w io.Writer, opts ...Option) error {
+	s := &lspServer{
+		conn: newLSPConn(r, w),
+		opts: opts,
+		docs: map[string]*lspDocument{},
+	}
+	bk := newBook()
+	for _, opt := range setupBuiltinFunctions() {
+		if err := opt(bk); err != nil {
+			return err
+		}
+	}
+	for k := range bk.funcs {
+		s.funcs = append(s.funcs, k)
+	}
+	sort.Strings(s.funcs)
+	return s.serve(ctx)
+}
+
+func (s *lspServer) serve(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		m, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+		result, err := s.handle(m)
+		if m.ID == nil {
+			// Notification
+			continue
+		}
+		if err := s.conn.reply(m.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *lspServer) handle(m *lspMessage) (any, error) {
+	if !s.initialized && m.Method != "initialize" {
+		return nil, &lspError{Code: lspErrServerNotInitialized, Message: "server not initialized"}
+	}
+	switch m.Method {
+	case "initialize":
+		s.initialized = true
+		return &lspInitializeResult{
+			Capabilities: lspServerCapabilities{
+				TextDocumentSync: lspTextDocumentSyncOptions{
+					OpenClose: true,
+					Change:    lspTextDocumentSyncKindFull,
+					Save:      lspSaveOptions{IncludeText: false},
+				},
+				CompletionProvider: lspCompletionOptions{TriggerCharacters: []string{".", "["}},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: lspServerInfo{Name: version.Name, Version: version.Version},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		p := &lspDidOpenTextDocumentParams{}
+		if err := unmarshalLSPParams(m, p); err != nil {
+			return nil, err
+		}
+		d, err := s.open(p.TextDocument.URI, p.TextDocument.Text)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.publishDiagnostics(d, true)
+	case "textDocument/didChange":
+		p := &lspDidChangeTextDocumentParams{}
+		if err := unmarshalLSPParams(m, p); err != nil {
+			return nil, err
+		}
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		d, err := s.open(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+		if err != nil {
+			return nil, err
+		}
+		// Only the syntax is validated while editing, because the linter reads the runbook from the file.
+		return nil, s.publishDiagnostics(d, false)
+	case "textDocument/didSave":
+		p := &lspDidSaveTextDocumentParams{}
+		if err := unmarshalLSPParams(m, p); err != nil {
+			return nil, err
+		}
+		d, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		if p.Text != nil {
+			if d, err = s.open(p.TextDocument.URI, *p.Text); err != nil {
+				return nil, err
+			}
+		}
+		return nil, s.publishDiagnostics(d, true)
+	case "textDocument/didClose":
+		p := &lspDidCloseTextDocumentParams{}
+		if err := unmarshalLSPParams(m, p); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		delete(s.docs, p.TextDocument.URI)
+		s.mu.Unlock()
+		return nil, s.conn.notify("textDocument/publishDiagnostics", &lspPublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []lspDiagnostic{}})
+	case "textDocument/completion":
+		p := &lspTextDocumentPositionParams{}
+		if err := unmarshalLSPParams(m, p); err != nil {
+			return nil, err
+		}
+		d, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return &lspCompletionList{Items: s.complete(d, p.Position)}, nil
+	case "textDocument/definition":
+		p := &lspTextDocumentPositionParams{}
+		if err := unmarshalLSPParams(m, p); err != nil {
+			return nil, err
+		}
+		d, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return d.definition(p.Position), nil
+	case "textDocument/hover":
+		p := &lspTextDocumentPositionParams{}
+		if err := unmarshalLSPParams(m, p); err != nil {
+			return nil, err
+		}
+		d, err := s.document(p.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return d.hover(p.Position), nil
+	default:
+		if strings.HasPrefix(m.Method, "$/") {
+			// Optional notifications and requests ( e.g. $/cancelRequest )
+			return nil, nil
+		}
+		return nil, &lspError{Code: lspErrMethodNotFound, Message: fmt.Sprintf("method not found: %s", m.Method)}
+	}
+}
+
+func unmarshalLSPParams(m *lspMessage, v any) error {
+	if err := json.Unmarshal(m.Params, v); err != nil {
+		return &lspError{Code: lspErrInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// open opens or updates the document.
+func (s *lspServer) open(uri, text string) (*lspDocument, error) {
+	p, err := lspURIToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.docs[uri]
+	if !ok {
+		d = &lspDocument{uri: uri, path: p}
+		s.docs[uri] = d
+	}
+	d.lines = strings.Split(text, "\n")
+	return d, nil
+}
+
+func (s *lspServer) document(uri string) (*lspDocument, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.docs[uri]
+	if !ok {
+		return nil, &lspError{Code: lspErrInvalidParams, Message: fmt.Sprintf("%s: %s", errLSPNotOpened, uri)}
+	}
+	return d, nil
+}
+
+// publishDiagnostics publishes the problems of the runbook.
+// If lint is true, the runbook file is also linted.
+func (s *lspServer) publishDiagnostics(d *lspDocument, lint bool) error {
+	diags := []lspDiagnostic{}
+	bk, err := parseBook(strings.NewReader(strings.Join(d.lines, "\n")))
+	if err != nil {
+		line := 0
+		if m := lspYAMLErrorRe.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		diags = append(diags, d.diagnostic(line, firstLine(err.Error())))
+	} else {
+		d.bk = bk
+		if lint {
+			diags = append(diags, s.lint(d)...)
+		}
+	}
+	return s.conn.notify("textDocument/publishDiagnostics", &lspPublishDiagnosticsParams{URI: d.uri, Diagnostics: diags})
+}
+
+func (s *lspServer) lint(d *lspDocument) []lspDiagnostic {
+	if _, err := os.Stat(d.path); err != nil {
+		// Not saved yet
+		return nil
+	}
+	opn, err := Load(d.path, append(s.opts, LoadOnly())...)
+	if err != nil {
+		return []lspDiagnostic{d.diagnostic(0, firstLine(err.Error()))}
+	}
+	problems, err := opn.Lint()
+	if err != nil {
+		return []lspDiagnostic{d.diagnostic(0, firstLine(err.Error()))}
+	}
+	var diags []lspDiagnostic
+	for _, p := range problems {
+		diags = append(diags, d.diagnostic(p.Line, p.Message))
+	}
+	return diags
+}
+
+// diagnostic returns the diagnostic for the whole line. The line number is 1-based and 0 means unknown.
+func (d *lspDocument) diagnostic(line int, msg string) lspDiagnostic {
+	l := 0
+	if line > 0 && line <= len(d.lines) {
+		l = line - 1
+	}
+	return lspDiagnostic{
+		Range: lspRange{
+			Start: lspPosition{Line: l},
+			End:   lspPosition{Line: l, Character: lspUTF16Len(d.lines[l])},
+		},
+		Severity: lspDiagnosticSeverityError,
+		Source:   lspSource,
+		Message:  msg,
+	}
+}
+
+// complete returns the completion items of the path or the built-in function at the position.
+func (s *lspServer) complete(d *lspDocument, pos lspPosition) []*lspCompletionItem {
+	items := []*lspCompletionItem{}
+	before := d.textBefore(pos)
+	token := lspPathRe.FindString(before)
+	if token == "" {
+		return items
+	}
+	segs, partial, ok := lspParsePath(token)
+	if !ok {
+		return items
+	}
+	if len(segs) == 0 {
+		// Root of the expression
+		if !strings.Contains(before, ":") && !strings.HasPrefix(strings.TrimSpace(before), "-") {
+			// Not in the value of YAML
+			return items
+		}
+		for _, k := range store.ReservedRootKeys {
+			items = append(items, &lspCompletionItem{Label: k, Kind: lspCompletionItemKindVariable})
+		}
+		for _, f := range s.funcs {
+			items = append(items, &lspCompletionItem{Label: f, Kind: lspCompletionItemKindFunction, Detail: "built-in function"})
+		}
+		return items
+	}
+	bk := d.bk
+	if bk == nil {
+		return items
+	}
+	switch segs[0] {
+	case store.RootKeyVars:
+		v := any(bk.vars)
+		for _, seg := range segs[1:] {
+			v = lspChild(v, seg)
+		}
+		m, ok := v.(map[string]any)
+		if !ok || partial == "[" {
+			return items
+		}
+		for _, k := range lspSortedKeys(m) {
+			items = append(items, &lspCompletionItem{Label: k, Kind: lspCompletionItemKindField, Detail: lspValueDetail(m[k])})
+		}
+	case store.RootKeySteps:
+		if len(segs) == 1 {
+			switch {
+			case partial == "[" && !bk.useMap:
+				for i := range bk.rawSteps {
+					items = append(items, &lspCompletionItem{Label: strconv.Itoa(i), Kind: lspCompletionItemKindField, Detail: lspStepDesc(bk.rawSteps[i])})
+				}
+			case partial != "[" && bk.useMap:
+				for i, k := range bk.stepKeys {
+					items = append(items, &lspCompletionItem{Label: k, Kind: lspCompletionItemKindField, Detail: lspStepDesc(bk.rawSteps[i])})
+				}
+			}
+			return items
+		}
+		idx := -1
+		if bk.useMap {
+			for i, k := range bk.stepKeys {
+				if k == segs[1] {
+					idx = i
+				}
+			}
+		} else if i, err := strconv.Atoi(segs[1]); err == nil {
+			idx = i
+		}
+		items = append(items, lspRecordedKeyItems(bk, idx, segs[2:], partial)...)
+	case store.RootKeyCurrent, store.RootKeyPrevious:
+		idx := d.stepIndex(pos.Line + 1)
+		if segs[0] == store.RootKeyPrevious {
+			idx--
+		}
+		items = append(items, lspRecordedKeyItems(bk, idx, segs[1:], partial)...)
+	}
+	return items
+}
+
+// definition returns the location of the runbook of `include:` at the position.
+func (d *lspDocument) definition(pos lspPosition) []lspLocation {
+	locs := []lspLocation{}
+	if pos.Line >= len(d.lines) {
+		return locs
+	}
+	m := lspKeyValueRe.FindStringSubmatch(d.lines[pos.Line])
+	if m == nil {
+		return locs
+	}
+	key, p := m[1], strings.Trim(m[2], `'"`)
+	ancestors := lspAncestorKeys(d.lines, pos.Line)
+	switch {
+	case key == includeRunnerKey:
+	case key == "path" && len(ancestors) > 0 && ancestors[0] == includeRunnerKey:
+	case key == "path" && len(ancestors) > 1 && ancestors[1] == "runners":
+		// Path of the include runner ( custom runner )
+	default:
+		return locs
+	}
+	if strings.Contains(p, "{{") || hasRemotePrefix(p) {
+		return locs
+	}
+	p = resolveReferencedPath(p, filepath.Dir(d.path))
+	if _, err := os.Stat(p); err != nil {
+		return locs
+	}
+	return append(locs, lspLocation{URI: lspPathToURI(p)})
+}
+
+// hover returns the docs of the CDP function at the position.
+func (d *lspDocument) hover(pos lspPosition) *lspHover {
+	if pos.Line >= len(d.lines) {
+		return nil
+	}
+	line := d.lines[pos.Line]
+	col := len(d.textBefore(pos))
+	for _, loc := range lspWordRe.FindAllStringIndex(line, -1) {
+		if col < loc[0] || col > loc[1] {
+			continue
+		}
+		word := line[loc[0]:loc[1]]
+		k, fn, err := findCDPFn(word)
+		if err != nil {
+			return nil
+		}
+		if !slices.Contains(lspAncestorKeys(d.lines, pos.Line), "actions") {
+			return nil
+		}
+		return &lspHover{
+			Contents: lspMarkupContent{Kind: lspMarkupKindMarkdown, Value: cdpFnDoc(k, fn)},
+			Range: &lspRange{
+				Start: lspPosition{Line: pos.Line, Character: lspUTF16Len(line[:loc[0]])},
+				End:   lspPosition{Line: pos.Line, Character: lspUTF16Len(line[:loc[1]])},
+			},
+		}
+	}
+	return nil
+}
+
+// cdpFnDoc returns the docs of the CDP function in Markdown.
+func cdpFnDoc(k string, fn CDPFn) string {
+	doc := &strings.Builder{}
+	as := ""
+	if len(fn.Aliases) > 0 {
+		as = fmt.Sprintf(" (aliases: `%s`)", strings.Join(fn.Aliases, "`, `"))
+	}
+	_, _ = fmt.Fprintf(doc, "**`%s`**%s\n\n", k, as)
+	_, _ = fmt.Fprintf(doc, "%s\n\n", fn.Desc)
+	_, _ = fmt.Fprint(doc, "```yaml\n")
+	_, _ = fmt.Fprint(doc, "actions:\n")
+	if len(fn.Args.ArgArgs()) == 0 {
+		_, _ = fmt.Fprintf(doc, "  - %s\n", k)
+	} else {
+		_, _ = fmt.Fprintf(doc, "  - %s:\n", k)
+	}
+	for _, a := range fn.Args.ArgArgs() {
+		_, _ = fmt.Fprintf(doc, "      %s: %q\n", a.Key, a.Example)
+	}
+	for _, a := range fn.Args.ResArgs() {
+		_, _ = fmt.Fprintf(doc, "# record to current.%s:\n", a.Key)
+	}
+	_, _ = fmt.Fprint(doc, "```")
+	return doc.String()
+}
+
+// textBefore returns the text of the line before the position.
+func (d *lspDocument) textBefore(pos lspPosition) string {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return ""
+	}
+	line := d.lines[pos.Line]
+	n := 0
+	for i, r := range line {
+		if n >= pos.Character {
+			return line[:i]
+		}
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return line
+}
+
+// stepIndex returns the index of the step at the line ( 1-based ). -1 means not in steps.
+func (d *lspDocument) stepIndex(line int) int {
+	a := detectRunbookAreas(strings.Join(d.lines, "\n"))
+	for i, s := range a.Steps {
+		if s.Start.Line <= line && line <= s.End.Line {
+			return i
+		}
+	}
+	return -1
+}
+
+// lspParsePath parses the path in the expression ( e.g. `steps[0].res.` ) into the segments and the partial segment being typed.
+// The partial segment is "[" if the path ends with "[".
+func lspParsePath(token string) ([]string, string, bool) {
+	var segs []string
+	rest := token
+	for rest != "" {
+		if rest == "." {
+			return segs, "", true
+		}
+		if rest == "[" {
+			return segs, "[", true
+		}
+		m := lspSegmentRe.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, "", false
+		}
+		rest = rest[len(m[0]):]
+		switch {
+		case m[1] != "":
+			if rest == "" {
+				return segs, m[1], true
+			}
+			segs = append(segs, m[1])
+		case m[2] != "":
+			segs = append(segs, m[2])
+		default:
+			segs = append(segs, m[3]+m[4])
+		}
+	}
+	return segs, "", true
+}
+
+// lspRecordedKeyItems returns the completion items of the keys recorded by the step.
+func lspRecordedKeyItems(bk *book, idx int, segs []string, partial string) []*lspCompletionItem {
+	items := []*lspCompletionItem{}
+	if idx < 0 || idx >= len(bk.rawSteps) || partial == "[" {
+		return items
+	}
+	keys, ok := lspRecordedKeys[lspStepRunnerType(bk, bk.rawSteps[idx])]
+	if !ok {
+		return items
+	}
+	for _, seg := range segs {
+		keys = keys[seg]
+	}
+	for _, k := range lspSortedKeys(keys) {
+		items = append(items, &lspCompletionItem{Label: k, Kind: lspCompletionItemKindField})
+	}
+	return items
+}
+
+// lspStepRunnerType returns the type of the runner of the step.
+func lspStepRunnerType(bk *book, s map[string]any) string {
+	for k := range s {
+		switch k {
+		case ifSectionKey, descSectionKey, loopSectionKey, deferSectionKey, forceSectionKey, testRunnerKey, dumpRunnerKey, bindRunnerKey:
+			continue
+		case execRunnerKey, includeRunnerKey, runnerRunnerKey:
+			return k
+		}
+		if v, ok := bk.runners[k]; ok {
+			return lspRunnerType(v)
+		}
+	}
+	return ""
+}
+
+// lspRunnerType returns the type of the runner from the DSN or the config in the same order as book.parseRunner.
+func lspRunnerType(v any) string {
+	switch vv := v.(type) {
+	case string:
+		switch {
+		case strings.HasPrefix(vv, "https://") || strings.HasPrefix(vv, "http://"):
+			return "http"
+		case strings.HasPrefix(vv, "grpc://"):
+			return "grpc"
+		case strings.HasPrefix(vv, "cdp://") || strings.HasPrefix(vv, "chrome://"):
+			return "cdp"
+		case strings.HasPrefix(vv, "ssh://"):
+			return "ssh"
+		case strings.HasPrefix(vv, "ws://") || strings.HasPrefix(vv, "wss://"):
+			return "ws"
+		case strings.HasPrefix(vv, "kafka://"):
+			return "kafka"
+		case strings.HasPrefix(vv, "redis://") || strings.HasPrefix(vv, "rediss://"):
+			return "redis"
+		case strings.HasPrefix(vv, "smtp://"):
+			return "smtp"
+		default:
+			return "db"
+		}
+	case map[string]any:
+		for _, c := range []struct {
+			key string
+			typ string
+		}{
+			{"endpoint", "http"},
+			{"mock", "mock"},
+			{"addr", "grpc"},
+			{"dsn", "db"},
+			{"host", "ssh"},
+			{"hostname", "ssh"},
+			{"path", includeRunnerKey},
+		} {
+			if _, ok := vv[c.key]; ok {
+				return c.typ
+			}
+		}
+	}
+	return ""
+}
+
+// lspAncestorKeys returns the keys of the ancestors of the line in YAML, nearest first.
+func lspAncestorKeys(lines []string, idx int) []string {
+	var keys []string
+	indent, item := lspIndent(lines[idx])
+	for i := idx - 1; i >= 0; i-- {
+		l := lines[i]
+		t := strings.TrimSpace(l)
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		ind, it := lspIndent(l)
+		if ind > indent || (ind == indent && (!item || it)) {
+			continue
+		}
+		m := lspKeyRe.FindStringSubmatch(l)
+		if m == nil {
+			continue
+		}
+		keys = append(keys, strings.Trim(m[1], `'"`))
+		indent, item = ind, it
+	}
+	return keys
+}
+
+// lspIndent returns the indentation of the line and whether the line is an item of the sequence.
+func lspIndent(l string) (int, bool) {
+	t := strings.TrimLeft(l, " ")
+	return len(l) - len(t), strings.HasPrefix(t, "- ") || t == "-"
+}
+
+func lspChild(v any, seg string) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		return vv[seg]
+	case []any:
+		i, err := strconv.Atoi(seg)
+		if err != nil || i < 0 || i >= len(vv) {
+			return nil
+		}
+		return vv[i]
+	}
+	return nil
+}
+
+func lspSortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func lspValueDetail(v any) string {
+	switch vv := v.(type) {
+	case map[string]any:
+		return "map"
+	case []any:
+		return "array"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%v", vv)
+	}
+}
+
+func lspStepDesc(s map[string]any) string {
+	desc, _ := s[descSectionKey].(string)
+	return desc
+}
+
+func lspUTF16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+func lspURIToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", &lspError{Code: lspErrInvalidParams, Message: fmt.Sprintf("unsupported URI: %s", uri)}
+	}
+	return filepath.FromSlash(u.Path), nil
+}
+
+func lspPathToURI(p string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String()
+}
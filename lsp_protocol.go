@@ -0,0 +1,259 @@
+package runn
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Subset of Language Server Protocol 3.17.
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/
+
+const lspJSONRPCVersion = "2.0"
+
+// JSON-RPC error codes.
+const (
+	lspErrMethodNotFound       = -32601
+	lspErrInvalidParams        = -32602
+	lspErrServerNotInitialized = -32002
+	lspErrInvalidRequest       = -32600
+)
+
+// LSP enums.
+const (
+	lspTextDocumentSyncKindFull = 1
+
+	lspDiagnosticSeverityError = 1
+
+	lspCompletionItemKindFunction = 3
+	lspCompletionItemKindField    = 5
+	lspCompletionItemKindVariable = 6
+
+	lspMarkupKindMarkdown = "markdown"
+)
+
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+type lspResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+type lspErrorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *lspError       `json:"error"`
+}
+
+type lspNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *lspError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type lspTextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type lspTextDocumentPositionParams struct {
+	TextDocument lspTextDocumentIdentifier `json:"textDocument"`
+	Position     lspPosition               `json:"position"`
+}
+
+type lspDidOpenTextDocumentParams struct {
+	TextDocument lspTextDocumentItem `json:"textDocument"`
+}
+
+type lspDidChangeTextDocumentParams struct {
+	TextDocument   lspTextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type lspDidSaveTextDocumentParams struct {
+	TextDocument lspTextDocumentIdentifier `json:"textDocument"`
+	Text         *string                   `json:"text,omitempty"`
+}
+
+type lspDidCloseTextDocumentParams struct {
+	TextDocument lspTextDocumentIdentifier `json:"textDocument"`
+}
+
+type lspPublishDiagnosticsParams struct {
+	URI         string          `json:"uri"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+}
+
+type lspCompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type lspCompletionList struct {
+	IsIncomplete bool                 `json:"isIncomplete"`
+	Items        []*lspCompletionItem `json:"items"`
+}
+
+type lspMarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type lspHover struct {
+	Contents lspMarkupContent `json:"contents"`
+	Range    *lspRange        `json:"range,omitempty"`
+}
+
+type lspInitializeResult struct {
+	Capabilities lspServerCapabilities `json:"capabilities"`
+	ServerInfo   lspServerInfo         `json:"serverInfo"`
+}
+
+type lspServerCapabilities struct {
+	TextDocumentSync   lspTextDocumentSyncOptions `json:"textDocumentSync"`
+	CompletionProvider lspCompletionOptions       `json:"completionProvider"`
+	HoverProvider      bool                       `json:"hoverProvider"`
+	DefinitionProvider bool                       `json:"definitionProvider"`
+}
+
+type lspTextDocumentSyncOptions struct {
+	OpenClose bool           `json:"openClose"`
+	Change    int            `json:"change"`
+	Save      lspSaveOptions `json:"save"`
+}
+
+type lspSaveOptions struct {
+	IncludeText bool `json:"includeText"`
+}
+
+type lspCompletionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters"`
+}
+
+type lspServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// lspConn reads and writes the messages with the base protocol ( Content-Length header and JSON-RPC content ).
+type lspConn struct {
+	r  *textproto.Reader
+	br *bufio.Reader
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func newLSPConn(r io.Reader, w io.Writer) *lspConn {
+	br := bufio.NewReader(r)
+	return &lspConn{
+		r:  textproto.NewReader(br),
+		br: br,
+		w:  w,
+	}
+}
+
+func (c *lspConn) read() (*lspMessage, error) {
+	h, err := c.r.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	l, err := strconv.Atoi(strings.TrimSpace(h.Get("Content-Length")))
+	if err != nil || l < 0 {
+		return nil, fmt.Errorf("invalid Content-Length: %q", h.Get("Content-Length"))
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(c.br, b); err != nil {
+		return nil, err
+	}
+	m := &lspMessage{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	if m.JSONRPC != lspJSONRPCVersion {
+		return nil, errors.New("invalid JSON-RPC version")
+	}
+	return m, nil
+}
+
+func (c *lspConn) write(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(b)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(b)
+	return err
+}
+
+func (c *lspConn) reply(id json.RawMessage, result any, err error) error {
+	if err != nil {
+		var le *lspError
+		if !errors.As(err, &le) {
+			le = &lspError{Code: lspErrInvalidRequest, Message: err.Error()}
+		}
+		return c.write(&lspErrorResponse{JSONRPC: lspJSONRPCVersion, ID: id, Error: le})
+	}
+	return c.write(&lspResponse{JSONRPC: lspJSONRPCVersion, ID: id, Result: result})
+}
+
+func (c *lspConn) notify(method string, params any) error {
+	return c.write(&lspNotification{JSONRPC: lspJSONRPCVersion, Method: method, Params: params})
+}
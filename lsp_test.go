@@ -0,0 +1,340 @@
+package runn
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const lspTestRunbook = `desc: LSP test
+runners:
+  req: https://example.com
+  cc: chrome://new
+vars:
+  user:
+    name: alice
+    age: 20
+steps:
+  login:
+    req:
+      /login:
+        post:
+          body:
+            application/json:
+              name: "{{ vars.user. }}"
+    test: current.res.status == 200 && steps.login.res.
+  included:
+    include:
+      path: included.yml
+  browser:
+    cc:
+      actions:
+        - navigate: https://example.com
+`
+
+func TestLSP(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.yml")
+	included := filepath.Join(dir, "included.yml")
+	if err := os.WriteFile(main, []byte(lspTestRunbook), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(included, []byte("desc: included\nsteps:\n  -\n    test: true\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := newLSPTestClient(t)
+	if err := c.call("initialize", map[string]any{"capabilities": map[string]any{}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.notify("initialized", map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+
+	uri := lspPathToURI(main)
+	if err := c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": lspTextDocumentItem{URI: uri, LanguageID: "yaml", Version: 1, Text: lspTestRunbook},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	{
+		// The expressions being typed are reported by lint.
+		diags := c.diagnostics(t)
+		var got []int
+		for _, d := range diags.Diagnostics {
+			got = append(got, d.Range.Start.Line)
+		}
+		if diff := cmp.Diff(got, []int{15, 16}); diff != "" {
+			t.Errorf("%s: %v", diff, diags.Diagnostics)
+		}
+	}
+
+	t.Run("completion", func(t *testing.T) {
+		tests := []struct {
+			line   string
+			before string
+			want   []string
+		}{
+			{`name: "{{ vars.user. }}"`, "vars.user.", []string{"age", "name"}},
+			{`name: "{{ vars.user. }}"`, "vars.", []string{"user"}},
+			{"test: current.res.status", "current.res.", []string{"body", "cookies", "events", "headers", "proto", "rawBody", "status"}},
+			{"test: current.res.status", "current.", []string{"res"}},
+			{"steps.login.res.", "steps.", []string{"login", "included", "browser"}},
+			{"steps.login.res.", "steps.login.res.", []string{"body", "cookies", "events", "headers", "proto", "rawBody", "status"}},
+			{"test: current.res.status", "current.res.sta", []string{"body", "cookies", "events", "headers", "proto", "rawBody", "status"}},
+			{"path: included.yml", "included.", nil},
+		}
+		for _, tt := range tests {
+			var got lspCompletionList
+			if err := c.call("textDocument/completion", c.position(t, uri, tt.line, tt.before), &got); err != nil {
+				t.Fatal(err)
+			}
+			var labels []string
+			for _, i := range got.Items {
+				labels = append(labels, i.Label)
+			}
+			if diff := cmp.Diff(labels, tt.want); diff != "" {
+				t.Errorf("%s: %s", tt.before, diff)
+			}
+		}
+
+		var got lspCompletionList
+		if err := c.call("textDocument/completion", c.position(t, uri, "test: current", "test: cur"), &got); err != nil {
+			t.Fatal(err)
+		}
+		var labels []string
+		for _, i := range got.Items {
+			labels = append(labels, i.Label)
+		}
+		for _, want := range []string{"vars", "steps", "current", "urlencode", "faker"} {
+			if !strings.Contains(strings.Join(labels, " "), want) {
+				t.Errorf("want %q in %v", want, labels)
+			}
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		var got []lspLocation
+		if err := c.call("textDocument/definition", c.position(t, uri, "path: included.yml", "path: incl"), &got); err != nil {
+			t.Fatal(err)
+		}
+		want := []lspLocation{{URI: lspPathToURI(included)}}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var got *lspHover
+		if err := c.call("textDocument/hover", c.position(t, uri, "- navigate:", "- navi"), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got == nil || !strings.Contains(got.Contents.Value, CDPFnMap["navigate"].Desc) {
+			t.Errorf("got %v", got)
+		}
+		got = nil
+		if err := c.call("textDocument/hover", c.position(t, uri, "path: included.yml", "path: incl"), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			t.Errorf("got %v", got)
+		}
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		if err := c.notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": uri, "version": 2},
+			"contentChanges": []map[string]any{{"text": lspTestRunbook + "  broken: [\n"}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		diags := c.diagnostics(t)
+		if len(diags.Diagnostics) != 1 {
+			t.Fatalf("want 1 diagnostic, got %v", diags.Diagnostics)
+		}
+
+		p, err := filepath.Abs("testdata/lint_problems.yml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.notify("textDocument/didOpen", map[string]any{
+			"textDocument": lspTextDocumentItem{URI: lspPathToURI(p), LanguageID: "yaml", Version: 1, Text: string(b)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		diags = c.diagnostics(t)
+		found := false
+		for _, d := range diags.Diagnostics {
+			if d.Range.Start.Line == 15 && strings.Contains(d.Message, "steps.logni") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("want diagnostic of steps.logni: %v", diags.Diagnostics)
+		}
+	})
+
+	if err := c.call("shutdown", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.notify("exit", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-c.done; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLSPParsePath(t *testing.T) {
+	tests := []struct {
+		in          string
+		wantSegs    []string
+		wantPartial string
+	}{
+		{"vars.", []string{"vars"}, ""},
+		{"vars.user.na", []string{"vars", "user"}, "na"},
+		{"steps[0].res.", []string{"steps", "0", "res"}, ""},
+		{"steps[", []string{"steps"}, "["},
+		{"steps['login'].", []string{"steps", "login"}, ""},
+		{"ste", nil, "ste"},
+	}
+	for _, tt := range tests {
+		segs, partial, ok := lspParsePath(lspPathRe.FindString(tt.in))
+		if !ok {
+			t.Errorf("%s: failed to parse", tt.in)
+			continue
+		}
+		if diff := cmp.Diff(segs, tt.wantSegs); diff != "" {
+			t.Errorf("%s: %s", tt.in, diff)
+		}
+		if partial != tt.wantPartial {
+			t.Errorf("%s: want %q, got %q", tt.in, tt.wantPartial, partial)
+		}
+	}
+}
+
+type lspTestClient struct {
+	conn *lspConn
+	id   int
+	done chan error
+	// docs - Opened documents for calculating positions.
+	docs map[string][]string
+}
+
+func newLSPTestClient(t *testing.T) *lspTestClient {
+	t.Helper()
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	c := &lspTestClient{
+		conn: newLSPConn(cr, cw),
+		done: make(chan error, 1),
+		docs: map[string][]string{},
+	}
+	go func() {
+		c.done <- ServeLSP(context.Background(), sr, sw, Scopes(ScopeAllowReadParent))
+		_ = sw.Close()
+	}()
+	t.Cleanup(func() {
+		_ = cw.Close()
+	})
+	return c
+}
+
+func (c *lspTestClient) call(method string, params, result any) error {
+	c.id++
+	id, _ := json.Marshal(c.id)
+	p, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	if err := c.conn.write(&lspMessage{JSONRPC: lspJSONRPCVersion, ID: id, Method: method, Params: p}); err != nil {
+		return err
+	}
+	for {
+		m, err := c.conn.read()
+		if err != nil {
+			return err
+		}
+		if string(m.ID) != string(id) {
+			continue
+		}
+		if m.Error != nil {
+			return m.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(m.Result, result)
+	}
+}
+
+func (c *lspTestClient) notify(method string, params any) error {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	if method == "textDocument/didOpen" || method == "textDocument/didChange" {
+		var d struct {
+			TextDocument   lspTextDocumentItem `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(p, &d); err != nil {
+			return err
+		}
+		text := d.TextDocument.Text
+		if len(d.ContentChanges) > 0 {
+			text = d.ContentChanges[0].Text
+		}
+		c.docs[d.TextDocument.URI] = strings.Split(text, "\n")
+	}
+	return c.conn.write(&lspMessage{JSONRPC: lspJSONRPCVersion, Method: method, Params: p})
+}
+
+func (c *lspTestClient) diagnostics(t *testing.T) *lspPublishDiagnosticsParams {
+	t.Helper()
+	for {
+		m, err := c.conn.read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		p := &lspPublishDiagnosticsParams{}
+		if err := json.Unmarshal(m.Params, p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+}
+
+// position returns the position at the end of `before` in the first line containing `line`.
+func (c *lspTestClient) position(t *testing.T, uri, line, before string) *lspTextDocumentPositionParams {
+	t.Helper()
+	for i, l := range c.docs[uri] {
+		if !strings.Contains(l, line) {
+			continue
+		}
+		j := strings.Index(l, before)
+		if j < 0 {
+			t.Fatalf("%q is not found in %q", before, l)
+		}
+		return &lspTextDocumentPositionParams{
+			TextDocument: lspTextDocumentIdentifier{URI: uri},
+			Position:     lspPosition{Line: i, Character: lspUTF16Len(l[:j+len(before)])},
+		}
+	}
+	t.Fatalf("%q is not found", line)
+	return nil
+}
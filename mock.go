@@ -0,0 +1,397 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+)
+
+const (
+	mockStoreRequestsKey = "requests"
+	mockStoreResponseKey = "res"
+
+	mockRequestMethodKey  = "method"
+	mockRequestPathKey    = "path"
+	mockRequestQueryKey   = "query"
+	mockRequestParamsKey  = "params"
+	mockRequestHeadersKey = "headers"
+	mockRequestBodyKey    = "body"
+	mockRequestRawBodyKey = "rawBody"
+	mockRequestStatusKey  = "status"
+
+	// mockExpandRequestKey - Root key of the received request in the expansion of the response.
+	mockExpandRequestKey = "request"
+)
+
+const mockDefaultWaitTimeout = 10 * time.Second
+
+// MockRequest is a request received by the mock runner.
+type MockRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	// Params - Values of the path parameters of the matched route.
+	Params map[string]string
+	Header http.Header
+	Body   []byte
+	// Status - Status code of the response.
+	Status int
+}
+
+type mockRunner struct {
+	name   string
+	addr   string
+	routes []*mockRoute
+	server *http.Server
+	// vars - Variables of the runbook available in the expansion of the response.
+	vars any
+	mu   sync.Mutex
+	// requests - Received requests that have not yet been recorded by any step.
+	requests []*MockRequest
+	// received - Closed and renewed when a request is received.
+	received chan struct{}
+	// operatorID - The id of the operator for which the runner is defined.
+	operatorID string
+}
+
+type mockRoute struct {
+	Method  string            `yaml:"method,omitempty"`
+	Path    string            `yaml:"path"`
+	Status  int               `yaml:"status,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    any               `yaml:"body,omitempty"`
+}
+
+type mockRequest struct {
+	count   int
+	match   string
+	timeout time.Duration
+}
+
+func newMockRunner(name, addr string) (*mockRunner, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("invalid mock server address: %w", err)
+	}
+	return &mockRunner{
+		name: name,
+		addr: addr,
+	}, nil
+}
+
+func (r *mockRoute) validate() error {
+	if !strings.HasPrefix(r.Path, "/") {
+		return fmt.Errorf("invalid route path: %q", r.Path)
+	}
+	if r.Status != 0 && (r.Status < 100 || r.Status > 999) {
+		return fmt.Errorf("invalid route status: %d", r.Status)
+	}
+	return nil
+}
+
+// match reports whether the route matches the request and returns the values of the path parameters.
+func (r *mockRoute) match(method, path string) (map[string]string, bool) {
+	if r.Method != "" && r.Method != "*" && !strings.EqualFold(r.Method, method) {
+		return nil, false
+	}
+	params := map[string]string{}
+	ps := strings.Split(strings.TrimPrefix(r.Path, "/"), "/")
+	ss := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, p := range ps {
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "...}") {
+			// Wildcard matches the rest of the path.
+			params[strings.TrimSuffix(strings.TrimPrefix(p, "{"), "...}")] = strings.Join(ss[i:], "/")
+			return params, true
+		}
+		if i >= len(ss) {
+			return nil, false
+		}
+		if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+			if ss[i] == "" {
+				return nil, false
+			}
+			params[strings.TrimSuffix(strings.TrimPrefix(p, "{"), "}")] = ss[i]
+			continue
+		}
+		if p != ss[i] {
+			return nil, false
+		}
+	}
+	if len(ps) != len(ss) {
+		return nil, false
+	}
+	return params, true
+}
+
+// start starts the mock server.
+func (rnr *mockRunner) start(o *operator) error {
+	if rnr.server != nil {
+		return nil
+	}
+	l, err := net.Listen("tcp", rnr.addr)
+	if err != nil {
+		return fmt.Errorf("failed to start mock server on %s: %w", rnr.addr, err)
+	}
+	s := &http.Server{
+		Handler:           rnr,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	rnr.mu.Lock()
+	rnr.server = s
+	rnr.vars = o.store.ToMap()[store.RootKeyVars]
+	rnr.requests = nil
+	rnr.received = make(chan struct{})
+	rnr.mu.Unlock()
+	go func() {
+		_ = s.Serve(l)
+	}()
+	return nil
+}
+
+func (rnr *mockRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.server == nil {
+		return nil
+	}
+	err := rnr.server.Close()
+	rnr.server = nil
+	return err
+}
+
+// ServeHTTP responds using the first matched route and keeps the request.
+func (rnr *mockRunner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := &MockRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Params: map[string]string{},
+		Header: r.Header.Clone(),
+		Body:   b,
+	}
+	defer rnr.receive(req)
+	for _, route := range rnr.routes {
+		params, ok := route.match(r.Method, r.URL.Path)
+		if !ok {
+			continue
+		}
+		req.Params = params
+		req.Status, err = rnr.respond(w, route, req)
+		if err != nil {
+			req.Status = http.StatusInternalServerError
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	req.Status = http.StatusNotFound
+	http.Error(w, fmt.Sprintf("no route matched: %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+}
+
+func (rnr *mockRunner) respond(w http.ResponseWriter, route *mockRoute, req *MockRequest) (int, error) {
+	sm := map[string]any{
+		store.RootKeyVars:    rnr.vars,
+		mockExpandRequestKey: mockRequestToMap(req),
+	}
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	for k, v := range route.Headers {
+		e, err := expr.EvalExpand(v, sm)
+		if err != nil {
+			return 0, err
+		}
+		w.Header().Set(k, fmt.Sprintf("%v", e))
+	}
+	var body []byte
+	switch v := route.Body.(type) {
+	case nil:
+	case string:
+		e, err := expr.EvalExpand(v, sm)
+		if err != nil {
+			return 0, err
+		}
+		body = []byte(fmt.Sprintf("%v", e))
+	default:
+		e, err := expr.EvalExpand(v, sm)
+		if err != nil {
+			return 0, err
+		}
+		body, err = json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", MediaTypeApplicationJSON)
+		}
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+	return status, nil
+}
+
+func (rnr *mockRunner) receive(req *MockRequest) {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	rnr.requests = append(rnr.requests, req)
+	close(rnr.received)
+	rnr.received = make(chan struct{})
+}
+
+func (rnr *mockRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	e, err := o.expandBeforeRecord(s.mockRequest, s)
+	if err != nil {
+		return err
+	}
+	v, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid mock request: %v", e)
+	}
+	req, err := parseMockRequest(v)
+	if err != nil {
+		return fmt.Errorf("invalid mock request: %w", err)
+	}
+	reqs, err := rnr.collect(ctx, req, s)
+	if err != nil {
+		return err
+	}
+	rs := make([]map[string]any, 0, len(reqs))
+	for _, r := range reqs {
+		o.capturers.captureMockRequest(rnr.name, r)
+		rs = append(rs, mockRequestToMap(r))
+	}
+	o.record(s.idx, map[string]any{
+		mockStoreResponseKey: map[string]any{
+			mockStoreRequestsKey: rs,
+		},
+	})
+	return nil
+}
+
+// collect collects the received requests that match the condition.
+// If count is specified, it waits until the number of collected requests reaches count.
+func (rnr *mockRunner) collect(ctx context.Context, r *mockRequest, s *step) ([]*MockRequest, error) {
+	timeout := r.timeout
+	if timeout == 0 {
+		timeout = mockDefaultWaitTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var collected []*MockRequest
+	for {
+		rnr.mu.Lock()
+		if rnr.server == nil {
+			rnr.mu.Unlock()
+			return nil, fmt.Errorf("mock server is not running: %s", rnr.addr)
+		}
+		var remains []*MockRequest
+		for _, req := range rnr.requests {
+			if r.count > 0 && len(collected) >= r.count {
+				remains = append(remains, req)
+				continue
+			}
+			tf, err := rnr.matchRequest(r.match, req, s)
+			if err != nil {
+				rnr.mu.Unlock()
+				return nil, err
+			}
+			if !tf {
+				remains = append(remains, req)
+				continue
+			}
+			collected = append(collected, req)
+		}
+		rnr.requests = remains
+		received := rnr.received
+		rnr.mu.Unlock()
+
+		if len(collected) >= r.count {
+			return collected, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			if r.match != "" {
+				return nil, fmt.Errorf("%d of %d requests matched %q within %s", len(collected), r.count, r.match, timeout)
+			}
+			return nil, fmt.Errorf("%d of %d requests received within %s", len(collected), r.count, timeout)
+		case <-received:
+		}
+	}
+}
+
+func (rnr *mockRunner) matchRequest(cond string, req *MockRequest, s *step) (bool, error) {
+	if cond == "" {
+		return true, nil
+	}
+	o := s.parent
+	sm := o.store.ToMap()
+	sm[store.RootKeyIncluded] = o.included
+	if !s.deferred {
+		sm[store.RootKeyPrevious] = o.store.Latest()
+	}
+	// `current` in `match:` is the received request.
+	sm[store.RootKeyCurrent] = mockRequestToMap(req)
+	return expr.EvalCond(cond, sm)
+}
+
+func mockRequestToMap(r *MockRequest) map[string]any {
+	query := map[string]any{}
+	for k, v := range r.Query {
+		vv := make([]any, 0, len(v))
+		for _, s := range v {
+			vv = append(vv, s)
+		}
+		query[k] = vv
+	}
+	params := map[string]any{}
+	for k, v := range r.Params {
+		params[k] = v
+	}
+	m := map[string]any{
+		mockRequestMethodKey:  r.Method,
+		mockRequestPathKey:    r.Path,
+		mockRequestQueryKey:   query,
+		mockRequestParamsKey:  params,
+		mockRequestHeadersKey: r.Header,
+		mockRequestBodyKey:    nil,
+		mockRequestRawBodyKey: string(r.Body),
+		mockRequestStatusKey:  r.Status,
+	}
+	if strings.Contains(r.Header.Get("Content-Type"), "json") && len(r.Body) > 0 {
+		var b any
+		if err := json.Unmarshal(r.Body, &b); err == nil {
+			m[mockRequestBodyKey] = b
+		}
+	}
+	return m
+}
+
+func (rnr *mockRunner) addRoutes(routes []*mockRoute) error {
+	var errs error
+	for _, r := range routes {
+		if err := r.validate(); err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		rnr.routes = append(rnr.routes, r)
+	}
+	return errs
+}
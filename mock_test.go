@@ -0,0 +1,158 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestMockRunner(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TEST_MOCK_PORT", strconv.Itoa(testutil.NewPort(t)))
+	o, err := New(Book("testdata/book/mock.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMockRunnerAddrInUse(t *testing.T) {
+	ctx := context.Background()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+	t.Setenv("TEST_MOCK_PORT", strconv.Itoa(l.Addr().(*net.TCPAddr).Port))
+	o, err := New(Book("testdata/book/mock.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err == nil {
+		t.Error("want error")
+	}
+	if o.Result().Err == nil {
+		t.Error("want error in the run result")
+	}
+}
+
+func TestMockRunnerRoutesNotExpandedOnLoad(t *testing.T) {
+	t.Setenv("TEST_MOCK_PORT", strconv.Itoa(testutil.NewPort(t)))
+	// Runners are expanded on load when the runbook is included.
+	bk, err := loadBook("testdata/book/mock.yml", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := bk.mockRunners["downstream"]
+	if !ok {
+		t.Fatal("mock runner not found")
+	}
+	want := "/users/{{ request.body.name }}"
+	if got := r.routes[0].Headers["Location"]; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMockRouteMatch(t *testing.T) {
+	tests := []struct {
+		route     *mockRoute
+		method    string
+		path      string
+		want      map[string]string
+		wantMatch bool
+	}{
+		{&mockRoute{Path: "/users"}, http.MethodGet, "/users", map[string]string{}, true},
+		{&mockRoute{Path: "/users"}, http.MethodGet, "/users/1", nil, false},
+		{&mockRoute{Method: "post", Path: "/users"}, http.MethodPost, "/users", map[string]string{}, true},
+		{&mockRoute{Method: "POST", Path: "/users"}, http.MethodGet, "/users", nil, false},
+		{&mockRoute{Method: "*", Path: "/users"}, http.MethodPut, "/users", map[string]string{}, true},
+		{&mockRoute{Path: "/users/{id}"}, http.MethodGet, "/users/1", map[string]string{"id": "1"}, true},
+		{&mockRoute{Path: "/users/{id}"}, http.MethodGet, "/users/", nil, false},
+		{&mockRoute{Path: "/users/{id}/posts/{pid}"}, http.MethodGet, "/users/1/posts/2", map[string]string{"id": "1", "pid": "2"}, true},
+		{&mockRoute{Path: "/files/{path...}"}, http.MethodGet, "/files/a/b/c.txt", map[string]string{"path": "a/b/c.txt"}, true},
+		{&mockRoute{Path: "/files/{path...}"}, http.MethodGet, "/files/", map[string]string{"path": ""}, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %s %s", tt.route.Method, tt.route.Path, tt.path), func(t *testing.T) {
+			got, ok := tt.route.match(tt.method, tt.path)
+			if ok != tt.wantMatch {
+				t.Fatalf("got %v, want %v", ok, tt.wantMatch)
+			}
+			if fmt.Sprintf("%v", got) != fmt.Sprintf("%v", tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockRunnerCollect(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *mockRequest
+		want    int
+		wantErr string
+	}{
+		{"all requests", &mockRequest{}, 3, ""},
+		{"wait for requests", &mockRequest{count: 2}, 2, ""},
+		{"matched requests", &mockRequest{match: `current.path == "/b"`}, 2, ""},
+		{"timeout", &mockRequest{count: 4, timeout: 200 * time.Millisecond}, 0, "3 of 4 requests received within 200ms"},
+		{"no request matched", &mockRequest{count: 1, match: `current.path == "/c"`, timeout: 200 * time.Millisecond}, 0, `0 of 1 requests matched "current.path == \"/c\"" within 200ms`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			addr := fmt.Sprintf("127.0.0.1:%d", testutil.NewPort(t))
+			o, err := New(MockRunner("downstream", addr, MockRoute("", "/{p...}", http.StatusNoContent, nil, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := o.mockRunners["downstream"]
+			if err := r.start(o); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = r.Close()
+			})
+			for _, p := range []string{"/a", "/b", "/b"} {
+				res, err := http.Get(fmt.Sprintf("http://%s%s", addr, p))
+				if err != nil {
+					t.Fatal(err)
+				}
+				_ = res.Body.Close()
+				if res.StatusCode != http.StatusNoContent {
+					t.Errorf("got %v, want %v", res.StatusCode, http.StatusNoContent)
+				}
+			}
+			s := newStep(0, "stepKey", o, nil)
+			got, err := r.collect(ctx, tt.req, s)
+			if err != nil {
+				if tt.wantErr == "" {
+					t.Fatal(err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("want error %q", tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %v, want %v", len(got), tt.want)
+			}
+		})
+	}
+}
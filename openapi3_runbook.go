@@ -0,0 +1,509 @@
+package runn
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+const (
+	// OpenAPI3GroupByNone generates a runbook for all operations.
+	OpenAPI3GroupByNone = ""
+	// OpenAPI3GroupByTag generates a runbook per tag of operations.
+	OpenAPI3GroupByTag = "tag"
+	// OpenAPI3GroupByOperation generates a runbook per operation.
+	OpenAPI3GroupByOperation = "operation"
+)
+
+const (
+	openAPI3RunnerKey       = "req"
+	openAPI3DefaultEndpoint = "http://localhost"
+	openAPI3UntaggedName    = "untagged"
+	openAPI3MaxSampleDepth  = 8
+)
+
+var openAPI3SupportedMediaTypes = []string{
+	MediaTypeApplicationJSON,
+	MediaTypeApplicationFormUrlencoded,
+	MediaTypeMultipartFormData,
+	MediaTypeTextPlain,
+}
+
+var (
+	openAPI3PathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+	openAPI3NameRe      = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+	openAPI3KeyRe       = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+type openAPI3RunbookConfig struct {
+	groupBy     string
+	docLocation string
+}
+
+// OpenAPI3RunbookOption is the option for NewRunbooksFromOpenAPI3.
+type OpenAPI3RunbookOption func(*openAPI3RunbookConfig) error
+
+// OpenAPI3RunbookGroupBy sets the unit of the generated runbooks (OpenAPI3GroupByNone, OpenAPI3GroupByTag or OpenAPI3GroupByOperation).
+func OpenAPI3RunbookGroupBy(g string) OpenAPI3RunbookOption {
+	return func(c *openAPI3RunbookConfig) error {
+		switch g {
+		case OpenAPI3GroupByNone, OpenAPI3GroupByTag, OpenAPI3GroupByOperation:
+		default:
+			return fmt.Errorf("invalid group: %q", g)
+		}
+		c.groupBy = g
+		return nil
+	}
+}
+
+// OpenAPI3RunbookDocLocation sets the location of the OpenAPI v3 document written to `openapi3:` of the runner.
+// The default is the location of the document passed to NewRunbooksFromOpenAPI3.
+func OpenAPI3RunbookDocLocation(l string) OpenAPI3RunbookOption {
+	return func(c *openAPI3RunbookConfig) error {
+		c.docLocation = l
+		return nil
+	}
+}
+
+type openAPI3Operation struct {
+	method    string
+	path      string
+	pathItem  *v3.PathItem
+	operation *v3.Operation
+}
+
+// NewRunbooksFromOpenAPI3 generates skeleton runbooks from the OpenAPI v3 document.
+// Each runbook has one step per operation, and the returned map is keyed by the name of the runbook.
+func NewRunbooksFromOpenAPI3(l string, opts ...OpenAPI3RunbookOption) (map[string]*runbook, error) {
+	c := &openAPI3RunbookConfig{
+		docLocation: l,
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	b, err := os.ReadFile(l)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := libopenapi.NewDocumentWithConfiguration(b, &datamodel.DocumentConfiguration{
+		AllowFileReferences: true,
+		BasePath:            filepath.Dir(l),
+	})
+	if err != nil {
+		return nil, err
+	}
+	m, errs := doc.BuildV3Model()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if m.Model.Paths == nil {
+		return nil, errors.New("no paths in the OpenAPI v3 document")
+	}
+	title := "OpenAPI"
+	if m.Model.Info != nil && m.Model.Info.Title != "" {
+		title = m.Model.Info.Title
+	}
+
+	var names []string
+	groups := map[string][]*openAPI3Operation{}
+	descs := map[string]string{}
+	for p := orderedmap.First(m.Model.Paths.PathItems); p != nil; p = p.Next() {
+		for o := orderedmap.First(p.Value().GetOperations()); o != nil; o = o.Next() {
+			op := &openAPI3Operation{
+				method:    strings.ToLower(o.Key()),
+				path:      p.Key(),
+				pathItem:  p.Value(),
+				operation: o.Value(),
+			}
+			var name, desc string
+			switch c.groupBy {
+			case OpenAPI3GroupByTag:
+				name = openAPI3UntaggedName
+				if len(op.operation.Tags) > 0 {
+					name = op.operation.Tags[0]
+				}
+				desc = fmt.Sprintf("%s: %s", title, name)
+			case OpenAPI3GroupByOperation:
+				name = op.key()
+				desc = fmt.Sprintf("%s: %s", title, op.desc())
+			default:
+				name = title
+				desc = title
+			}
+			name = strings.Trim(openAPI3NameRe.ReplaceAllString(name, "_"), "_")
+			if name == "" {
+				name = openAPI3UntaggedName
+			}
+			if _, ok := groups[name]; !ok {
+				names = append(names, name)
+				descs[name] = desc
+			}
+			groups[name] = append(groups[name], op)
+		}
+	}
+
+	endpoint := openAPI3Endpoint(m.Model.Servers)
+	rbs := map[string]*runbook{}
+	for _, name := range names {
+		rb := NewRunbook(descs[name])
+		rb.useMap = true
+		rb.Runners[openAPI3RunnerKey] = yaml.MapSlice{
+			{Key: "endpoint", Value: endpoint},
+			{Key: "openapi3", Value: c.docLocation},
+		}
+		if c.groupBy == OpenAPI3GroupByTag && name != openAPI3UntaggedName {
+			rb.Labels = []string{name}
+		}
+		keys := map[string]int{}
+		for _, op := range groups[name] {
+			key := op.key()
+			keys[key]++
+			if keys[key] > 1 {
+				key = fmt.Sprintf("%s%d", key, keys[key])
+			}
+			rb.stepKeys = append(rb.stepKeys, key)
+			rb.Steps = append(rb.Steps, op.step())
+		}
+		rbs[name] = rb
+	}
+	return rbs, nil
+}
+
+// key returns the step key of the operation ( operationId or lowerCamelCase of the method and the path ).
+func (op *openAPI3Operation) key() string {
+	if op.operation.OperationId != "" {
+		return op.operation.OperationId
+	}
+	key := op.method
+	for _, w := range openAPI3KeyRe.Split(op.path, -1) {
+		if w == "" {
+			continue
+		}
+		key += strings.ToUpper(w[:1]) + w[1:]
+	}
+	return key
+}
+
+func (op *openAPI3Operation) desc() string {
+	if op.operation.Summary != "" {
+		return op.operation.Summary
+	}
+	return fmt.Sprintf("%s %s", strings.ToUpper(op.method), op.path)
+}
+
+func (op *openAPI3Operation) step() yaml.MapSlice {
+	params := map[string]*v3.Parameter{}
+	var order []string
+	for _, p := range append(append([]*v3.Parameter{}, op.pathItem.Parameters...), op.operation.Parameters...) {
+		k := fmt.Sprintf("%s:%s", p.In, p.Name)
+		if _, ok := params[k]; !ok {
+			order = append(order, k)
+		}
+		params[k] = p
+	}
+
+	path := openAPI3PathParamRe.ReplaceAllStringFunc(op.path, func(s string) string {
+		name := s[1 : len(s)-1]
+		p, ok := params["path:"+name]
+		if !ok {
+			return s
+		}
+		return fmt.Sprintf("%v", openAPI3ParameterSample(p))
+	})
+	q := url.Values{}
+	h := yaml.MapSlice{}
+	for _, k := range order {
+		p := params[k]
+		if p.Required == nil || !*p.Required {
+			continue
+		}
+		switch p.In {
+		case "query":
+			q.Add(p.Name, fmt.Sprintf("%v", openAPI3ParameterSample(p)))
+		case "header":
+			h = append(h, yaml.MapItem{Key: p.Name, Value: fmt.Sprintf("%v", openAPI3ParameterSample(p))})
+		}
+	}
+	if len(q) > 0 {
+		// Keep the expressions in the query readable.
+		qs, _ := url.QueryUnescape(q.Encode())
+		path = fmt.Sprintf("%s?%s", path, qs)
+	}
+
+	hb := yaml.MapSlice{}
+	if len(h) > 0 {
+		hb = append(hb, yaml.MapItem{Key: "headers", Value: h})
+	}
+	hb = append(hb, yaml.MapItem{Key: "body", Value: openAPI3RequestBody(op.operation.RequestBody)})
+
+	step := yaml.MapSlice{
+		{Key: "desc", Value: op.desc()},
+		{Key: openAPI3RunnerKey, Value: yaml.MapSlice{
+			{Key: path, Value: yaml.MapSlice{
+				{Key: op.method, Value: hb},
+			}},
+		}},
+	}
+	if cond := openAPI3StatusCond(op.operation.Responses); cond != "" {
+		step = append(step, yaml.MapItem{Key: "test", Value: cond + "\n"})
+	}
+	return step
+}
+
+func openAPI3Endpoint(servers []*v3.Server) string {
+	if len(servers) == 0 {
+		return openAPI3DefaultEndpoint
+	}
+	s := servers[0]
+	u := s.URL
+	for v := orderedmap.First(s.Variables); v != nil; v = v.Next() {
+		u = strings.ReplaceAll(u, fmt.Sprintf("{%s}", v.Key()), v.Value().Default)
+	}
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		// Relative server URL
+		u = openAPI3DefaultEndpoint + "/" + strings.TrimPrefix(u, "/")
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+func openAPI3RequestBody(rb *v3.RequestBody) any {
+	if rb == nil || orderedmap.Len(rb.Content) == 0 {
+		return nil
+	}
+	var (
+		mt string
+		m  *v3.MediaType
+	)
+	for _, s := range openAPI3SupportedMediaTypes {
+		for p := orderedmap.First(rb.Content); p != nil; p = p.Next() {
+			if p.Key() == s || (s == MediaTypeApplicationJSON && strings.HasSuffix(p.Key(), "+json")) {
+				mt, m = p.Key(), p.Value()
+				break
+			}
+		}
+		if m != nil {
+			break
+		}
+	}
+	if m == nil {
+		p := orderedmap.First(rb.Content)
+		mt, m = p.Key(), p.Value()
+	}
+	var v any
+	switch {
+	case m.Example != nil:
+		v = openAPI3DecodeNode(m.Example)
+	case orderedmap.Len(m.Examples) > 0 && orderedmap.First(m.Examples).Value().Value != nil:
+		v = openAPI3DecodeNode(orderedmap.First(m.Examples).Value().Value)
+	case m.Schema != nil:
+		v = openAPI3SchemaSample(m.Schema, "", 0)
+	}
+	return yaml.MapSlice{{Key: mt, Value: v}}
+}
+
+// openAPI3StatusCond returns the condition of the documented status code. 2xx codes take precedence.
+func openAPI3StatusCond(res *v3.Responses) string {
+	if res == nil {
+		return ""
+	}
+	var codes []string
+	for p := orderedmap.First(res.Codes); p != nil; p = p.Next() {
+		codes = append(codes, strings.ToUpper(p.Key()))
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	sort.SliceStable(codes, func(i, j int) bool {
+		si := strings.HasPrefix(codes[i], "2")
+		sj := strings.HasPrefix(codes[j], "2")
+		if si != sj {
+			return si
+		}
+		return codes[i] < codes[j]
+	})
+	code := codes[0]
+	if strings.HasSuffix(code, "XX") {
+		n, err := strconv.Atoi(code[:1])
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("current.res.status >= %d && current.res.status < %d", n*100, (n+1)*100)
+	}
+	if _, err := strconv.Atoi(code); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("current.res.status == %s", code)
+}
+
+func openAPI3ParameterSample(p *v3.Parameter) any {
+	switch {
+	case p.Example != nil:
+		return openAPI3DecodeNode(p.Example)
+	case orderedmap.Len(p.Examples) > 0 && orderedmap.First(p.Examples).Value().Value != nil:
+		return openAPI3DecodeNode(orderedmap.First(p.Examples).Value().Value)
+	case p.Schema != nil:
+		return openAPI3SchemaSample(p.Schema, p.Name, 0)
+	}
+	return fakerExpr("LetterN(10)")
+}
+
+// openAPI3SchemaSample returns the sample value of the schema.
+// The sample is taken from the examples of the schema, and falls back to the expression using `faker`.
+func openAPI3SchemaSample(sp *base.SchemaProxy, name string, depth int) any {
+	if sp == nil || depth > openAPI3MaxSampleDepth {
+		return nil
+	}
+	s := sp.Schema()
+	if s == nil {
+		return nil
+	}
+	switch {
+	case s.Example != nil:
+		return openAPI3DecodeNode(s.Example)
+	case len(s.Examples) > 0:
+		return openAPI3DecodeNode(s.Examples[0])
+	case s.Const != nil:
+		return openAPI3DecodeNode(s.Const)
+	case s.Default != nil:
+		return openAPI3DecodeNode(s.Default)
+	case len(s.Enum) > 0:
+		return openAPI3DecodeNode(s.Enum[0])
+	case len(s.AllOf) > 0:
+		merged := yaml.MapSlice{}
+		for _, a := range s.AllOf {
+			if v, ok := openAPI3SchemaSample(a, name, depth+1).(yaml.MapSlice); ok {
+				merged = append(merged, v...)
+			}
+		}
+		if props := openAPI3PropertiesSample(s, depth); len(props) > 0 {
+			merged = append(merged, props...)
+		}
+		return merged
+	case len(s.OneOf) > 0:
+		return openAPI3SchemaSample(s.OneOf[0], name, depth+1)
+	case len(s.AnyOf) > 0:
+		return openAPI3SchemaSample(s.AnyOf[0], name, depth+1)
+	}
+
+	var typ string
+	for _, t := range s.Type {
+		if t != "null" {
+			typ = t
+			break
+		}
+	}
+	if typ == "" && orderedmap.Len(s.Properties) > 0 {
+		typ = "object"
+	}
+	switch typ {
+	case "object":
+		return openAPI3PropertiesSample(s, depth)
+	case "array":
+		if s.Items == nil || !s.Items.IsA() {
+			return []any{}
+		}
+		return []any{openAPI3SchemaSample(s.Items.A, name, depth+1)}
+	case "integer", "number":
+		min, max := 1, 100
+		if s.Minimum != nil {
+			min = int(*s.Minimum)
+		}
+		if s.Maximum != nil {
+			max = int(*s.Maximum)
+		}
+		if max < min {
+			max = min
+		}
+		return fakerExpr(fmt.Sprintf("IntRange(%d, %d)", min, max))
+	case "boolean":
+		return fakerExpr("Bool()")
+	case "string":
+		return openAPI3StringSample(s, name)
+	}
+	return nil
+}
+
+func openAPI3PropertiesSample(s *base.Schema, depth int) yaml.MapSlice {
+	props := yaml.MapSlice{}
+	for p := orderedmap.First(s.Properties); p != nil; p = p.Next() {
+		if ps := p.Value().Schema(); ps != nil && ps.ReadOnly != nil && *ps.ReadOnly {
+			continue
+		}
+		props = append(props, yaml.MapItem{Key: p.Key(), Value: openAPI3SchemaSample(p.Value(), p.Key(), depth+1)})
+	}
+	return props
+}
+
+func openAPI3StringSample(s *base.Schema, name string) any {
+	switch s.Format {
+	case "email":
+		return fakerExpr("Email()")
+	case "uuid":
+		return fakerExpr("UUID()")
+	case "uri", "url":
+		return fakerExpr("URL()")
+	case "ipv4":
+		return fakerExpr("IPv4()")
+	case "ipv6":
+		return fakerExpr("IPv6()")
+	case "hostname":
+		return fakerExpr("Domain()")
+	case "date":
+		return "2006-01-02"
+	case "date-time":
+		return "2006-01-02T15:04:05Z"
+	case "password":
+		return fakerExpr("Password(true, true, true, false, false, 12)")
+	case "binary":
+		return "path/to/file"
+	}
+	n := strings.ToLower(name)
+	switch {
+	case strings.Contains(n, "email"):
+		return fakerExpr("Email()")
+	case strings.Contains(n, "password"):
+		return fakerExpr("Password(true, true, true, false, false, 12)")
+	case n == "username":
+		return fakerExpr("Username()")
+	case strings.Contains(n, "name"):
+		return fakerExpr("Name()")
+	case strings.HasSuffix(n, "url"):
+		return fakerExpr("URL()")
+	}
+	l := 10
+	if s.MinLength != nil && int(*s.MinLength) > l {
+		l = int(*s.MinLength)
+	}
+	if s.MaxLength != nil && int(*s.MaxLength) < l {
+		l = int(*s.MaxLength)
+	}
+	return fakerExpr(fmt.Sprintf("LetterN(%d)", l))
+}
+
+func fakerExpr(fn string) string {
+	return fmt.Sprintf("{{ faker.%s }}", fn)
+}
+
+// openAPI3DecodeNode decodes the YAML node of the document ( e.g. example ).
+func openAPI3DecodeNode(n interface{ Decode(any) error }) any {
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil
+	}
+	return v
+}
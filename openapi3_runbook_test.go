@@ -0,0 +1,126 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/k1LoW/runn/testutil"
+	"github.com/tenntenn/golden"
+)
+
+func TestNewRunbooksFromOpenAPI3(t *testing.T) {
+	tests := []struct {
+		groupBy   string
+		wantNames []string
+	}{
+		{OpenAPI3GroupByNone, []string{"test_spec"}},
+		{OpenAPI3GroupByTag, []string{"untagged"}},
+		{OpenAPI3GroupByOperation, []string{"getNotfound", "getPing", "getPrivate", "getRedirect", "getUsers", "getUsersId", "postHelp", "postUpload", "postUsers"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("group by %q", tt.groupBy), func(t *testing.T) {
+			rbs, err := NewRunbooksFromOpenAPI3("testdata/openapi3.yml", OpenAPI3RunbookGroupBy(tt.groupBy), OpenAPI3RunbookDocLocation("../openapi3.yml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for n := range rbs {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			if fmt.Sprint(names) != fmt.Sprint(tt.wantNames) {
+				t.Errorf("got %v\nwant %v", names, tt.wantNames)
+			}
+			if tt.groupBy != OpenAPI3GroupByNone {
+				return
+			}
+			got := new(bytes.Buffer)
+			enc := yaml.NewEncoder(got, encOpts...)
+			if err := enc.Encode(rbs[names[0]]); err != nil {
+				t.Fatal(err)
+			}
+			f := "openapi3.yml.runbook"
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, testutil.Testdata(), f, got)
+				return
+			}
+			if diff := golden.Diff(t, testutil.Testdata(), f, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestNewRunbooksFromOpenAPI3Samples(t *testing.T) {
+	rbs, err := NewRunbooksFromOpenAPI3("testdata/openapi3_samples.yml", OpenAPI3RunbookGroupBy(OpenAPI3GroupByTag))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(bytes.Buffer)
+	enc := yaml.NewEncoder(got, encOpts...)
+	for _, n := range []string{"pets", "owners"} {
+		rb, ok := rbs[n]
+		if !ok {
+			t.Fatalf("%s is not generated", n)
+		}
+		if err := enc.Encode(rb); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := "openapi3_samples.yml.runbook"
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		golden.Update(t, testutil.Testdata(), f, got)
+		return
+	}
+	if diff := golden.Diff(t, testutil.Testdata(), f, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestNewRunbooksFromOpenAPI3Coverage(t *testing.T) {
+	ctx := context.Background()
+	spec, err := filepath.Abs("testdata/openapi3.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range []string{OpenAPI3GroupByNone, OpenAPI3GroupByOperation} {
+		t.Run(fmt.Sprintf("group by %q", g), func(t *testing.T) {
+			rbs, err := NewRunbooksFromOpenAPI3(spec, OpenAPI3RunbookGroupBy(g))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			for n, rb := range rbs {
+				b, err := yaml.MarshalWithOptions(rb, encOpts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, n+".yml"), b, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ops, err := Load(filepath.Join(dir, "*.yml"), LoadOnly(), Scopes(ScopeAllowReadParent))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cov, err := ops.CollectCoverage(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cov.Specs) != 1 {
+				t.Fatalf("got %d specs", len(cov.Specs))
+			}
+			for k, v := range cov.Specs[0].Coverages {
+				if v == 0 {
+					t.Errorf("%s is not covered", k)
+				}
+			}
+		})
+	}
+}
@@ -35,6 +35,7 @@ import (
 
 var errStepSkipped = errors.New("step skipped")
 var ErrFailFast = errors.New("fail fast")
+var errClientNotFound = errors.New("cannot find client")
 
 var _ otchkiss.Requester = (*operatorN)(nil)
 
@@ -59,6 +60,11 @@ type operator struct {
 	grpcRunners     map[string]*grpcRunner
 	cdpRunners      map[string]*cdpRunner
 	sshRunners      map[string]*sshRunner
+	wsRunners       map[string]*wsRunner
+	kafkaRunners    map[string]*kafkaRunner
+	redisRunners    map[string]*redisRunner
+	smtpRunners     map[string]*smtpRunner
+	mockRunners     map[string]*mockRunner
 	includeRunners  map[string]*includeRunner
 	steps           []*step
 	deferred        *deferredOpAndSteps
@@ -94,6 +100,7 @@ type operator struct {
 	afterFuncs      []func(*RunResult) error
 	sw              *stopw.Span
 	capturers       capturers
+	fixture         *fixtureSession
 	runResult       *RunResult
 	dbg             *dbg
 	hasRunnerRunner bool
@@ -153,6 +160,21 @@ func (op *operator) Close(force bool) {
 	for _, r := range op.sshRunners {
 		_ = r.Close()
 	}
+	for _, r := range op.wsRunners {
+		_ = r.Close()
+	}
+	for _, r := range op.kafkaRunners {
+		_ = r.Close()
+	}
+	for _, r := range op.redisRunners {
+		_ = r.Close()
+	}
+	for _, r := range op.smtpRunners {
+		_ = r.Close()
+	}
+	for _, r := range op.mockRunners {
+		_ = r.Close()
+	}
 	for _, r := range op.dbRunners {
 		if !force && r.dsn == "" {
 			continue
@@ -226,6 +248,26 @@ func (op *operator) runStep(ctx context.Context, s *step) error {
 				s.sshRunner = r
 				s.sshCommand = s.runnerValues
 			}
+			if r, ok := op.wsRunners[s.runnerKey]; ok {
+				s.wsRunner = r
+				s.wsRequest = s.runnerValues
+			}
+			if r, ok := op.kafkaRunners[s.runnerKey]; ok {
+				s.kafkaRunner = r
+				s.kafkaRequest = s.runnerValues
+			}
+			if r, ok := op.redisRunners[s.runnerKey]; ok {
+				s.redisRunner = r
+				s.redisRequest = s.runnerValues
+			}
+			if r, ok := op.smtpRunners[s.runnerKey]; ok {
+				s.smtpRunner = r
+				s.smtpRequest = s.runnerValues
+			}
+			if r, ok := op.mockRunners[s.runnerKey]; ok {
+				s.mockRunner = r
+				s.mockRequest = s.runnerValues
+			}
 		}
 		switch {
 		case s.httpRunner != nil && s.httpRequest != nil:
@@ -253,6 +295,31 @@ func (op *operator) runStep(ctx context.Context, s *step) error {
 				return fmt.Errorf("ssh command failed on %s: %w", op.stepName(idx), err)
 			}
 			run = true
+		case s.wsRunner != nil && s.wsRequest != nil:
+			if err := s.wsRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("websocket request failed on %s: %w", op.stepName(idx), err)
+			}
+			run = true
+		case s.kafkaRunner != nil && s.kafkaRequest != nil:
+			if err := s.kafkaRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("kafka request failed on %s: %w", op.stepName(idx), err)
+			}
+			run = true
+		case s.redisRunner != nil && s.redisRequest != nil:
+			if err := s.redisRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("redis command failed on %s: %w", op.stepName(idx), err)
+			}
+			run = true
+		case s.smtpRunner != nil && s.smtpRequest != nil:
+			if err := s.smtpRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("smtp request failed on %s: %w", op.stepName(idx), err)
+			}
+			run = true
+		case s.mockRunner != nil && s.mockRequest != nil:
+			if err := s.mockRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("mock request failed on %s: %w", op.stepName(idx), err)
+			}
+			run = true
 		case s.execRunner != nil && s.execCommand != nil:
 			if err := s.execRunner.Run(ctx, s); err != nil {
 				return fmt.Errorf("exec command failed on %s: %w", op.stepName(idx), err)
@@ -455,6 +522,11 @@ func New(opts ...Option) (*operator, error) {
 		grpcRunners:    map[string]*grpcRunner{},
 		cdpRunners:     map[string]*cdpRunner{},
 		sshRunners:     map[string]*sshRunner{},
+		wsRunners:      map[string]*wsRunner{},
+		kafkaRunners:   map[string]*kafkaRunner{},
+		redisRunners:   map[string]*redisRunner{},
+		smtpRunners:    map[string]*smtpRunner{},
+		mockRunners:    map[string]*mockRunner{},
 		includeRunners: map[string]*includeRunner{},
 		deferred:       &deferredOpAndSteps{},
 		store:          st,
@@ -491,6 +563,10 @@ func New(opts ...Option) (*operator, error) {
 	if op.debug {
 		op.capturers = append(op.capturers, NewDebugger(op.stderr))
 	}
+	if bk.fixtures != nil {
+		op.fixture = newFixtureSession(bk.fixtures)
+		op.capturers = append(op.capturers, op.fixture)
+	}
 
 	root, err := bk.generateOperatorRoot()
 	if err != nil {
@@ -541,11 +617,9 @@ func New(opts ...Option) (*operator, error) {
 			}
 		}
 		if len(hostRules) > 0 {
-			tp, ok := v.client.Transport.(*http.Transport)
-			if !ok {
-				return nil, fmt.Errorf("failed to cast: %v", v.client.Transport)
+			if err := v.setDialContext(hostRules.dialContextFunc()); err != nil {
+				return nil, err
 			}
-			tp.DialContext = hostRules.dialContextFunc()
 		}
 		op.httpRunners[k] = v
 	}
@@ -619,6 +693,45 @@ func New(opts ...Option) (*operator, error) {
 		}
 		op.sshRunners[k] = v
 	}
+	for k, v := range bk.wsRunners {
+		if len(hostRules) > 0 {
+			v.hostRules = hostRules
+		}
+		if v.operatorID == "" {
+			v.operatorID = op.id
+		}
+		op.wsRunners[k] = v
+	}
+	for k, v := range bk.kafkaRunners {
+		if len(hostRules) > 0 {
+			v.hostRules = hostRules
+		}
+		if v.operatorID == "" {
+			v.operatorID = op.id
+		}
+		op.kafkaRunners[k] = v
+	}
+	for k, v := range bk.redisRunners {
+		if len(hostRules) > 0 {
+			v.hostRules = hostRules
+		}
+		if v.operatorID == "" {
+			v.operatorID = op.id
+		}
+		op.redisRunners[k] = v
+	}
+	for k, v := range bk.smtpRunners {
+		if v.operatorID == "" {
+			v.operatorID = op.id
+		}
+		op.smtpRunners[k] = v
+	}
+	for k, v := range bk.mockRunners {
+		if v.operatorID == "" {
+			v.operatorID = op.id
+		}
+		op.mockRunners[k] = v
+	}
 	for k, v := range bk.includeRunners {
 		op.includeRunners[k] = v
 	}
@@ -651,6 +764,36 @@ func New(opts ...Option) (*operator, error) {
 		}
 		keys[k] = struct{}{}
 	}
+	for k := range op.wsRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
+	for k := range op.kafkaRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
+	for k := range op.redisRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
+	for k := range op.smtpRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
+	for k := range op.mockRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
 	for k := range op.includeRunners {
 		if _, ok := keys[k]; ok {
 			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
@@ -877,6 +1020,56 @@ func (op *operator) appendStep(idx int, key string, s map[string]any) error {
 				st.sshCommand = vv
 				detected = true
 			}
+			wc, ok := op.wsRunners[k]
+			if ok && !detected {
+				st.wsRunner = wc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid WebSocket request: %v", v)
+				}
+				st.wsRequest = vv
+				detected = true
+			}
+			kc, ok := op.kafkaRunners[k]
+			if ok && !detected {
+				st.kafkaRunner = kc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid Kafka request: %v", v)
+				}
+				st.kafkaRequest = vv
+				detected = true
+			}
+			rc, ok := op.redisRunners[k]
+			if ok && !detected {
+				st.redisRunner = rc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid Redis request: %v", v)
+				}
+				st.redisRequest = vv
+				detected = true
+			}
+			mc, ok := op.smtpRunners[k]
+			if ok && !detected {
+				st.smtpRunner = mc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid SMTP request: %v", v)
+				}
+				st.smtpRequest = vv
+				detected = true
+			}
+			hc, ok := op.mockRunners[k]
+			if ok && !detected {
+				st.mockRunner = hc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid mock request: %v", v)
+				}
+				st.mockRequest = vv
+				detected = true
+			}
 			ic, ok := op.includeRunners[k]
 			if ok && !detected {
 				st.includeRunner = ic
@@ -889,7 +1082,7 @@ func (op *operator) appendStep(idx int, key string, s map[string]any) error {
 
 			if !detected {
 				if !op.hasRunnerRunner {
-					return fmt.Errorf("cannot find client: %s", k)
+					return fmt.Errorf("%w: %s", errClientNotFound, k)
 				}
 				vv, ok := v.(map[string]any)
 				if !ok {
@@ -926,7 +1119,7 @@ func (op *operator) Run(ctx context.Context) (err error) {
 		op.sw.Disable()
 	}
 	opn := op.toOperatorN()
-	result, err := opn.runN(cctx)
+	result, err := opn.runN(cctx, opn.sw)
 	opn.mu.Lock()
 	opn.results = append(opn.results, result)
 	opn.mu.Unlock()
@@ -1177,6 +1370,42 @@ func (op *operator) runInternal(ctx context.Context) (rerr error) {
 		}
 	}()
 
+	// Roll back the transactions kept across steps at the end of the runbook.
+	for _, r := range op.dbRunners {
+		if r.transaction != dbTransactionPerRunbookRollback || r.operatorID != op.id {
+			continue
+		}
+		if err := donegroup.Cleanup(ctx, r.rollbackRunbookTx); err != nil {
+			return err
+		}
+	}
+
+	// Start the SMTP servers for the lifetime of the runbook.
+	for _, r := range op.smtpRunners {
+		if r.operatorID != op.id {
+			continue
+		}
+		if err := r.start(); err != nil {
+			return err
+		}
+		if err := donegroup.Cleanup(ctx, r.Close); err != nil {
+			return err
+		}
+	}
+
+	// Start the mock servers for the lifetime of the runbook.
+	for _, r := range op.mockRunners {
+		if r.operatorID != op.id {
+			continue
+		}
+		if err := r.start(op); err != nil {
+			return err
+		}
+		if err := donegroup.Cleanup(ctx, r.Close); err != nil {
+			return err
+		}
+	}
+
 	// context done
 	select {
 	case <-ctx.Done():
@@ -1325,6 +1554,16 @@ func (op *operator) expandBeforeRecord(in any, s *step) (any, error) {
 	return expr.EvalExpand(in, sm)
 }
 
+// evalBeforeRecord - evaluate before the runner records the result.
+func (op *operator) evalBeforeRecord(in any, s *step) (any, error) {
+	sm := op.store.ToMap()
+	sm[store.RootKeyIncluded] = op.included
+	if !s.deferred {
+		sm[store.RootKeyPrevious] = op.store.Latest()
+	}
+	return expr.EvalAny(in, sm)
+}
+
 // expandCondBeforeRecord - expand condition before the runner records the result.
 func (op *operator) expandCondBeforeRecord(ifCond string, s *step) (bool, error) {
 	sm := op.store.ToMap()
@@ -1440,6 +1679,10 @@ type operatorN struct {
 	kv           *kv.KV
 	dbg          *dbg
 	mu           sync.Mutex
+
+	loadtStartedAt time.Time                       // loadtStartedAt is the time when the load test started.
+	loadtSamples   map[string]*loadtRunbookSamples // loadtSamples holds the elapsed times of runbooks and steps in the load test. key is the runbook ID.
+	loadtCounters  []*loadtCounter                 // loadtCounters is the custom counters of the load test.
 }
 
 func Load(pathp string, opts ...Option) (*operatorN, error) {
@@ -1479,6 +1722,12 @@ func Load(pathp string, opts ...Option) (*operatorN, error) {
 		dbg:          newDBG(bk.attach),
 	}
 	opn.runNIndex.Store(-1) // Set index to -1 ( no runN )
+	for name, cond := range bk.loadtCounters {
+		opn.loadtCounters = append(opn.loadtCounters, &loadtCounter{name: name, cond: cond})
+	}
+	sort.Slice(opn.loadtCounters, func(i, j int) bool {
+		return opn.loadtCounters[i].name < opn.loadtCounters[j].name
+	})
 
 	opn.dbg.setOperatorN(opn) // link back to dbg
 	if bk.runConcurrent {
@@ -1593,7 +1842,7 @@ func (opn *operatorN) RunN(ctx context.Context) (err error) {
 	if !opn.profile {
 		opn.sw.Disable()
 	}
-	result, err := opn.runN(cctx)
+	result, err := opn.runN(cctx, opn.sw)
 	opn.mu.Lock()
 	opn.results = append(opn.results, result)
 	opn.mu.Unlock()
@@ -1628,15 +1877,21 @@ func (opn *operatorN) DumpProfile(w io.Writer) error {
 }
 
 func (opn *operatorN) Init() error {
+	opn.mu.Lock()
+	defer opn.mu.Unlock()
+	opn.loadtStartedAt = time.Now()
+	opn.loadtSamples = map[string]*loadtRunbookSamples{}
+	for _, c := range opn.loadtCounters {
+		c.counted = nil
+	}
 	return nil
 }
 
 func (opn *operatorN) RequestOne(ctx context.Context) error {
-	if !opn.profile {
-		opn.sw.Disable()
-	}
 	ctx = context.WithoutCancel(ctx)
-	result, err := opn.runN(ctx)
+	// Use the stopwatch of each RunN to measure the elapsed times of runbooks and steps because RunNs of the load test run concurrently.
+	result, err := opn.runN(ctx, stopw.New())
+	opn.recordLoadtSamples(result)
 	if err != nil {
 		return err
 	}
@@ -1656,10 +1911,15 @@ func (opn *operatorN) Result() *runNResult {
 }
 
 func (opn *operatorN) SelectedOperators() (tops []*operator, err error) {
+	return opn.selectedOperators(opn.sw)
+}
+
+// selectedOperators returns the operators to run that measure the elapsed time using sw.
+func (opn *operatorN) selectedOperators(sw *stopw.Span) (tops []*operator, err error) {
 	defer func() {
 		selected := &operatorN{
 			ops:          tops,
-			sw:           opn.sw,
+			sw:           sw,
 			om:           opn.om,
 			nm:           opn.nm,
 			skipIncluded: opn.skipIncluded,
@@ -1705,7 +1965,7 @@ func (opn *operatorN) SelectedOperators() (tops []*operator, err error) {
 			return nil, err
 		}
 		for _, op := range rops {
-			op.sw = opn.sw
+			op.sw = sw
 		}
 		return rops, nil
 	}
@@ -1760,17 +2020,17 @@ func (opn *operatorN) Clear() {
 	opn.kv.Clear()
 }
 
-func (opn *operatorN) runN(ctx context.Context) (*runNResult, error) {
+func (opn *operatorN) runN(ctx context.Context, sw *stopw.Span) (*runNResult, error) {
 	result := &runNResult{}
 	if opn.t != nil {
 		opn.t.Helper()
 	}
-	defer opn.sw.Start().Stop()
+	defer sw.Start().Stop()
 	defer opn.Close()
 	runNIndex := opn.runNIndex.Add(1)
 	cg, cctx := concgroup.WithContext(ctx)
 	cg.SetLimit(opn.concmax)
-	selected, err := opn.SelectedOperators()
+	selected, err := opn.selectedOperators(sw)
 	if err != nil {
 		return result, err
 	}
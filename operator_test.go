@@ -958,7 +958,7 @@ func TestShard(t *testing.T) {
 			}
 			sortOperators(got)
 			allow := []any{
-				operator{}, httpRunner{}, dbRunner{}, grpcRunner{}, cdpRunner{}, sshRunner{}, includeRunner{},
+				operator{}, httpRunner{}, dbRunner{}, grpcRunner{}, cdpRunner{}, sshRunner{}, wsRunner{}, kafkaRunner{}, redisRunner{}, smtpRunner{}, mockRunner{}, includeRunner{},
 			}
 			ignore := []any{
 				step{}, store.Store{}, sql.DB{}, os.File{}, stopw.Span{}, debugger{}, nest.DB{}, Loop{}, hostRule{},
@@ -970,8 +970,13 @@ func TestShard(t *testing.T) {
 				cmpopts.IgnoreFields(operator{}, "id", "concurrency", "mu", "dbg", "needs", "nm", "maskRule", "stdout", "stderr", "deferred"),
 				cmpopts.IgnoreFields(cdpRunner{}, "ctx", "cancel", "opts", "mu", "operatorID"),
 				cmpopts.IgnoreFields(sshRunner{}, "client", "sess", "stdin", "stdout", "stderr", "operatorID"),
+				cmpopts.IgnoreFields(wsRunner{}, "conn", "rw", "operatorID"),
+				cmpopts.IgnoreFields(kafkaRunner{}, "client", "operatorID"),
+				cmpopts.IgnoreFields(redisRunner{}, "client", "operatorID"),
+				cmpopts.IgnoreFields(smtpRunner{}, "server", "mu", "messages", "received", "operatorID"),
+				cmpopts.IgnoreFields(mockRunner{}, "server", "vars", "mu", "requests", "received", "operatorID"),
 				cmpopts.IgnoreFields(grpcRunner{}, "mu", "operatorID"),
-				cmpopts.IgnoreFields(dbRunner{}, "operatorID"),
+				cmpopts.IgnoreFields(dbRunner{}, "mu", "runbookTx", "operatorID"),
 				cmpopts.IgnoreFields(RunResult{}, "included", "store"),
 				cmpopts.IgnoreFields(http.Client{}, "Transport"),
 			}
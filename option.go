@@ -85,6 +85,21 @@ func Overlay(path string) Option {
 		for k, r := range loaded.sshRunners {
 			bk.sshRunners[k] = r
 		}
+		for k, r := range loaded.wsRunners {
+			bk.wsRunners[k] = r
+		}
+		for k, r := range loaded.kafkaRunners {
+			bk.kafkaRunners[k] = r
+		}
+		for k, r := range loaded.redisRunners {
+			bk.redisRunners[k] = r
+		}
+		for k, r := range loaded.smtpRunners {
+			bk.smtpRunners[k] = r
+		}
+		for k, r := range loaded.mockRunners {
+			bk.mockRunners[k] = r
+		}
 		for k, v := range loaded.vars {
 			bk.vars[k] = v
 		}
@@ -157,6 +172,31 @@ func Underlay(path string) Option {
 				bk.sshRunners[k] = r
 			}
 		}
+		for k, r := range loaded.wsRunners {
+			if _, ok := bk.wsRunners[k]; !ok {
+				bk.wsRunners[k] = r
+			}
+		}
+		for k, r := range loaded.kafkaRunners {
+			if _, ok := bk.kafkaRunners[k]; !ok {
+				bk.kafkaRunners[k] = r
+			}
+		}
+		for k, r := range loaded.redisRunners {
+			if _, ok := bk.redisRunners[k]; !ok {
+				bk.redisRunners[k] = r
+			}
+		}
+		for k, r := range loaded.smtpRunners {
+			if _, ok := bk.smtpRunners[k]; !ok {
+				bk.smtpRunners[k] = r
+			}
+		}
+		for k, r := range loaded.mockRunners {
+			if _, ok := bk.mockRunners[k]; !ok {
+				bk.mockRunners[k] = r
+			}
+		}
 		for k, v := range loaded.vars {
 			if _, ok := bk.vars[k]; !ok {
 				bk.vars[k] = v
@@ -237,7 +277,15 @@ func Runner(name, dsn string, opts ...httpRunnerOption) Option {
 				return fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
 			}
 		}
-		if c.OpenAPI3DocLocation != "" {
+		if err := r.setTransport(c); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		if err := r.setAuth(c); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		if c.OpenAPI3DocLocation != "" || c.GraphQLSchemaLocation != "" {
 			v, err := newHttpValidator(c)
 			if err != nil {
 				bk.runnerErrs[name] = err
@@ -299,6 +347,12 @@ func HTTPRunner(name, endpoint string, client *http.Client, opts ...httpRunnerOp
 				return err
 			}
 		}
+		if c.GraphQLSchemaLocation != "" {
+			c.GraphQLSchemaLocation, err = fp(c.GraphQLSchemaLocation, root)
+			if err != nil {
+				return err
+			}
+		}
 		if c.CACert != "" {
 			p, err := fp(c.CACert, root)
 			if err != nil {
@@ -339,6 +393,14 @@ func HTTPRunner(name, endpoint string, client *http.Client, opts ...httpRunnerOp
 				return fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
 			}
 		}
+		if err := r.setTransport(c); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		if err := r.setAuth(c); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
 		r.useCookie = c.UseCookie
 		r.trace = c.Trace.Enable
 		r.traceHeaderName = c.Trace.HeaderName
@@ -388,6 +450,14 @@ func HTTPRunnerWithHandler(name string, h http.Handler, opts ...httpRunnerOption
 				bk.runnerErrs[name] = errors.New("runn.HTTPRunnerWithHandler does not support option NotFollowRedirect")
 				return nil
 			}
+			if err := r.setTransport(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+			if err := r.setAuth(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
 			r.multipartBoundary = c.MultipartBoundary
 			if c.Timeout != "" {
 				r.client.Timeout, err = duration.Parse(c.Timeout)
@@ -419,8 +489,9 @@ func DBRunner(name string, client Querier) Option {
 			return err
 		}
 		bk.dbRunners[name] = &dbRunner{
-			name:   name,
-			client: nt,
+			name:        name,
+			client:      nt,
+			transaction: dbTransactionPerStep,
 		}
 		return nil
 	}
@@ -443,6 +514,10 @@ func DBRunnerWithOptions(name, dsn string, opts ...dbRunnerOption) Option {
 				}
 			}
 			r.trace = c.Trace
+			r.transaction, err = parseDBTransactionMode(c.Transaction)
+			if err != nil {
+				return err
+			}
 		}
 		bk.dbRunners[name] = r
 		return nil
@@ -769,6 +844,23 @@ func Profile(enable bool) Option {
 	}
 }
 
+// LoadtCounter - Add a custom counter of the load test that counts the runs of runbooks meeting the condition.
+func LoadtCounter(name, cond string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if name == "" {
+			return errors.New("empty counter name")
+		}
+		if bk.loadtCounters == nil {
+			bk.loadtCounters = map[string]string{}
+		}
+		bk.loadtCounters[name] = cond
+		return nil
+	}
+}
+
 // Interval - Set interval between steps.
 func Interval(d time.Duration) Option {
 	return func(bk *book) error {
@@ -1058,6 +1150,21 @@ func Capture(c Capturer) Option {
 	}
 }
 
+// Fixtures - Record HTTP and gRPC exchanges to the fixture directory, replay them instead of the network, or detect drift from them.
+func Fixtures(dir string, mode FixtureMode) Option {
+	fs, err := newFixtureStore(dir, mode)
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if err != nil {
+			return err
+		}
+		bk.fixtures = fs
+		return nil
+	}
+}
+
 // RunMatch - Run only runbooks with matching paths.
 func RunMatch(m string) Option { //nostyle:repetition
 	return func(bk *book) error {
@@ -1338,6 +1445,96 @@ func included(included bool) Option {
 	}
 }
 
+// WebSocketRunner - Set WebSocket runner to runbook.
+func WebSocketRunner(name, endpoint string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runnerErrs, name)
+		r, err := newWSRunner(name, endpoint)
+		if err != nil {
+			return err
+		}
+		bk.wsRunners[name] = r
+		return nil
+	}
+}
+
+// KafkaRunner - Set Kafka runner to runbook.
+func KafkaRunner(name, dsn string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runnerErrs, name)
+		r, err := newKafkaRunner(name, dsn)
+		if err != nil {
+			return err
+		}
+		bk.kafkaRunners[name] = r
+		return nil
+	}
+}
+
+// RedisRunner - Set Redis runner to runbook.
+func RedisRunner(name, dsn string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runnerErrs, name)
+		r, err := newRedisRunner(name, dsn)
+		if err != nil {
+			return err
+		}
+		bk.redisRunners[name] = r
+		return nil
+	}
+}
+
+// SMTPRunner - Set SMTP runner to runbook.
+func SMTPRunner(name, dsn string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runnerErrs, name)
+		r, err := newSMTPRunner(name, dsn)
+		if err != nil {
+			return err
+		}
+		bk.smtpRunners[name] = r
+		return nil
+	}
+}
+
+// MockRunner - Set mock HTTP server runner to runbook.
+func MockRunner(name, addr string, opts ...mockRunnerOption) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runnerErrs, name)
+		r, err := newMockRunner(name, addr)
+		if err != nil {
+			return err
+		}
+		bk.mockRunners[name] = r
+		c := &mockRunnerConfig{}
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+		}
+		if err := r.addRoutes(c.Routes); err != nil {
+			bk.runnerErrs[name] = err
+		}
+		return nil
+	}
+}
+
 // Books - Load multiple runbooks.
 func Books(pathp string) ([]Option, error) {
 	paths, err := fetchPaths(pathp)
@@ -1391,6 +1588,56 @@ func reuseSSHRunner(name string, r *sshRunner) Option {
 	}
 }
 
+func reuseWSRunner(name string, r *wsRunner) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.wsRunners[name] = r
+		return nil
+	}
+}
+
+func reuseKafkaRunner(name string, r *kafkaRunner) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.kafkaRunners[name] = r
+		return nil
+	}
+}
+
+func reuseRedisRunner(name string, r *redisRunner) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.redisRunners[name] = r
+		return nil
+	}
+}
+
+func reuseSMTPRunner(name string, r *smtpRunner) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.smtpRunners[name] = r
+		return nil
+	}
+}
+
+func reuseMockRunner(name string, r *mockRunner) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.mockRunners[name] = r
+		return nil
+	}
+}
+
 var (
 	AsTestHelper = T
 	Runbook      = Book
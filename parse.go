@@ -1,7 +1,9 @@
 package runn
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"regexp"
 	"strings"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/goccy/go-yaml"
 	"github.com/k1LoW/duration"
+	"github.com/twmb/franz-go/pkg/kgo"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -80,6 +83,22 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 					}
 				}
 			}
+			gm, ok := vvvvv["graphql"]
+			if ok {
+				if _, ok := vvvvv["body"]; ok {
+					return nil, fmt.Errorf("invalid request: body and graphql cannot be specified at the same time: %s", string(part))
+				}
+				if req.method != http.MethodPost {
+					return nil, fmt.Errorf("invalid request: graphql requires POST method: %s", string(part))
+				}
+				body, err := parseGraphQLRequest(gm)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %w: %s", err, string(part))
+				}
+				req.mediaType = MediaTypeApplicationJSON
+				req.body = body
+				req.graphql = true
+			}
 			um, ok := vvvvv["useCookie"]
 			if ok {
 				switch v := um.(type) {
@@ -102,6 +121,27 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 					}
 				}
 			}
+			tom, ok := vvvvv["timeout"]
+			if ok {
+				switch v := tom.(type) {
+				case string:
+					req.timeout, err = duration.Parse(v)
+					if err != nil {
+						return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+					}
+				default:
+					if v != nil {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+				}
+			}
+			sm, ok := vvvvv["stream"]
+			if ok {
+				req.stream, err = parseHTTPStream(sm)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %w: %s", err, string(part))
+				}
+			}
 		}
 
 		break
@@ -112,14 +152,108 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 	return req, nil
 }
 
+// parseGraphQLRequest parses `graphql:` section and returns the JSON body of the GraphQL request.
+func parseGraphQLRequest(v any) (map[string]any, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid graphql: %v", v)
+	}
+	body := map[string]any{}
+	for k, vv := range m {
+		switch k {
+		case graphQLQueryKey:
+			q, ok := vv.(string)
+			if !ok || q == "" {
+				return nil, fmt.Errorf("invalid graphql query: %v", vv)
+			}
+			body[graphQLQueryKey] = q
+		case graphQLVariablesKey:
+			if vv == nil {
+				continue
+			}
+			vars, ok := vv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid graphql variables: %v", vv)
+			}
+			body[graphQLVariablesKey] = vars
+		case graphQLOperationNameKey:
+			if vv == nil {
+				continue
+			}
+			n, ok := vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid graphql operationName: %v", vv)
+			}
+			body[graphQLOperationNameKey] = n
+		default:
+			return nil, fmt.Errorf("invalid graphql key: %s", k)
+		}
+	}
+	if _, ok := body[graphQLQueryKey]; !ok {
+		return nil, errors.New("graphql requires query")
+	}
+	return body, nil
+}
+
+// parseHTTPStream parses `stream:` section of HTTP request.
+func parseHTTPStream(v any) (*httpStream, error) {
+	switch vv := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if !vv {
+			return nil, nil
+		}
+		return &httpStream{}, nil
+	case map[string]any:
+		st := &httpStream{}
+		for k, vvv := range vv {
+			switch k {
+			case "count":
+				c, ok := vvv.(uint64)
+				if !ok || c == 0 || c > math.MaxInt32 {
+					return nil, fmt.Errorf("invalid stream count: %v", vvv)
+				}
+				st.count = int(c)
+			case "timeout":
+				ts, ok := vvv.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid stream timeout: %v", vvv)
+				}
+				var err error
+				st.timeout, err = duration.Parse(ts)
+				if err != nil {
+					return nil, fmt.Errorf("invalid stream timeout: %w", err)
+				}
+			case "until":
+				// `until:` is evaluated for each received event so not here
+				u, ok := vvv.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid stream until: %v", vvv)
+				}
+				st.until = u
+			default:
+				return nil, fmt.Errorf("invalid stream key: %s", k)
+			}
+		}
+		return st, nil
+	default:
+		return nil, fmt.Errorf("invalid stream: %v", v)
+	}
+}
+
 func parseDBQuery(v map[string]any) (*dbQuery, error) {
 	q := &dbQuery{}
 	part, err := yaml.Marshal(v)
 	if err != nil {
 		return nil, err
 	}
-	if len(v) != 1 {
-		return nil, fmt.Errorf("invalid query: %s", string(part))
+	for k := range v {
+		switch k {
+		case "query", "trace", dbParamsKey, dbArgsKey:
+		default:
+			return nil, fmt.Errorf("invalid query: %s", string(part))
+		}
 	}
 	s, ok := v["query"]
 	if !ok {
@@ -141,6 +275,24 @@ func parseDBQuery(v map[string]any) (*dbQuery, error) {
 			}
 		}
 	}
+	pm, ok := v[dbParamsKey]
+	if !ok {
+		pm, ok = v[dbArgsKey]
+	} else if _, ok := v[dbArgsKey]; ok {
+		return nil, fmt.Errorf("invalid query: both %s and %s are specified: %s", dbParamsKey, dbArgsKey, string(part))
+	}
+	if ok {
+		switch v := pm.(type) {
+		case []any:
+			q.args = v
+		case map[string]any:
+			q.namedArgs = v
+		default:
+			if v != nil {
+				return nil, fmt.Errorf("invalid query: invalid params: %s", string(part))
+			}
+		}
+	}
 	return q, nil
 }
 
@@ -379,6 +531,400 @@ func parseSSHCommand(v map[string]any, s *step, expand func(any, *step) (any, er
 	return sc, nil
 }
 
+func parseWebSocketRequest(v map[string]any, s *step, expand func(any, *step) (any, error)) (*wsRequest, error) {
+	v = trimDelimiter(v)
+	req := &wsRequest{
+		headers: http.Header{},
+	}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	for k := range v {
+		if k != "headers" && k != "messages" {
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	hm, ok := v["headers"]
+	if ok {
+		hme, err := expand(hm, s)
+		if err != nil {
+			return nil, err
+		}
+		hm, ok := hme.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+		for k, v := range hm {
+			switch v := v.(type) {
+			case string:
+				req.headers.Add(k, v)
+			case []any:
+				for _, vv := range v {
+					svv, ok := vv.(string)
+					if !ok {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+					req.headers.Add(k, svv)
+				}
+			default:
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+		}
+	}
+	// payloads of `messages:` expand at run time so not here
+	mm, ok := v["messages"]
+	if !ok {
+		return req, nil
+	}
+	ms, ok := mm.(string)
+	if ok {
+		// Only for string, variable expansion is acceptable.
+		mm, err = expand(ms, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	mms, ok := mm.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid request: %s", string(part))
+	}
+	for _, mm := range mms {
+		switch v := mm.(type) {
+		case string:
+			op := WebSocketOp(v)
+			if op != WebSocketOpReceive && op != WebSocketOpClose {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			req.messages = append(req.messages, &wsMessage{
+				op: op,
+			})
+		case map[string]any:
+			if len(v) != 1 {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			for k, vv := range v {
+				m := &wsMessage{
+					op: WebSocketOp(k),
+				}
+				switch m.op {
+				case WebSocketOpText, WebSocketOpBinary, WebSocketOpJSON:
+					m.data = vv
+				case WebSocketOpReceive:
+					if vv == nil {
+						break
+					}
+					rm, ok := vv.(map[string]any)
+					if !ok {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+					for kk, vvv := range rm {
+						switch kk {
+						case "timeout":
+							tme, err := expand(vvv, s)
+							if err != nil {
+								return nil, err
+							}
+							tms, ok := tme.(string)
+							if !ok {
+								return nil, fmt.Errorf("invalid request: %s", string(part))
+							}
+							m.timeout, err = duration.Parse(tms)
+							if err != nil {
+								return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+							}
+						case "match":
+							// `match:` is evaluated for each received message so not here
+							m.match, ok = vvv.(string)
+							if !ok {
+								return nil, fmt.Errorf("invalid request: %s", string(part))
+							}
+						default:
+							return nil, fmt.Errorf("invalid request: %s", string(part))
+						}
+					}
+				case WebSocketOpClose:
+					if vv != nil {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+				default:
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				req.messages = append(req.messages, m)
+			}
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	return req, nil
+}
+
+func parseKafkaRequest(v map[string]any) (*kafkaRequest, error) {
+	v = trimDelimiter(v)
+	req := &kafkaRequest{}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(v) != 1 {
+		return nil, fmt.Errorf("invalid request: %s", string(part))
+	}
+	for k, vv := range v {
+		req.op = KafkaOp(k)
+		m, ok := vv.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+		if t, ok := m["topic"]; ok {
+			req.topic, ok = t.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+		}
+		switch req.op {
+		case KafkaOpProduce:
+			for kk := range m {
+				if kk != "topic" && kk != "messages" {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+			}
+			ms, ok := m["messages"].([]any)
+			if !ok || len(ms) == 0 {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			for _, mm := range ms {
+				mmm, ok := mm.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				pm := &kafkaProduceMessage{
+					headers: map[string]string{},
+				}
+				for kk, vvv := range mmm {
+					switch kk {
+					case "key":
+						pm.key, ok = vvv.(string)
+						if !ok {
+							return nil, fmt.Errorf("invalid request: %s", string(part))
+						}
+					case "headers":
+						hm, ok := vvv.(map[string]any)
+						if !ok {
+							return nil, fmt.Errorf("invalid request: %s", string(part))
+						}
+						for hk, hv := range hm {
+							hs, ok := hv.(string)
+							if !ok {
+								return nil, fmt.Errorf("invalid request: %s", string(part))
+							}
+							pm.headers[hk] = hs
+						}
+					case "value":
+						pm.value = vvv
+					default:
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+				}
+				req.messages = append(req.messages, pm)
+			}
+		case KafkaOpConsume:
+			req.offset = kgo.NewOffset().AtStart()
+			for kk, vvv := range m {
+				switch kk {
+				case "topic":
+				case "partition":
+					p, ok := vvv.(uint64)
+					if !ok || p > math.MaxInt32 {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+					pp := int32(p) //nolint:gosec
+					req.partition = &pp
+				case "offset":
+					switch o := vvv.(type) {
+					case string:
+						switch o {
+						case kafkaOffsetStart:
+							req.offset = kgo.NewOffset().AtStart()
+						case kafkaOffsetEnd:
+							req.offset = kgo.NewOffset().AtEnd()
+						default:
+							return nil, fmt.Errorf("invalid request: %s", string(part))
+						}
+					case uint64:
+						if o > math.MaxInt64 {
+							return nil, fmt.Errorf("invalid request: %s", string(part))
+						}
+						req.offset = kgo.NewOffset().At(int64(o)) //nolint:gosec
+					default:
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+				case "timeout":
+					ts, ok := vvv.(string)
+					if !ok {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+					req.timeout, err = duration.Parse(ts)
+					if err != nil {
+						return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+					}
+				case "count":
+					c, ok := vvv.(uint64)
+					if !ok || c == 0 || c > math.MaxInt32 {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+					req.count = int(c)
+				case "match":
+					// `match:` is evaluated for each consumed message so not here
+					req.match, ok = vvv.(string)
+					if !ok {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+				default:
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	return req, nil
+}
+
+func parseRedisRequest(v map[string]any) (*redisRequest, error) {
+	v = trimDelimiter(v)
+	req := &redisRequest{}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(v) != 1 {
+		return nil, fmt.Errorf("invalid request: %s", string(part))
+	}
+	for k, vv := range v {
+		switch k {
+		case "command":
+			c, err := parseRedisCommand(vv)
+			if err != nil {
+				return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+			}
+			req.commands = append(req.commands, c)
+		case "pipeline":
+			cs, ok := vv.([]any)
+			if !ok || len(cs) == 0 {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			for _, cc := range cs {
+				c, err := parseRedisCommand(cc)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+				req.commands = append(req.commands, c)
+			}
+			req.pipeline = true
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	return req, nil
+}
+
+// parseRedisCommand parses a Redis command given as an array ( e.g. [SET, key, value] ).
+func parseRedisCommand(v any) ([]any, error) {
+	c, ok := v.([]any)
+	if !ok || len(c) == 0 {
+		return nil, fmt.Errorf("command must be a non-empty array: %v", v)
+	}
+	if _, ok := c[0].(string); !ok {
+		return nil, fmt.Errorf("command name must be a string: %v", c[0])
+	}
+	for _, a := range c {
+		switch a.(type) {
+		case string, uint64, int64, int, float64, bool:
+		default:
+			return nil, fmt.Errorf("invalid argument: %v", a)
+		}
+	}
+	return c, nil
+}
+
+func parseSMTPRequest(v map[string]any) (*smtpRequest, error) {
+	v = trimDelimiter(v)
+	req := &smtpRequest{}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	for k, vv := range v {
+		switch k {
+		case "to":
+			req.to, err = parseSMTPRequestString(vv)
+		case "subject":
+			req.subject, err = parseSMTPRequestString(vv)
+		case "match":
+			// `match:` is evaluated for each received message so not here
+			req.match, err = parseSMTPRequestString(vv)
+		case "timeout":
+			var ts string
+			ts, err = parseSMTPRequestString(vv)
+			if err == nil {
+				req.timeout, err = duration.Parse(ts)
+			}
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+		}
+	}
+	return req, nil
+}
+
+func parseSMTPRequestString(v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("not a string: %v", v)
+	}
+	return s, nil
+}
+
+func parseMockRequest(v map[string]any) (*mockRequest, error) {
+	v = trimDelimiter(v)
+	req := &mockRequest{}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	for k, vv := range v {
+		switch k {
+		case "count":
+			c, ok := vv.(uint64)
+			if !ok || c > math.MaxInt32 {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			req.count = int(c)
+		case "match":
+			// `match:` is evaluated for each received request so not here
+			m, ok := vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			req.match = m
+		case "timeout":
+			ts, ok := vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			req.timeout, err = duration.Parse(ts)
+			if err != nil {
+				return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+			}
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	return req, nil
+}
+
 func parseServiceAndMethod(in string) (string, string, error) {
 	splitted := strings.Split(strings.TrimPrefix(in, "/"), "/")
 	if len(splitted) < 2 {
@@ -8,6 +8,7 @@ import (
 	"github.com/goccy/go-yaml"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/twmb/franz-go/pkg/kgo"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -156,6 +157,150 @@ func TestParseHTTPRequest(t *testing.T) {
     body: null
     useCookie: true
     trace: "true"
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/graphql:
+  post:
+    graphql:
+      query: |
+        query GetUser($id: ID!) { user(id: $id) { name } }
+      variables:
+        id: "1"
+      operationName: GetUser
+`,
+			&httpRequest{
+				path:      "/graphql",
+				method:    http.MethodPost,
+				mediaType: MediaTypeApplicationJSON,
+				headers:   http.Header{},
+				body: map[string]any{
+					"query":         "query GetUser($id: ID!) { user(id: $id) { name } }\n",
+					"variables":     map[string]any{"id": "1"},
+					"operationName": "GetUser",
+				},
+				graphql: true,
+			},
+			false,
+		},
+		{
+			`
+/graphql:
+  post:
+    graphql:
+      query: "{ users { name } }"
+`,
+			&httpRequest{
+				path:      "/graphql",
+				method:    http.MethodPost,
+				mediaType: MediaTypeApplicationJSON,
+				headers:   http.Header{},
+				body: map[string]any{
+					"query": "{ users { name } }",
+				},
+				graphql: true,
+			},
+			false,
+		},
+		{
+			`
+/graphql:
+  post:
+    graphql:
+      variables:
+        id: "1"
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/graphql:
+  post:
+    body:
+      application/json:
+        key: value
+    graphql:
+      query: "{ users { name } }"
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/graphql:
+  get:
+    graphql:
+      query: "{ users { name } }"
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/graphql:
+  post:
+    graphql:
+      query: "{ users { name } }"
+      extensions: {}
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/events:
+  get:
+    stream:
+      count: 3
+      timeout: 10sec
+      until: current.event == "done"
+`,
+			&httpRequest{
+				path:    "/events",
+				method:  http.MethodGet,
+				headers: http.Header{},
+				stream: &httpStream{
+					count:   3,
+					timeout: 10 * time.Second,
+					until:   `current.event == "done"`,
+				},
+			},
+			false,
+		},
+		{
+			`
+/events:
+  get:
+    stream: true
+`,
+			&httpRequest{
+				path:    "/events",
+				method:  http.MethodGet,
+				headers: http.Header{},
+				stream:  &httpStream{},
+			},
+			false,
+		},
+		{
+			`
+/events:
+  get:
+    stream:
+      count: 0
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/events:
+  get:
+    stream:
+      match: current.event == "done"
 `,
 			nil,
 			true,
@@ -177,7 +322,7 @@ func TestParseHTTPRequest(t *testing.T) {
 		if tt.wantErr {
 			t.Error("want error")
 		}
-		opts := cmp.AllowUnexported(httpRequest{})
+		opts := cmp.AllowUnexported(httpRequest{}, httpStream{})
 		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
 			t.Error(diff)
 		}
@@ -209,6 +354,58 @@ query: |
 			},
 			false,
 		},
+		{
+			`
+query: SELECT * FROM users WHERE id = ? AND username = ?;
+params:
+  - 1
+  - alice
+`,
+			&dbQuery{
+				stmt: "SELECT * FROM users WHERE id = ? AND username = ?;",
+				args: []any{uint64(1), "alice"},
+			},
+			false,
+		},
+		{
+			`
+query: SELECT * FROM users WHERE id = :id;
+args:
+  id: 1
+`,
+			&dbQuery{
+				stmt:      "SELECT * FROM users WHERE id = :id;",
+				namedArgs: map[string]any{"id": uint64(1)},
+			},
+			false,
+		},
+		{
+			`
+query: SELECT * FROM users WHERE id = ?;
+params:
+  - 1
+args:
+  - 1
+`,
+			nil,
+			true,
+		},
+		{
+			`
+query: SELECT * FROM users WHERE id = ?;
+params: 1
+`,
+			nil,
+			true,
+		},
+		{
+			`
+query: SELECT * FROM users;
+unknown: true
+`,
+			nil,
+			true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -463,6 +660,349 @@ stdin: |
 	}
 }
 
+func TestParseKafkaRequest(t *testing.T) {
+	partition := int32(0)
+	tests := []struct {
+		in      string
+		want    *kafkaRequest
+		wantErr bool
+	}{
+		{
+			`
+produce:
+  messages:
+    -
+      key: user-1
+      headers:
+        content-type: application/json
+      value:
+        type: created
+`,
+			&kafkaRequest{
+				op: KafkaOpProduce,
+				messages: []*kafkaProduceMessage{
+					{
+						key:     "user-1",
+						headers: map[string]string{"content-type": "application/json"},
+						value:   map[string]any{"type": "created"},
+					},
+				},
+			},
+			false,
+		},
+		{
+			`
+consume:
+  topic: events
+`,
+			&kafkaRequest{
+				op:     KafkaOpConsume,
+				topic:  "events",
+				offset: kgo.NewOffset().AtStart(),
+			},
+			false,
+		},
+		{
+			`
+consume:
+  partition: 0
+  offset: 3
+  timeout: 10sec
+  count: 2
+  match: current.key == "user-1"
+`,
+			&kafkaRequest{
+				op:        KafkaOpConsume,
+				partition: &partition,
+				offset:    kgo.NewOffset().At(3),
+				timeout:   10 * time.Second,
+				count:     2,
+				match:     `current.key == "user-1"`,
+			},
+			false,
+		},
+		{
+			`
+produce:
+  messages: []
+`,
+			nil,
+			true,
+		},
+		{
+			`
+consume:
+  offset: latest
+`,
+			nil,
+			true,
+		},
+		{
+			`
+produce:
+  messages:
+    -
+      value: hello
+consume:
+  topic: events
+`,
+			nil,
+			true,
+		},
+		{
+			`
+delete:
+  topic: events
+`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		var v map[string]any
+		if err := yaml.Unmarshal([]byte(tt.in), &v); err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseKafkaRequest(v)
+		if err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+		}
+		opts := cmp.AllowUnexported(kafkaRequest{}, kafkaProduceMessage{}, kgo.Offset{})
+		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestParseRedisRequest(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *redisRequest
+		wantErr bool
+	}{
+		{
+			`
+command: [SET, key, value]
+`,
+			&redisRequest{
+				commands: [][]any{{"SET", "key", "value"}},
+			},
+			false,
+		},
+		{
+			`
+pipeline:
+  - [INCR, counter]
+  - [EXPIRE, counter, 10]
+`,
+			&redisRequest{
+				commands: [][]any{{"INCR", "counter"}, {"EXPIRE", "counter", uint64(10)}},
+				pipeline: true,
+			},
+			false,
+		},
+		{
+			`
+command: []
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command: SET key value
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command: [SET, key, {nested: value}]
+`,
+			nil,
+			true,
+		},
+		{
+			`
+pipeline: []
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command: [GET, key]
+pipeline:
+  - [GET, key]
+`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		var v map[string]any
+		if err := yaml.Unmarshal([]byte(tt.in), &v); err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseRedisRequest(v)
+		if err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+		}
+		opts := cmp.AllowUnexported(redisRequest{})
+		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestParseSMTPRequest(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *smtpRequest
+		wantErr bool
+	}{
+		{
+			`{}`,
+			&smtpRequest{},
+			false,
+		},
+		{
+			`
+to: alice@example.com
+subject: Reset
+match: current.links[0] startsWith "https://"
+timeout: 30sec
+`,
+			&smtpRequest{
+				to:      "alice@example.com",
+				subject: "Reset",
+				match:   `current.links[0] startsWith "https://"`,
+				timeout: 30 * time.Second,
+			},
+			false,
+		},
+		{
+			`
+to:
+  - alice@example.com
+`,
+			nil,
+			true,
+		},
+		{
+			`
+timeout: invalid
+`,
+			nil,
+			true,
+		},
+		{
+			`
+from: noreply@example.com
+`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		var v map[string]any
+		if err := yaml.Unmarshal([]byte(tt.in), &v); err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseSMTPRequest(v)
+		if err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+		}
+		opts := cmp.AllowUnexported(smtpRequest{})
+		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestParseMockRequest(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *mockRequest
+		wantErr bool
+	}{
+		{
+			`{}`,
+			&mockRequest{},
+			false,
+		},
+		{
+			`
+count: 2
+match: current.path == "/users"
+timeout: 3sec
+`,
+			&mockRequest{
+				count:   2,
+				match:   `current.path == "/users"`,
+				timeout: 3 * time.Second,
+			},
+			false,
+		},
+		{
+			`
+count: -1
+`,
+			nil,
+			true,
+		},
+		{
+			`
+path: /users
+`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		var v map[string]any
+		if err := yaml.Unmarshal([]byte(tt.in), &v); err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseMockRequest(v)
+		if err != nil {
+			if !tt.wantErr {
+				t.Error(err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+		}
+		opts := cmp.AllowUnexported(mockRequest{})
+		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
 func TestTrimDelimiter(t *testing.T) {
 	tests := []struct {
 		in   map[string]any
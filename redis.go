@@ -0,0 +1,159 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/k1LoW/donegroup"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisStoreResultKey   = "result"
+	redisStoreResultsKey  = "results"
+	redisStoreResponseKey = "res"
+)
+
+type redisRunner struct {
+	name      string
+	dsn       string
+	client    *redis.Client
+	hostRules hostRules
+	// operatorID - The id of the operator for which the runner is defined.
+	operatorID string
+}
+
+type redisRequest struct {
+	commands [][]any
+	pipeline bool
+}
+
+func newRedisRunner(name, dsn string) (*redisRunner, error) {
+	if _, err := redis.ParseURL(dsn); err != nil {
+		return nil, fmt.Errorf("invalid Redis DSN: %w", err)
+	}
+	return &redisRunner{
+		name: name,
+		dsn:  dsn,
+	}, nil
+}
+
+func (rnr *redisRunner) Close() error {
+	if rnr.client == nil {
+		return nil
+	}
+	err := rnr.client.Close()
+	rnr.client = nil
+	return err
+}
+
+func (rnr *redisRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	e, err := o.expandBeforeRecord(s.redisRequest, s)
+	if err != nil {
+		return err
+	}
+	v, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid Redis request: %v", e)
+	}
+	req, err := parseRedisRequest(v)
+	if err != nil {
+		return fmt.Errorf("invalid Redis request: %w", err)
+	}
+	if err := rnr.run(ctx, req, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *redisRunner) run(ctx context.Context, r *redisRequest, s *step) error {
+	o := s.parent
+	if rnr.client == nil {
+		if len(rnr.hostRules) > 0 {
+			rnr.dsn = rnr.hostRules.replaceDSN(rnr.dsn)
+		}
+		opt, err := redis.ParseURL(rnr.dsn)
+		if err != nil {
+			return err
+		}
+		rnr.client = redis.NewClient(opt)
+		if err := donegroup.Cleanup(ctx, func() error {
+			// In the case of Reused runners, leave the cleanup to the main cleanup
+			if o.id != rnr.operatorID {
+				return nil
+			}
+			return rnr.Close()
+		}); err != nil {
+			return err
+		}
+	}
+
+	var cmds []*redis.Cmd
+	if r.pipeline {
+		pipe := rnr.client.Pipeline()
+		for _, c := range r.commands {
+			o.capturers.captureRedisCommand(rnr.name, c)
+			cmds = append(cmds, pipe.Do(ctx, c...))
+		}
+		// Errors are checked for each command below.
+		_, _ = pipe.Exec(ctx)
+	} else {
+		for _, c := range r.commands {
+			o.capturers.captureRedisCommand(rnr.name, c)
+			cmds = append(cmds, rnr.client.Do(ctx, c...))
+		}
+	}
+
+	var results []any
+	for _, cmd := range cmds {
+		v, err := cmd.Result()
+		if err != nil {
+			if !errors.Is(err, redis.Nil) {
+				return fmt.Errorf("%v: %w", cmd.Args(), err)
+			}
+			v = nil
+		}
+		v = redisValue(v)
+		o.capturers.captureRedisResult(rnr.name, v)
+		results = append(results, v)
+	}
+
+	d := map[string]any{}
+	if r.pipeline {
+		d[redisStoreResultsKey] = results
+	} else {
+		d[redisStoreResultKey] = results[0]
+	}
+	o.record(s.idx, map[string]any{
+		redisStoreResponseKey: d,
+	})
+	return nil
+}
+
+// redisValue converts the result of Redis command into a value that can be handled in the store.
+func redisValue(v any) any {
+	switch vv := v.(type) {
+	case []any:
+		s := make([]any, 0, len(vv))
+		for _, e := range vv {
+			s = append(s, redisValue(e))
+		}
+		return s
+	case map[any]any:
+		m := map[string]any{}
+		for k, e := range vv {
+			m[fmt.Sprintf("%v", k)] = redisValue(e)
+		}
+		return m
+	case map[string]string:
+		m := map[string]any{}
+		for k, e := range vv {
+			m[k] = e
+		}
+		return m
+	default:
+		return vv
+	}
+}
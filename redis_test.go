@@ -0,0 +1,154 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestRedisRunner(t *testing.T) {
+	ctx := context.Background()
+	dsn := testutil.RedisServer(t)
+	o, err := New(Book("testdata/book/redis.yml"), RedisRunner("cache", dsn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRedisRunnerWithHostRules(t *testing.T) {
+	ctx := context.Background()
+	dsn := testutil.RedisServer(t)
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := New(Book("testdata/book/redis.yml"), HostRules(fmt.Sprintf("redis.example.com %s", u.Host)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRedisRunnerRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     map[string]any
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			"string",
+			map[string]any{"command": []any{"GET", "str"}},
+			map[string]any{"result": "value"},
+			false,
+		},
+		{
+			"int",
+			map[string]any{"command": []any{"INCR", "counter"}},
+			map[string]any{"result": int64(1)},
+			false,
+		},
+		{
+			"nil",
+			map[string]any{"command": []any{"GET", "missing"}},
+			map[string]any{"result": nil},
+			false,
+		},
+		{
+			"array",
+			map[string]any{"command": []any{"LRANGE", "list", uint64(0), int64(-1)}},
+			map[string]any{"result": []any{"a", "b"}},
+			false,
+		},
+		{
+			"map",
+			map[string]any{"command": []any{"HGETALL", "hash"}},
+			map[string]any{"result": map[string]any{"name": "alice"}},
+			false,
+		},
+		{
+			"pipeline",
+			map[string]any{"pipeline": []any{
+				[]any{"SET", "key", "v"},
+				[]any{"GET", "key"},
+				[]any{"GET", "missing"},
+			}},
+			map[string]any{"results": []any{"OK", "v", nil}},
+			false,
+		},
+		{
+			"error",
+			map[string]any{"command": []any{"INCR", "str"}},
+			nil,
+			true,
+		},
+		{
+			"error in pipeline",
+			map[string]any{"pipeline": []any{
+				[]any{"SET", "key", "v"},
+				[]any{"INCR", "str"},
+			}},
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			dsn := testutil.RedisServer(t)
+			o, err := New(RedisRunner("cache", dsn))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := o.redisRunners["cache"]
+			ps := newStep(0, "setup", o, nil)
+			setup := &redisRequest{
+				commands: [][]any{
+					{"SET", "str", "value"},
+					{"RPUSH", "list", "a", "b"},
+					{"HSET", "hash", "name", "alice"},
+				},
+			}
+			if err := r.run(ctx, setup, ps); err != nil {
+				t.Fatal(err)
+			}
+			s := newStep(1, "stepKey", o, nil)
+			req, err := parseRedisRequest(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.run(ctx, req, s); err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			sm := o.store.ToMap()
+			sl, ok := sm["steps"].([]map[string]any)
+			if !ok {
+				t.Fatal("steps not found")
+			}
+			got, ok := sl[1]["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", sl[1])
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
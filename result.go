@@ -2,6 +2,7 @@ package runn
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
@@ -102,7 +103,6 @@ func (r *runNResult) HasFailure() bool {
 }
 
 func (r *runNResult) Out(out io.Writer) error {
-	var ts, fs string
 	_, _ = fmt.Fprintln(out, "")
 	if r.HasFailure() {
 		_, _ = fmt.Fprintln(out, "")
@@ -116,7 +116,31 @@ func (r *runNResult) Out(out io.Writer) error {
 		}
 	}
 	_, _ = fmt.Fprintln(out, "")
+	return r.outTotals(out)
+}
 
+// OutSummary outputs the compact summary of the results: the result of each runbook and the totals.
+func (r *runNResult) OutSummary(out io.Writer) error {
+	rs := r.simplify()
+	for _, rr := range rs.Results {
+		var mark string
+		switch rr.Result {
+		case resultFailure:
+			mark = red("FAIL")
+		case resultSkipped:
+			mark = yellow("SKIP")
+		default:
+			mark = green("PASS")
+		}
+		if _, err := fmt.Fprintf(out, "%s %s %s\n", mark, normalizePath(rr.Path), cyan(rr.ID)); err != nil {
+			return err
+		}
+	}
+	return r.outTotals(out)
+}
+
+func (r *runNResult) outTotals(out io.Writer) error {
+	var ts, fs string
 	rs := r.simplify()
 	if rs.Total == 1 {
 		ts = fmt.Sprintf("%d scenario", rs.Total)
@@ -156,6 +180,51 @@ func (r *runNResult) OutJSON(out io.Writer) error {
 	return nil
 }
 
+func (r *runNResult) OutJUnit(out io.Writer) error {
+	s := r.simplify()
+	suites := &junitTestSuites{
+		Name: "runn",
+		Time: junitTime(s.Elapsed),
+	}
+	for i, rs := range s.Results {
+		ts := newJUnitTestSuite(r.RunResults[i], rs)
+		suites.Tests += ts.Tests
+		suites.Failures += ts.Failures
+		suites.Skipped += ts.Skipped
+		suites.TestSuites = append(suites.TestSuites, ts)
+	}
+	b, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(out, xml.Header); err != nil {
+		return err
+	}
+	if _, err := out.Write(b); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(out, "\n"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *runNResult) OutTAP(out io.Writer) error {
+	s := r.simplify()
+	if _, err := fmt.Fprintln(out, "TAP version 14"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(out, "1..%d\n", len(s.Results)); err != nil {
+		return err
+	}
+	for i, rs := range s.Results {
+		if err := outTAPRunResult(out, r.RunResults[i], rs, i+1, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (rr *RunResult) OutFailure(out io.Writer) error {
 	_, err := rr.outFailure(out, 1)
 	return err
@@ -327,6 +396,201 @@ func simplifyStepResults(stepResults []*StepResult) []*stepResultSimplified {
 	return simplified
 }
 
+type junitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Name       string            `xml:"name,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Skipped    int               `xml:"skipped,attr"`
+	Time       string            `xml:"time,attr"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string            `xml:"name,attr"`
+	ID         string            `xml:"id,attr,omitempty"`
+	File       string            `xml:"file,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Skipped    int               `xml:"skipped,attr"`
+	Time       string            `xml:"time,attr"`
+	TestCases  []*junitTestCase  `xml:"testcase"`
+	TestSuites []*junitTestSuite `xml:"testsuite,omitempty"` // Test suites of runbooks loaded by include runner
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	ID        string        `xml:"id,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct{}
+
+// newJUnitTestSuite converts a runbook result to a test suite. Each step becomes a test case.
+func newJUnitTestSuite(rr *RunResult, rs *runResultSimplified) *junitTestSuite {
+	name := rs.Path
+	if rr.Desc != "" {
+		name = rr.Desc
+	}
+	ts := &junitTestSuite{
+		Name: name,
+		ID:   rs.ID,
+		File: rs.Path,
+		Time: junitTime(rs.Elapsed),
+	}
+	stepFailed := false
+	for i, ss := range rs.Steps {
+		sr := rr.StepResults[i]
+		tc := &junitTestCase{
+			Name:      stepResultName(sr),
+			Classname: rs.Path,
+			ID:        ss.ID,
+			Time:      junitTime(ss.Elapsed),
+		}
+		switch ss.Result {
+		case resultFailure:
+			tc.Failure = newJUnitFailure(sr.Err)
+			ts.Failures++
+			stepFailed = true
+		case resultSkipped:
+			tc.Skipped = &junitSkipped{}
+			ts.Skipped++
+		}
+		ts.Tests++
+		ts.TestCases = append(ts.TestCases, tc)
+		for ii, irs := range ss.IncludedRunResults {
+			its := newJUnitTestSuite(sr.IncludedRunResults[ii], irs)
+			ts.Tests += its.Tests
+			ts.Failures += its.Failures
+			ts.Skipped += its.Skipped
+			ts.TestSuites = append(ts.TestSuites, its)
+		}
+	}
+	if rs.Result == resultFailure && !stepFailed {
+		// The runbook failed outside of the steps (e.g. `needs:`, beforeFunc).
+		ts.Tests++
+		ts.Failures++
+		ts.TestCases = append(ts.TestCases, &junitTestCase{
+			Name:      rs.Path,
+			Classname: rs.Path,
+			ID:        rs.ID,
+			Time:      junitTime(rs.Elapsed),
+			Failure:   newJUnitFailure(rr.Err),
+		})
+	}
+	return ts
+}
+
+func newJUnitFailure(err error) *junitFailure {
+	f := &junitFailure{
+		Type: string(resultFailure),
+	}
+	if err == nil {
+		return f
+	}
+	f.Text = strings.TrimRight(err.Error(), "\n")
+	f.Message, _, _ = strings.Cut(f.Text, "\n")
+	return f
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// outTAPRunResult writes a runbook result as a TAP subtest. Each step becomes a test point of the subtest.
+func outTAPRunResult(out io.Writer, rr *RunResult, rs *runResultSimplified, n int, indent string) error {
+	const subindent = "    "
+	if _, err := fmt.Fprintf(out, "%s# Subtest: %s\n", indent, rs.Path); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(out, "%s%s1..%d\n", indent, subindent, len(rs.Steps)); err != nil {
+		return err
+	}
+	stepFailed := false
+	for i, ss := range rs.Steps {
+		sr := rr.StepResults[i]
+		if len(ss.IncludedRunResults) > 0 {
+			if _, err := fmt.Fprintf(out, "%s%s# Subtest: %s\n", indent, subindent, stepResultName(sr)); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(out, "%s%s%s1..%d\n", indent, subindent, subindent, len(ss.IncludedRunResults)); err != nil {
+				return err
+			}
+			for ii, irs := range ss.IncludedRunResults {
+				if err := outTAPRunResult(out, sr.IncludedRunResults[ii], irs, ii+1, indent+subindent+subindent); err != nil {
+					return err
+				}
+			}
+		}
+		if ss.Result == resultFailure {
+			stepFailed = true
+		}
+		if err := outTAPTestPoint(out, indent+subindent, i+1, stepResultName(sr), ss.Result, sr.Err, ss.Elapsed); err != nil {
+			return err
+		}
+	}
+	var err error
+	if !stepFailed {
+		err = rr.Err
+	}
+	return outTAPTestPoint(out, indent, n, rs.Path, rs.Result, err, rs.Elapsed)
+}
+
+func outTAPTestPoint(out io.Writer, indent string, n int, desc string, r result, ferr error, elapsed time.Duration) error {
+	var line string
+	switch r {
+	case resultFailure:
+		line = fmt.Sprintf("%snot ok %d - %s", indent, n, desc)
+	case resultSkipped:
+		line = fmt.Sprintf("%sok %d - %s # SKIP", indent, n, desc)
+	default:
+		line = fmt.Sprintf("%sok %d - %s", indent, n, desc)
+	}
+	if _, err := fmt.Fprintln(out, line); err != nil {
+		return err
+	}
+	if ferr == nil && elapsed == 0 {
+		return nil
+	}
+	// YAML diagnostic block
+	if _, err := fmt.Fprintf(out, "%s  ---\n", indent); err != nil {
+		return err
+	}
+	if ferr != nil {
+		if _, err := fmt.Fprintf(out, "%s  message: |\n", indent); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(out, sprintMultilinef(indent+"    %s\n", "%s", strings.TrimRight(ferr.Error(), "\n"))); err != nil {
+			return err
+		}
+	}
+	if elapsed > 0 {
+		if _, err := fmt.Fprintf(out, "%s  duration_ms: %.3f\n", indent, float64(elapsed)/float64(time.Millisecond)); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(out, "%s  ...\n", indent); err != nil {
+		return err
+	}
+	return nil
+}
+
+func stepResultName(sr *StepResult) string {
+	if sr.Desc == "" {
+		return sr.Key
+	}
+	return fmt.Sprintf("%s (%s)", sr.Key, sr.Desc)
+}
+
 func sprintMultilinef(lineformat, format string, a ...any) string {
 	lines := strings.Split(fmt.Sprintf(format, a...), "\n")
 	var formatted string
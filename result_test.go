@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/tenntenn/golden"
 )
@@ -258,3 +259,182 @@ func TestResultElasped(t *testing.T) {
 		})
 	}
 }
+
+func TestResultOutJUnit(t *testing.T) {
+	tests := []struct {
+		r *runNResult
+	}{
+		{newRunNResult(t, 3, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Desc:        "Success",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Elapsed: 1500 * time.Millisecond}},
+				Elapsed:     2 * time.Second,
+			},
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/runn_1_fail.yml",
+				Err:  errDummy,
+				StepResults: []*StepResult{
+					{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Desc: "Login", Err: errDummy},
+					{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=1", Key: "1", Err: nil, Skipped: true},
+				},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_3.skip.yml",
+				Err:         nil,
+				Skipped:     true,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Skipped: true}},
+			},
+		})},
+		{newRunNResult(t, 2, []*RunResult{
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/runn_1_fail.yml",
+				Err:  errDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: errDummy, IncludedRunResults: []*RunResult{{
+					ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0",
+					Path:        "testdata/book/runn_included_0_fail.yml",
+					Err:         errDummy,
+					StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0&step=0", Key: "0", Err: errDummy}},
+				}}}},
+			},
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/always_failure.yml",
+				Err:  errDummy,
+			},
+		})},
+	}
+	for i, tt := range tests {
+		key := fmt.Sprintf("result_out_junit_%d", i)
+		t.Run(key, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := tt.r.OutJUnit(buf); err != nil {
+				t.Error(err)
+			}
+			got := buf.String()
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", key, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", key, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestResultOutTAP(t *testing.T) {
+	tests := []struct {
+		r *runNResult
+	}{
+		{newRunNResult(t, 3, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Desc:        "Success",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Elapsed: 1500 * time.Millisecond}},
+				Elapsed:     2 * time.Second,
+			},
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/runn_1_fail.yml",
+				Err:  errDummy,
+				StepResults: []*StepResult{
+					{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Desc: "Login", Err: errDummy},
+					{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=1", Key: "1", Err: nil, Skipped: true},
+				},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_3.skip.yml",
+				Err:         nil,
+				Skipped:     true,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Skipped: true}},
+			},
+		})},
+		{newRunNResult(t, 2, []*RunResult{
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/runn_1_fail.yml",
+				Err:  errDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: errDummy, IncludedRunResults: []*RunResult{{
+					ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0",
+					Path:        "testdata/book/runn_included_0_fail.yml",
+					Err:         errDummy,
+					StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0&step=0", Key: "0", Err: errDummy}},
+				}}}},
+			},
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/always_failure.yml",
+				Err:  errDummy,
+			},
+		})},
+	}
+	for i, tt := range tests {
+		key := fmt.Sprintf("result_out_tap_%d", i)
+		t.Run(key, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := tt.r.OutTAP(buf); err != nil {
+				t.Error(err)
+			}
+			got := buf.String()
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", key, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", key, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestResultOutSummary(t *testing.T) {
+	noColor(t)
+	tests := []struct {
+		r *runNResult
+	}{
+		{newRunNResult(t, 3, []*RunResult{
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/runn_0_success.yml",
+				Err:  nil,
+			},
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/runn_1_fail.yml",
+				Err:  errDummy,
+			},
+			{
+				ID:      "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:    "testdata/book/runn_3.skip.yml",
+				Err:     nil,
+				Skipped: true,
+			},
+		})},
+	}
+	for i, tt := range tests {
+		key := fmt.Sprintf("result_out_summary_%d", i)
+		t.Run(key, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := tt.r.OutSummary(buf); err != nil {
+				t.Error(err)
+			}
+			got := buf.String()
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", key, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", key, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
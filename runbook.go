@@ -142,7 +142,9 @@ func parseRunbookAsMapped(b []byte, rb *runbook) error {
 	rb.Desc = m.Desc
 	rb.Labels = m.Labels
 	rb.Needs = m.Needs
-	rb.Runners = m.Runners
+	if m.Runners != nil {
+		rb.Runners = m.Runners
+	}
 	rb.Vars = m.Vars
 	rb.Secrets = m.Secrets
 	rb.HostRules = m.HostRules
@@ -180,23 +182,49 @@ func (rb *runbook) AppendStep(in ...string) error {
 	if len(in) == 0 {
 		return errors.New("no argument")
 	}
-	if rb.useMap {
-		key := fmt.Sprintf("%s%d", in[0], len(rb.stepKeys))
-		rb.stepKeys = append(rb.stepKeys, key)
-	}
+	var err error
 	switch {
 	case strings.HasPrefix(in[0], "curl"):
-		return rb.curlToStep(in...)
+		err = rb.curlToStep(in...)
 	case strings.HasPrefix(in[0], "grpcurl"):
-		return rb.grpcurlToStep(in...)
+		err = rb.grpcurlToStep(in...)
 	default:
 		if len(in) == 1 {
-			if err := rb.axsLogToStep(in...); err == nil {
-				return nil
-			}
+			err = rb.axsLogToStep(in...)
+		}
+		if len(in) > 1 || err != nil {
+			err = rb.cmdToStep(in...)
 		}
-		return rb.cmdToStep(in...)
 	}
+	if err != nil {
+		return err
+	}
+	rb.appendStepKey(in[0])
+	return nil
+}
+
+// AppendDBStep appends the step to execute the query with the DB runner of dsn.
+func (rb *runbook) AppendDBStep(dsn, query string) error {
+	if dsn == "" {
+		return errors.New("no dsn")
+	}
+	if strings.TrimSpace(query) == "" {
+		return errors.New("no query")
+	}
+	if err := rb.queryToStep(dsn, query); err != nil {
+		return err
+	}
+	rb.appendStepKey("query")
+	return nil
+}
+
+// appendStepKey appends the key of the appended step when the steps are a map.
+func (rb *runbook) appendStepKey(prefix string) {
+	if !rb.useMap {
+		return
+	}
+	key := fmt.Sprintf("%s%d", prefix, len(rb.stepKeys))
+	rb.stepKeys = append(rb.stepKeys, key)
 }
 
 func (rb *runbook) MarshalYAML() (any, error) {
@@ -408,6 +436,17 @@ func (rb *runbook) cmdToStep(in ...string) error {
 	return nil
 }
 
+func (rb *runbook) queryToStep(dsn, query string) error {
+	key := rb.setRunner(dsn)
+	step := yaml.MapSlice{
+		{Key: key, Value: yaml.MapSlice{
+			{Key: "query", Value: strings.TrimSpace(query)},
+		}},
+	}
+	rb.Steps = append(rb.Steps, step)
+	return nil
+}
+
 var invalidLabelTokens = []string{" ", "\n", "\r", "!", "+", "=", "|", ".", "*", "%", "^", "?", ">", "<"}
 
 func (rb *runbook) validate() error {
@@ -12,19 +12,27 @@ import (
 )
 
 type httpRunnerConfig struct {
-	Endpoint                   string `yaml:"endpoint"`
-	OpenAPI3DocLocation        string `yaml:"openapi3,omitempty"`
-	SkipValidateRequest        bool   `yaml:"skipValidateRequest,omitempty"`
-	SkipValidateResponse       bool   `yaml:"skipValidateResponse,omitempty"`
-	SkipCircularReferenceCheck bool   `yaml:"skipCircularReferenceCheck,omitempty"`
-	NotFollowRedirect          bool   `yaml:"notFollowRedirect,omitempty"`
-	MultipartBoundary          string `yaml:"multipartBoundary,omitempty"`
-	CACert                     string `yaml:"cacert,omitempty"`
-	Cert                       string `yaml:"cert,omitempty"`
-	Key                        string `yaml:"key,omitempty"`
-	SkipVerify                 bool   `yaml:"skipVerify,omitempty"`
-	Timeout                    string `yaml:"timeout,omitempty"`
-	UseCookie                  *bool  `yaml:"useCookie,omitempty"`
+	Endpoint                   string          `yaml:"endpoint"`
+	OpenAPI3DocLocation        string          `yaml:"openapi3,omitempty"`
+	GraphQLSchemaLocation      string          `yaml:"graphqlSchema,omitempty"`
+	SkipValidateRequest        bool            `yaml:"skipValidateRequest,omitempty"`
+	SkipValidateResponse       bool            `yaml:"skipValidateResponse,omitempty"`
+	SkipCircularReferenceCheck bool            `yaml:"skipCircularReferenceCheck,omitempty"`
+	NotFollowRedirect          bool            `yaml:"notFollowRedirect,omitempty"`
+	MultipartBoundary          string          `yaml:"multipartBoundary,omitempty"`
+	CACert                     string          `yaml:"cacert,omitempty"`
+	Cert                       string          `yaml:"cert,omitempty"`
+	Key                        string          `yaml:"key,omitempty"`
+	SkipVerify                 bool            `yaml:"skipVerify,omitempty"`
+	Timeout                    string          `yaml:"timeout,omitempty"`
+	UseCookie                  *bool           `yaml:"useCookie,omitempty"`
+	HTTP2                      bool            `yaml:"http2,omitempty"`
+	Proxy                      string          `yaml:"proxy,omitempty"`
+	MaxIdleConns               int             `yaml:"maxIdleConns,omitempty"`
+	MaxIdleConnsPerHost        int             `yaml:"maxIdleConnsPerHost,omitempty"`
+	MaxConnsPerHost            int             `yaml:"maxConnsPerHost,omitempty"`
+	DisableKeepAlives          bool            `yaml:"disableKeepAlives,omitempty"`
+	Auth                       *httpAuthConfig `yaml:"auth,omitempty"`
 	Trace                      traceConfig
 
 	openAPI3Doc libopenapi.Document
@@ -35,6 +43,48 @@ type traceConfig struct {
 	HeaderName string `yaml:"headerName,omitempty"`
 }
 
+type httpAuthConfig struct {
+	Basic    *httpBasicAuthConfig `yaml:"basic,omitempty"`
+	Digest   *httpBasicAuthConfig `yaml:"digest,omitempty"`
+	OAuth2   *httpOAuth2Config    `yaml:"oauth2,omitempty"`
+	AWSSigV4 *httpAWSSigV4Config  `yaml:"awsSigV4,omitempty"`
+	HMAC     *httpHMACConfig      `yaml:"hmac,omitempty"`
+}
+
+type httpBasicAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type httpOAuth2Config struct {
+	TokenURL     string            `yaml:"tokenURL"`
+	GrantType    string            `yaml:"grantType,omitempty"`
+	ClientID     string            `yaml:"clientID,omitempty"`
+	ClientSecret string            `yaml:"clientSecret,omitempty"`
+	Username     string            `yaml:"username,omitempty"`
+	Password     string            `yaml:"password,omitempty"`
+	Scopes       []string          `yaml:"scopes,omitempty"`
+	Params       map[string]string `yaml:"params,omitempty"`
+}
+
+type httpAWSSigV4Config struct {
+	AccessKeyID     string `yaml:"accessKeyID"`
+	SecretAccessKey string `yaml:"secretAccessKey"`
+	SessionToken    string `yaml:"sessionToken,omitempty"`
+	Region          string `yaml:"region"`
+	Service         string `yaml:"service"`
+}
+
+type httpHMACConfig struct {
+	Key             string `yaml:"key"`
+	Algorithm       string `yaml:"algorithm,omitempty"`
+	Canonical       string `yaml:"canonical,omitempty"`
+	Header          string `yaml:"header,omitempty"`
+	Prefix          string `yaml:"prefix,omitempty"`
+	Encoding        string `yaml:"encoding,omitempty"`
+	TimestampHeader string `yaml:"timestampHeader,omitempty"`
+}
+
 type grpcRunnerConfig struct {
 	Addr        string   `yaml:"addr"`
 	TLS         *bool    `yaml:"tls,omitempty"`
@@ -56,8 +106,9 @@ type grpcRunnerConfig struct {
 }
 
 type dbRunnerConfig struct {
-	DSN   string `yaml:"dsn"`
-	Trace *bool  `yaml:"trace,omitempty"`
+	DSN         string `yaml:"dsn"`
+	Trace       *bool  `yaml:"trace,omitempty"`
+	Transaction string `yaml:"transaction,omitempty"`
 }
 
 type sshRunnerConfig struct {
@@ -88,6 +139,11 @@ type cdpRunnerConfig struct {
 	Remote string         `yaml:"-"`
 }
 
+type mockRunnerConfig struct {
+	Addr   string       `yaml:"addr"`
+	Routes []*mockRoute `yaml:"routes,omitempty"`
+}
+
 type httpRunnerOption func(*httpRunnerConfig) error
 
 type grpcRunnerOption func(*grpcRunnerConfig) error
@@ -98,6 +154,8 @@ type sshRunnerOption func(*sshRunnerConfig) error
 
 type cdpRunnerOption func(*cdpRunnerConfig) error
 
+type mockRunnerOption func(*mockRunnerConfig) error
+
 func (c *sshRunnerConfig) validate() error {
 	if c.Host == "" && c.Hostname == "" {
 		return fmt.Errorf("host or hostname is required")
@@ -153,6 +211,14 @@ func OpenAPI3FromData(d []byte) httpRunnerOption {
 	}
 }
 
+// GraphQLSchema sets GraphQL schema ( SDL ) using file path.
+func GraphQLSchema(l string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.GraphQLSchemaLocation = l
+		return nil
+	}
+}
+
 // SkipValidateRequest sets whether to skip validation of HTTP request with OpenAPI Document.
 func SkipValidateRequest(skip bool) httpRunnerOption {
 	return func(c *httpRunnerConfig) error {
@@ -226,6 +292,122 @@ func HTTPTimeout(timeout string) httpRunnerOption {
 	}
 }
 
+// HTTP2 sets whether to force HTTP/2. HTTP/2 over plaintext ( h2c ) is used for http:// endpoints.
+func HTTP2(force bool) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.HTTP2 = force
+		return nil
+	}
+}
+
+// HTTPProxy sets the proxy URL ( http://, https://, socks5:// or socks5h:// ).
+func HTTPProxy(u string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Proxy = u
+		return nil
+	}
+}
+
+// HTTPMaxIdleConns sets the maximum number of idle connections across all hosts.
+func HTTPMaxIdleConns(n int) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.MaxIdleConns = n
+		return nil
+	}
+}
+
+// HTTPMaxIdleConnsPerHost sets the maximum number of idle connections per host.
+func HTTPMaxIdleConnsPerHost(n int) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.MaxIdleConnsPerHost = n
+		return nil
+	}
+}
+
+// HTTPMaxConnsPerHost sets the maximum number of connections per host.
+func HTTPMaxConnsPerHost(n int) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.MaxConnsPerHost = n
+		return nil
+	}
+}
+
+// HTTPDisableKeepAlives sets whether to disable HTTP keep-alives.
+func HTTPDisableKeepAlives(disable bool) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.DisableKeepAlives = disable
+		return nil
+	}
+}
+
+// HTTPBasicAuth sets the credentials of Basic authentication.
+func HTTPBasicAuth(username, password string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{Basic: &httpBasicAuthConfig{Username: username, Password: password}}
+		return nil
+	}
+}
+
+// HTTPDigestAuth sets the credentials of Digest authentication.
+func HTTPDigestAuth(username, password string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{Digest: &httpBasicAuthConfig{Username: username, Password: password}}
+		return nil
+	}
+}
+
+// HTTPOAuth2ClientCredentials sets the OAuth2 client credentials grant.
+func HTTPOAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{OAuth2: &httpOAuth2Config{
+			TokenURL:     tokenURL,
+			GrantType:    httpOAuth2GrantTypeClientCredentials,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scopes:       scopes,
+		}}
+		return nil
+	}
+}
+
+// HTTPOAuth2Password sets the OAuth2 resource owner password credentials grant.
+func HTTPOAuth2Password(tokenURL, clientID, clientSecret, username, password string, scopes ...string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{OAuth2: &httpOAuth2Config{
+			TokenURL:     tokenURL,
+			GrantType:    httpOAuth2GrantTypePassword,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Username:     username,
+			Password:     password,
+			Scopes:       scopes,
+		}}
+		return nil
+	}
+}
+
+// HTTPAWSSigV4 sets the credentials of AWS Signature Version 4.
+func HTTPAWSSigV4(accessKeyID, secretAccessKey, sessionToken, region, service string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{AWSSigV4: &httpAWSSigV4Config{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    sessionToken,
+			Region:          region,
+			Service:         service,
+		}}
+		return nil
+	}
+}
+
+// HTTPHMAC sets the key and the canonical string of HMAC signing.
+func HTTPHMAC(key, canonical string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{HMAC: &httpHMACConfig{Key: key, Canonical: canonical}}
+		return nil
+	}
+}
+
 func UseCookie(use bool) httpRunnerOption {
 	return func(c *httpRunnerConfig) error {
 		c.UseCookie = &use
@@ -347,6 +529,17 @@ func DBTrace(trace bool) dbRunnerOption {
 	}
 }
 
+// DBTransaction set the transaction mode ("per-step" or "per-runbook-rollback").
+func DBTransaction(mode string) dbRunnerOption {
+	return func(c *dbRunnerConfig) error {
+		if _, err := parseDBTransactionMode(mode); err != nil {
+			return err
+		}
+		c.Transaction = mode
+		return nil
+	}
+}
+
 func SSHConfig(p string) sshRunnerOption {
 	return func(c *sshRunnerConfig) error {
 		c.SSHConfig = p
@@ -418,6 +611,20 @@ func CDPFlag(flag string, tf any) cdpRunnerOption {
 	}
 }
 
+// MockRoute add route to mock server. Routes are matched in the order they are added.
+func MockRoute(method, path string, status int, headers map[string]string, body any) mockRunnerOption {
+	return func(c *mockRunnerConfig) error {
+		c.Routes = append(c.Routes, &mockRoute{
+			Method:  method,
+			Path:    path,
+			Status:  status,
+			Headers: headers,
+			Body:    body,
+		})
+		return nil
+	}
+}
+
 func (t *traceConfig) UnmarshalYAML(b []byte) error {
 	if enable, err := strconv.ParseBool(strings.TrimSpace(string(b))); err == nil {
 		t.Enable = &enable
@@ -79,6 +79,36 @@ func (rnr *runnerRunner) run(_ context.Context, d map[string]any, s *step) error
 		}
 		o.sshRunners[k] = r
 	}
+	for k, r := range bk.wsRunners {
+		if _, ok := o.wsRunners[k]; ok {
+			return fmt.Errorf("websocket runner key %s is already exists", k)
+		}
+		o.wsRunners[k] = r
+	}
+	for k, r := range bk.kafkaRunners {
+		if _, ok := o.kafkaRunners[k]; ok {
+			return fmt.Errorf("kafka runner key %s is already exists", k)
+		}
+		o.kafkaRunners[k] = r
+	}
+	for k, r := range bk.redisRunners {
+		if _, ok := o.redisRunners[k]; ok {
+			return fmt.Errorf("redis runner key %s is already exists", k)
+		}
+		o.redisRunners[k] = r
+	}
+	for k, r := range bk.smtpRunners {
+		if _, ok := o.smtpRunners[k]; ok {
+			return fmt.Errorf("smtp runner key %s is already exists", k)
+		}
+		o.smtpRunners[k] = r
+	}
+	for k, r := range bk.mockRunners {
+		if _, ok := o.mockRunners[k]; ok {
+			return fmt.Errorf("mock runner key %s is already exists", k)
+		}
+		o.mockRunners[k] = r
+	}
 	o.record(s.idx, map[string]any{})
 	return nil
 }
@@ -0,0 +1,463 @@
+package runn
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+const (
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	jsonSchemaID    = "https://github.com/k1LoW/runn/runbook.schema.json"
+)
+
+// jsonSchema is a subset of JSON Schema (draft 2020-12).
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 any                    `json:"type,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	PropertyNames        *jsonSchema            `json:"propertyNames,omitempty"`
+	MinProperties        *int                   `json:"minProperties,omitempty"`
+	MaxProperties        *int                   `json:"maxProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	AnyOf                []*jsonSchema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// Definitions of the schema.
+const (
+	schemaDefStep                = "step"
+	schemaDefLoop                = "loop"
+	schemaDefRunner              = "runner"
+	schemaDefHTTPRunnerConfig    = "httpRunnerConfig"
+	schemaDefGRPCRunnerConfig    = "grpcRunnerConfig"
+	schemaDefDBRunnerConfig      = "dbRunnerConfig"
+	schemaDefSSHRunnerConfig     = "sshRunnerConfig"
+	schemaDefMockRunnerConfig    = "mockRunnerConfig"
+	schemaDefIncludeRunnerConfig = "includeRunnerConfig"
+	schemaDefRequest             = "request"
+	schemaDefHTTPRequest         = "httpRequest"
+	schemaDefGRPCRequest         = "grpcRequest"
+	schemaDefDBQuery             = "dbQuery"
+	schemaDefCDPActions          = "cdpActions"
+	schemaDefSSHCommand          = "sshCommand"
+	schemaDefWebSocketRequest    = "wsRequest"
+	schemaDefKafkaRequest        = "kafkaRequest"
+	schemaDefRedisRequest        = "redisRequest"
+	schemaDefSMTPRequest         = "smtpRequest"
+	schemaDefMockRequest         = "mockRequest"
+)
+
+// schemaRunnerConfigs is the runner configs that can be written in `runners:`.
+// Unknown keys of the runner configs are ignored by runn, but the schema rejects them deliberately to report typos.
+// cdpRunnerConfig is not included because CDP runners are defined only by DSN.
+// The config of the mock runner is written under the `mock:` key.
+var schemaRunnerConfigs = []struct {
+	def      string
+	typ      reflect.Type
+	required []string
+	under    string
+}{
+	{schemaDefHTTPRunnerConfig, reflect.TypeOf(httpRunnerConfig{}), []string{"endpoint"}, ""},
+	{schemaDefMockRunnerConfig, reflect.TypeOf(mockRunnerConfig{}), nil, "mock"},
+	{schemaDefGRPCRunnerConfig, reflect.TypeOf(grpcRunnerConfig{}), []string{"addr"}, ""},
+	{schemaDefDBRunnerConfig, reflect.TypeOf(dbRunnerConfig{}), []string{"dsn"}, ""},
+	{schemaDefSSHRunnerConfig, reflect.TypeOf(sshRunnerConfig{}), nil, ""},
+	{schemaDefIncludeRunnerConfig, reflect.TypeOf(includeRunnerConfig{}), []string{"path"}, ""},
+}
+
+// schemaRequests is the request shapes of the runners defined in `runners:`.
+var schemaRequests = []string{
+	schemaDefHTTPRequest,
+	schemaDefGRPCRequest,
+	schemaDefDBQuery,
+	schemaDefCDPActions,
+	schemaDefSSHCommand,
+	schemaDefWebSocketRequest,
+	schemaDefKafkaRequest,
+	schemaDefRedisRequest,
+	schemaDefSMTPRequest,
+	schemaDefMockRequest,
+}
+
+// RunbookSchema returns the JSON Schema of the runbook.
+func RunbookSchema() ([]byte, error) {
+	return json.MarshalIndent(runbookSchema(), "", "  ")
+}
+
+func runbookSchema() *jsonSchema {
+	s := schemaFromType(reflect.TypeOf(runbook{}))
+	s.Schema = jsonSchemaDraft
+	s.ID = jsonSchemaID
+	s.Title = "runbook"
+	s.Description = "runn scenario file"
+	// Unknown keys are allowed for the anchors of YAML.
+	s.AdditionalProperties = nil
+	s.Properties["runners"] = schemaMap(schemaRef(schemaDefRunner))
+	s.Properties["hostRules"] = schemaMap(schemaString())
+	s.Properties["interval"] = schemaDuration()
+	s.Properties["if"] = schemaCondition()
+	s.Properties["loop"] = schemaRef(schemaDefLoop)
+	s.Properties["concurrency"] = schemaAnyOf(schemaString(), schemaArray(schemaString()))
+	s.Properties["steps"] = schemaAnyOf(
+		schemaArray(schemaRef(schemaDefStep)),
+		schemaMap(schemaRef(schemaDefStep)),
+	)
+
+	s.Defs = map[string]*jsonSchema{
+		schemaDefStep:   stepSchema(),
+		schemaDefLoop:   loopSchema(),
+		schemaDefRunner: runnerSchema(),
+	}
+	for _, c := range schemaRunnerConfigs {
+		s.Defs[c.def] = runnerConfigSchema(c.def)
+	}
+	var reqs []*jsonSchema
+	for _, def := range schemaRequests {
+		reqs = append(reqs, schemaRef(def))
+	}
+	// The request of the include runner ( custom runner ) is any map passed to the included runbook as `parent.nodes`.
+	reqs = append(reqs, &jsonSchema{Type: "object"})
+	s.Defs[schemaDefRequest] = schemaAnyOf(reqs...)
+	for def, rs := range requestSchemas() {
+		s.Defs[def] = rs
+	}
+	return s
+}
+
+// stepSchema returns the schema of a step.
+// The keys other than the sections and the built-in runners are the names of the runners.
+func stepSchema() *jsonSchema {
+	dump := schemaObject(map[string]*jsonSchema{
+		"expr":                   {Type: []string{"string", "number", "boolean"}},
+		"out":                    schemaString(),
+		"disableTrailingNewline": schemaBoolean(),
+		"disableMaskingSecrets":  schemaBoolean(),
+	}, "expr")
+	include := schemaObject(map[string]*jsonSchema{
+		"path":     schemaString(),
+		"vars":     schemaMap(&jsonSchema{}),
+		"skipTest": schemaBoolean(),
+		"force":    schemaBoolean(),
+	}, "path")
+	exec := schemaObject(map[string]*jsonSchema{
+		"command":    schemaString(),
+		"stdin":      schemaString(),
+		"shell":      schemaString(),
+		"background": schemaBoolean(),
+		"liveOutput": schemaBoolean(),
+	}, "command")
+	return &jsonSchema{
+		Type: "object",
+		Properties: map[string]*jsonSchema{
+			descSectionKey:   schemaString(),
+			ifSectionKey:     schemaCondition(),
+			loopSectionKey:   schemaRef(schemaDefLoop),
+			deferSectionKey:  schemaBoolean(),
+			forceSectionKey:  schemaBoolean(),
+			testRunnerKey:    schemaAnyOf(schemaString(), schemaBoolean()),
+			dumpRunnerKey:    schemaAnyOf(schemaString(), dump),
+			bindRunnerKey:    schemaMap(&jsonSchema{}),
+			includeRunnerKey: schemaAnyOf(schemaString(), include),
+			execRunnerKey:    exec,
+			runnerRunnerKey:  schemaMap(schemaRef(schemaDefRunner)),
+		},
+		AdditionalProperties: schemaRef(schemaDefRequest),
+	}
+}
+
+// loopSchema returns the schema of `loop:`, which is the count or the loop config.
+func loopSchema() *jsonSchema {
+	l := schemaFromType(reflect.TypeOf(Loop{}))
+	l.Properties["count"] = schemaAnyOf(schemaInteger(), schemaString())
+	for _, k := range []string{"interval", "minInterval", "maxInterval"} {
+		l.Properties[k] = schemaDuration()
+	}
+	return schemaAnyOf(schemaInteger(), schemaString(), l)
+}
+
+// runnerSchema returns the schema of a runner, which is the DSN or the runner config.
+func runnerSchema() *jsonSchema {
+	rs := []*jsonSchema{schemaString()}
+	for _, c := range schemaRunnerConfigs {
+		rs = append(rs, schemaRef(c.def))
+	}
+	return schemaAnyOf(rs...)
+}
+
+// runnerConfigSchema returns the schema of the runner config of the definition.
+func runnerConfigSchema(def string) *jsonSchema {
+	for _, c := range schemaRunnerConfigs {
+		if c.def != def {
+			continue
+		}
+		cs := schemaFromType(c.typ)
+		cs.Required = c.required
+		if c.under != "" {
+			cs = schemaObject(map[string]*jsonSchema{c.under: cs}, c.under)
+		}
+		return cs
+	}
+	return nil
+}
+
+// requestSchemas returns the schemas of the requests of the runners.
+func requestSchemas() map[string]*jsonSchema {
+	headers := schemaMap(schemaAnyOf(schemaString(), schemaArray(schemaString())))
+	var methods []any
+	for _, m := range []string{"get", "head", "post", "put", "patch", "delete", "connect", "options", "trace"} {
+		methods = append(methods, m, strings.ToUpper(m))
+	}
+	httpOptions := schemaObject(map[string]*jsonSchema{
+		"headers": headers,
+		"body":    schemaAnyOf(&jsonSchema{Type: "null"}, schemaSingleMap(&jsonSchema{})),
+		"graphql": schemaObject(map[string]*jsonSchema{
+			graphQLQueryKey:         schemaString(),
+			graphQLVariablesKey:     schemaMap(&jsonSchema{}),
+			graphQLOperationNameKey: schemaString(),
+		}, graphQLQueryKey),
+		"useCookie": schemaBoolean(),
+		"trace":     schemaBoolean(),
+		"timeout":   schemaString(),
+		"stream": schemaAnyOf(schemaBoolean(), schemaObject(map[string]*jsonSchema{
+			"count":   schemaInteger(),
+			"timeout": schemaString(),
+			"until":   schemaString(),
+		})),
+	})
+	httpMethod := schemaSingleMap(httpOptions)
+	httpMethod.PropertyNames = &jsonSchema{Enum: methods}
+
+	grpcMessage := schemaAnyOf(schemaString(), schemaMap(&jsonSchema{}))
+	grpcMethod := schemaSingleMap(schemaObject(map[string]*jsonSchema{
+		"headers": headers,
+		"timeout": schemaString(),
+		"message": grpcMessage,
+		"messages": schemaAnyOf(schemaString(), schemaArray(schemaAnyOf(
+			&jsonSchema{Enum: []any{string(GRPCOpReceive), string(GRPCOpClose)}},
+			grpcMessage,
+		))),
+		"trace": schemaBoolean(),
+	}))
+	grpcMethod.PropertyNames = &jsonSchema{Pattern: "/"}
+
+	cdpAction := schemaAnyOf(schemaString(), schemaSingleMap(schemaAnyOf(schemaString(), schemaMap(&jsonSchema{}))))
+
+	wsMessage := schemaSingleMap(nil)
+	wsMessage.Properties = map[string]*jsonSchema{
+		string(WebSocketOpText):   {},
+		string(WebSocketOpBinary): {},
+		string(WebSocketOpJSON):   {},
+		string(WebSocketOpReceive): schemaAnyOf(&jsonSchema{Type: "null"}, schemaObject(map[string]*jsonSchema{
+			"timeout": schemaString(),
+			"match":   schemaString(),
+		})),
+		string(WebSocketOpClose): {Type: "null"},
+	}
+	wsMessage.AdditionalProperties = false
+
+	kafka := schemaObject(map[string]*jsonSchema{
+		string(KafkaOpProduce): schemaObject(map[string]*jsonSchema{
+			kafkaMessageTopicKey: schemaString(),
+			"messages": schemaArray(schemaObject(map[string]*jsonSchema{
+				kafkaMessageKeyKey:     schemaString(),
+				kafkaMessageHeadersKey: schemaMap(schemaString()),
+				kafkaMessageValueKey:   {},
+			})),
+		}, kafkaMessageTopicKey, "messages"),
+		string(KafkaOpConsume): schemaObject(map[string]*jsonSchema{
+			kafkaMessageTopicKey:     schemaString(),
+			kafkaMessagePartitionKey: schemaInteger(),
+			kafkaMessageOffsetKey:    schemaAnyOf(schemaInteger(), &jsonSchema{Enum: []any{kafkaOffsetStart, kafkaOffsetEnd}}),
+			"timeout":                schemaString(),
+			"count":                  schemaInteger(),
+			"match":                  schemaString(),
+		}, kafkaMessageTopicKey),
+	})
+	kafka.MinProperties = schemaInt(1)
+	kafka.MaxProperties = schemaInt(1)
+
+	redisCommand := schemaArray(&jsonSchema{Type: []string{"string", "number", "boolean"}})
+	redisCommand.MinItems = schemaInt(1)
+	redis := schemaObject(map[string]*jsonSchema{
+		"command":  redisCommand,
+		"pipeline": schemaArray(redisCommand),
+	})
+	redis.MinProperties = schemaInt(1)
+	redis.MaxProperties = schemaInt(1)
+
+	return map[string]*jsonSchema{
+		schemaDefHTTPRequest: schemaSingleMap(httpMethod),
+		schemaDefGRPCRequest: grpcMethod,
+		schemaDefDBQuery: schemaObject(map[string]*jsonSchema{
+			"query":     schemaString(),
+			"trace":     schemaBoolean(),
+			dbParamsKey: schemaAnyOf(schemaArray(&jsonSchema{}), schemaMap(&jsonSchema{})),
+			dbArgsKey:   schemaAnyOf(schemaArray(&jsonSchema{}), schemaMap(&jsonSchema{})),
+		}, "query"),
+		schemaDefCDPActions: schemaObject(map[string]*jsonSchema{
+			"actions": schemaArray(cdpAction),
+		}, "actions"),
+		schemaDefSSHCommand: schemaObject(map[string]*jsonSchema{
+			"command": schemaString(),
+		}, "command"),
+		schemaDefWebSocketRequest: schemaObject(map[string]*jsonSchema{
+			"headers": headers,
+			"messages": schemaAnyOf(schemaString(), schemaArray(schemaAnyOf(
+				&jsonSchema{Enum: []any{string(WebSocketOpReceive), string(WebSocketOpClose)}},
+				wsMessage,
+			))),
+		}),
+		schemaDefKafkaRequest: kafka,
+		schemaDefRedisRequest: redis,
+		schemaDefSMTPRequest: schemaObject(map[string]*jsonSchema{
+			smtpMessageToKey:      schemaString(),
+			smtpMessageSubjectKey: schemaString(),
+			"match":               schemaString(),
+			"timeout":             schemaString(),
+		}),
+		schemaDefMockRequest: schemaObject(map[string]*jsonSchema{
+			"count":   schemaInteger(),
+			"match":   schemaString(),
+			"timeout": schemaString(),
+		}),
+	}
+}
+
+// schemaFromType returns the schema of the Go type using the keys of the yaml tags.
+func schemaFromType(t reflect.Type) *jsonSchema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFromType(t.Elem())
+	case reflect.String:
+		return schemaString()
+	case reflect.Bool:
+		return schemaBoolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schemaInteger()
+	case reflect.Float32, reflect.Float64:
+		return schemaScalar("number")
+	case reflect.Slice, reflect.Array:
+		return schemaArray(schemaFromType(t.Elem()))
+	case reflect.Map:
+		return schemaMap(schemaFromType(t.Elem()))
+	case reflect.Struct:
+		if t == reflect.TypeOf(traceConfig{}) {
+			// `trace:` also accepts a boolean ( see traceConfig.UnmarshalYAML ).
+			return schemaAnyOf(schemaBoolean(), schemaFromStruct(t))
+		}
+		return schemaFromStruct(t)
+	default:
+		return &jsonSchema{}
+	}
+}
+
+func schemaFromStruct(t reflect.Type) *jsonSchema {
+	props := map[string]*jsonSchema{}
+	for _, k := range yamlKeys(t) {
+		f, _ := t.FieldByName(k.field)
+		props[k.key] = schemaFromType(f.Type)
+	}
+	return schemaObject(props)
+}
+
+type yamlKey struct {
+	key   string
+	field string
+}
+
+// yamlKeys returns the keys of the exported fields of the struct in the same way as goccy/go-yaml.
+func yamlKeys(t reflect.Type) []yamlKey {
+	var keys []yamlKey
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		k := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if k == "-" {
+			continue
+		}
+		if k == "" {
+			k = strings.ToLower(f.Name)
+		}
+		keys = append(keys, yamlKey{key: k, field: f.Name})
+	}
+	return keys
+}
+
+func schemaObject(props map[string]*jsonSchema, required ...string) *jsonSchema {
+	return &jsonSchema{
+		Type:                 "object",
+		Properties:           props,
+		AdditionalProperties: false,
+		Required:             required,
+	}
+}
+
+func schemaMap(v *jsonSchema) *jsonSchema {
+	return &jsonSchema{Type: "object", AdditionalProperties: v}
+}
+
+// schemaSingleMap returns the schema of the map that has only one key.
+func schemaSingleMap(v *jsonSchema) *jsonSchema {
+	s := &jsonSchema{Type: "object", MinProperties: schemaInt(1), MaxProperties: schemaInt(1)}
+	if v != nil {
+		s.AdditionalProperties = v
+	}
+	return s
+}
+
+func schemaArray(v *jsonSchema) *jsonSchema {
+	return &jsonSchema{Type: "array", Items: v}
+}
+
+func schemaAnyOf(s ...*jsonSchema) *jsonSchema {
+	return &jsonSchema{AnyOf: s}
+}
+
+func schemaRef(def string) *jsonSchema {
+	return &jsonSchema{Ref: "#/$defs/" + def}
+}
+
+func schemaString() *jsonSchema {
+	return &jsonSchema{Type: "string"}
+}
+
+func schemaBoolean() *jsonSchema {
+	return schemaScalar("boolean")
+}
+
+func schemaInteger() *jsonSchema {
+	return schemaScalar("integer")
+}
+
+// schemaScalar returns the schema of the scalar type.
+// The string including environment variables ( e.g. ${DEBUG:-true} ) is also allowed, because they are expanded before parsing the runbook.
+func schemaScalar(typ string) *jsonSchema {
+	return schemaAnyOf(&jsonSchema{Type: typ}, &jsonSchema{Type: "string", Pattern: `\$\{[^}]+\}`})
+}
+
+// schemaCondition returns the schema of the condition, which is the expression or the boolean.
+func schemaCondition() *jsonSchema {
+	return schemaAnyOf(schemaString(), schemaBoolean())
+}
+
+// schemaDuration returns the schema of the duration, which is the string ( e.g. 1sec ) or the number of seconds.
+func schemaDuration() *jsonSchema {
+	return &jsonSchema{Type: []string{"string", "number"}}
+}
+
+func schemaInt(i int) *int {
+	return &i
+}
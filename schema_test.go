@@ -0,0 +1,408 @@
+package runn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/google/go-cmp/cmp"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestRunbookSchemaStructKeys(t *testing.T) {
+	s := runbookSchema()
+	tests := []struct {
+		name   string
+		schema *jsonSchema
+		typ    reflect.Type
+	}{
+		{"runbook", s, reflect.TypeOf(runbook{})},
+		{schemaDefLoop, s.Defs[schemaDefLoop].AnyOf[2], reflect.TypeOf(Loop{})},
+		{schemaDefHTTPRunnerConfig, s.Defs[schemaDefHTTPRunnerConfig], reflect.TypeOf(httpRunnerConfig{})},
+		{schemaDefMockRunnerConfig, s.Defs[schemaDefMockRunnerConfig].Properties["mock"], reflect.TypeOf(mockRunnerConfig{})},
+		{schemaDefGRPCRunnerConfig, s.Defs[schemaDefGRPCRunnerConfig], reflect.TypeOf(grpcRunnerConfig{})},
+		{schemaDefDBRunnerConfig, s.Defs[schemaDefDBRunnerConfig], reflect.TypeOf(dbRunnerConfig{})},
+		{schemaDefSSHRunnerConfig, s.Defs[schemaDefSSHRunnerConfig], reflect.TypeOf(sshRunnerConfig{})},
+		{schemaDefIncludeRunnerConfig, s.Defs[schemaDefIncludeRunnerConfig], reflect.TypeOf(includeRunnerConfig{})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []string
+			for _, k := range yamlKeys(tt.typ) {
+				want = append(want, k.key)
+			}
+			sort.Strings(want)
+			if diff := cmp.Diff(schemaKeys(tt.schema), want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRunbookSchemaStepKeys(t *testing.T) {
+	s := runbookSchema()
+	want := []string{
+		descSectionKey,
+		ifSectionKey,
+		loopSectionKey,
+		deferSectionKey,
+		forceSectionKey,
+		testRunnerKey,
+		dumpRunnerKey,
+		bindRunnerKey,
+		includeRunnerKey,
+		execRunnerKey,
+		runnerRunnerKey,
+	}
+	sort.Strings(want)
+	if diff := cmp.Diff(schemaKeys(s.Defs[schemaDefStep]), want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// TestRunbookSchemaRequestKeys tests that the keys of the request in the schema are the same as the keys accepted by the parser.
+func TestRunbookSchemaRequestKeys(t *testing.T) {
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parseStep := func(key string) func(v map[string]any) error {
+		return func(v map[string]any) error {
+			return o.appendStep(0, "0", map[string]any{key: v})
+		}
+	}
+	s := runbookSchema()
+	st := s.Defs[schemaDefStep]
+	httpOptions := s.Defs[schemaDefHTTPRequest].AdditionalProperties.(*jsonSchema).AdditionalProperties.(*jsonSchema)
+	grpcOptions := s.Defs[schemaDefGRPCRequest].AdditionalProperties.(*jsonSchema)
+	tests := []struct {
+		def    string
+		schema *jsonSchema
+		parse  func(v map[string]any) error
+		// samples - Requests using each key accepted by the parser.
+		samples map[string]string
+	}{
+		{
+			schemaDefHTTPRequest,
+			httpOptions,
+			func(v map[string]any) error {
+				_, err := parseHTTPRequest(v)
+				return err
+			},
+			map[string]string{
+				"headers":   "/users:\n  get:\n    headers:\n      Accept: application/json\n",
+				"body":      "/users:\n  post:\n    body:\n      application/json:\n        name: alice\n",
+				"graphql":   "/graphql:\n  post:\n    graphql:\n      query: '{ users { name } }'\n      variables: {}\n      operationName: Users\n",
+				"useCookie": "/users:\n  get:\n    useCookie: true\n",
+				"trace":     "/users:\n  get:\n    trace: true\n",
+				"timeout":   "/users:\n  get:\n    timeout: 10sec\n",
+				"stream":    "/events:\n  get:\n    stream:\n      count: 3\n      timeout: 10sec\n      until: current.event == 'done'\n",
+			},
+		},
+		{
+			schemaDefGRPCRequest,
+			grpcOptions,
+			func(v map[string]any) error {
+				_, err := parseGrpcRequest(v, &step{}, o.expandBeforeRecord)
+				return err
+			},
+			map[string]string{
+				"headers":  "grpctest.GrpcTestService/Hello:\n  headers:\n    user-agent: runn/dev\n",
+				"timeout":  "grpctest.GrpcTestService/Hello:\n  timeout: 3sec\n",
+				"message":  "grpctest.GrpcTestService/Hello:\n  message:\n    name: alice\n",
+				"messages": "grpctest.GrpcTestService/Hello:\n  messages:\n    - name: alice\n    - receive\n    - close\n",
+				"trace":    "grpctest.GrpcTestService/Hello:\n  trace: true\n",
+			},
+		},
+		{
+			schemaDefDBQuery,
+			s.Defs[schemaDefDBQuery],
+			func(v map[string]any) error {
+				_, err := parseDBQuery(v)
+				return err
+			},
+			map[string]string{
+				"query":     "query: SELECT 1\n",
+				"trace":     "query: SELECT 1\ntrace: true\n",
+				dbParamsKey: "query: SELECT * FROM users WHERE id = :id\nparams:\n  id: 1\n",
+				dbArgsKey:   "query: SELECT * FROM users WHERE id = ?\nargs:\n  - 1\n",
+			},
+		},
+		{
+			schemaDefCDPActions,
+			s.Defs[schemaDefCDPActions],
+			func(v map[string]any) error {
+				_, err := parseCDPActions(v, &step{}, o.expandBeforeRecord)
+				return err
+			},
+			map[string]string{
+				"actions": "actions:\n  - navigate: https://example.com\n  - latestTab\n",
+			},
+		},
+		{
+			schemaDefSSHCommand,
+			s.Defs[schemaDefSSHCommand],
+			func(v map[string]any) error {
+				_, err := parseSSHCommand(v, &step{}, o.expandBeforeRecord)
+				return err
+			},
+			map[string]string{
+				"command": "command: hostname\n",
+			},
+		},
+		{
+			schemaDefWebSocketRequest,
+			s.Defs[schemaDefWebSocketRequest],
+			func(v map[string]any) error {
+				_, err := parseWebSocketRequest(v, &step{}, o.expandBeforeRecord)
+				return err
+			},
+			map[string]string{
+				"headers":  "headers:\n  Authorization: Bearer xxx\n",
+				"messages": "messages:\n  - text: hello\n  - json:\n      hello: world\n  - receive:\n      timeout: 3sec\n      match: current.message.data == 'hello'\n  - close\n",
+			},
+		},
+		{
+			schemaDefKafkaRequest,
+			s.Defs[schemaDefKafkaRequest],
+			func(v map[string]any) error {
+				_, err := parseKafkaRequest(v)
+				return err
+			},
+			map[string]string{
+				string(KafkaOpProduce): "produce:\n  topic: orders\n  messages:\n    - key: k\n      headers:\n        h: v\n      value: hello\n",
+				string(KafkaOpConsume): "consume:\n  topic: orders\n  partition: 0\n  offset: start\n  timeout: 3sec\n  count: 1\n  match: current.value == 'hello'\n",
+			},
+		},
+		{
+			schemaDefRedisRequest,
+			s.Defs[schemaDefRedisRequest],
+			func(v map[string]any) error {
+				_, err := parseRedisRequest(v)
+				return err
+			},
+			map[string]string{
+				"command":  "command: [SET, key, 1]\n",
+				"pipeline": "pipeline:\n  - [SET, key, value]\n  - [GET, key]\n",
+			},
+		},
+		{
+			schemaDefSMTPRequest,
+			s.Defs[schemaDefSMTPRequest],
+			func(v map[string]any) error {
+				_, err := parseSMTPRequest(v)
+				return err
+			},
+			map[string]string{
+				smtpMessageToKey:      "to: alice@example.com\n",
+				smtpMessageSubjectKey: "subject: Hello\n",
+				"match":               "match: current.text contains 'hello'\n",
+				"timeout":             "timeout: 3sec\n",
+			},
+		},
+		{
+			schemaDefMockRequest,
+			s.Defs[schemaDefMockRequest],
+			func(v map[string]any) error {
+				_, err := parseMockRequest(v)
+				return err
+			},
+			map[string]string{
+				"count":   "count: 1\n",
+				"match":   "match: current.request.method == 'GET'\n",
+				"timeout": "timeout: 3sec\n",
+			},
+		},
+		{
+			execRunnerKey,
+			st.Properties[execRunnerKey],
+			func(v map[string]any) error {
+				_, err := parseExecCommand(v)
+				return err
+			},
+			map[string]string{
+				"command":    "command: echo hello\n",
+				"stdin":      "command: cat\nstdin: hello\n",
+				"shell":      "command: echo hello\nshell: bash\n",
+				"background": "command: sleep 1\nbackground: true\n",
+				"liveOutput": "command: echo hello\nliveOutput: true\n",
+			},
+		},
+		{
+			includeRunnerKey,
+			st.Properties[includeRunnerKey].AnyOf[1],
+			func(v map[string]any) error {
+				_, err := parseIncludeConfig(v)
+				return err
+			},
+			map[string]string{
+				"path":     "path: included.yml\n",
+				"vars":     "path: included.yml\nvars:\n  name: alice\n",
+				"skipTest": "path: included.yml\nskipTest: true\n",
+				"force":    "path: included.yml\nforce: true\n",
+			},
+		},
+		{
+			dumpRunnerKey,
+			st.Properties[dumpRunnerKey].AnyOf[1],
+			parseStep(dumpRunnerKey),
+			map[string]string{
+				"expr":                   "expr: vars\n",
+				"out":                    "expr: vars\nout: dump.json\n",
+				"disableTrailingNewline": "expr: vars\ndisableTrailingNewline: true\n",
+				"disableMaskingSecrets":  "expr: vars\ndisableMaskingSecrets: true\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.def, func(t *testing.T) {
+			var (
+				schema *jsonschema.Schema
+				wrap   func(v map[string]any) any
+			)
+			switch tt.def {
+			case execRunnerKey, includeRunnerKey, dumpRunnerKey:
+				schema = compileRunbookSchema(t, "")
+				wrap = func(v map[string]any) any {
+					return map[string]any{"steps": []any{map[string]any{tt.def: v}}}
+				}
+			default:
+				schema = compileRunbookSchema(t, tt.def)
+				wrap = func(v map[string]any) any { return v }
+			}
+			var keys []string
+			for k := range tt.samples {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if diff := cmp.Diff(schemaKeys(tt.schema), keys); diff != "" {
+				t.Error(diff)
+			}
+			for _, k := range keys {
+				var v map[string]any
+				if err := yaml.Unmarshal([]byte(tt.samples[k]), &v); err != nil {
+					t.Fatal(err)
+				}
+				if err := tt.parse(v); err != nil {
+					t.Errorf("%s: the parser does not accept the sample: %v", k, err)
+				}
+				if err := schema.Validate(toJSONValue(t, wrap(v))); err != nil {
+					t.Errorf("%s: the schema does not accept the sample: %v", k, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRunbookSchemaBooks(t *testing.T) {
+	schema := compileRunbookSchema(t, "")
+	books, err := filepath.Glob("testdata/book/*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The runbooks that have the keys of runner configs ignored by runn.
+	// The schema is stricter than runn and rejects them.
+	unknownKeys := map[string]string{
+		"testdata/book/sshd_local_forward_with_openapi3.yml": "useAgent",
+	}
+	for _, p := range books {
+		t.Run(p, func(t *testing.T) {
+			b, err := readFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var v any
+			if err := yaml.Unmarshal(b, &v); err != nil {
+				t.Fatal(err)
+			}
+			err = schema.Validate(toJSONValue(t, v))
+			if k, ok := unknownKeys[p]; ok {
+				if err == nil || !strings.Contains(fmt.Sprintf("%#v", err), k) {
+					t.Errorf("want error for the unknown key %q: %v", k, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("%#v", err)
+			}
+		})
+	}
+}
+
+func TestRunbookSchemaRejectsInvalidKeys(t *testing.T) {
+	tests := []struct {
+		def string
+		in  string
+	}{
+		{"", "runners:\n  req:\n    endpont: https://example.com\n"},
+		{"", "steps:\n  - loop:\n      count: 3\n      untill: current.res.status == 200\n"},
+		{"", "steps:\n  - exec:\n      command: echo hello\n      stdout: true\n"},
+		{"", "steps:\n  - dump:\n      out: dump.json\n"},
+		{schemaDefHTTPRequest, "/users:\n  fetch:\n    body: null\n"},
+		{schemaDefHTTPRequest, "/users:\n  get:\n    header:\n      Accept: application/json\n"},
+		{schemaDefKafkaRequest, "consume:\n  topic: orders\n  messages: []\n"},
+	}
+	for _, tt := range tests {
+		schema := compileRunbookSchema(t, tt.def)
+		var v any
+		if err := yaml.Unmarshal([]byte(tt.in), &v); err != nil {
+			t.Fatal(err)
+		}
+		if err := schema.Validate(toJSONValue(t, v)); err == nil {
+			t.Errorf("want error: %s", tt.in)
+		}
+	}
+}
+
+// compileRunbookSchema compiles the schema of the runbook, or the definition of the schema if def is specified.
+func compileRunbookSchema(t *testing.T, def string) *jsonschema.Schema {
+	t.Helper()
+	b, err := RunbookSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(jsonSchemaID, bytes.NewReader(b)); err != nil {
+		t.Fatal(err)
+	}
+	u := jsonSchemaID
+	if def != "" {
+		u += "#/$defs/" + def
+	}
+	s, err := c.Compile(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// toJSONValue converts the value decoded from YAML to the value decoded from JSON.
+func toJSONValue(t *testing.T, v any) any {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(fmt.Errorf("failed to convert to JSON: %w", err))
+	}
+	var vv any
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&vv); err != nil {
+		t.Fatal(err)
+	}
+	return vv
+}
+
+func schemaKeys(s *jsonSchema) []string {
+	var keys []string
+	for k := range s.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
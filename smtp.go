@@ -0,0 +1,407 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+)
+
+const (
+	smtpStoreResponseKey = "res"
+
+	smtpMessageFromKey    = "from"
+	smtpMessageToKey      = "to"
+	smtpMessageSubjectKey = "subject"
+	smtpMessageHeadersKey = "headers"
+	smtpMessageTextKey    = "text"
+	smtpMessageHTMLKey    = "html"
+	smtpMessageLinksKey   = "links"
+)
+
+const smtpDefaultWaitTimeout = 10 * time.Second
+
+var (
+	smtpTextLinkRe = regexp.MustCompile(`https?://[^\s"'<>]+`)
+	smtpHTMLLinkRe = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+)
+
+// SMTPMessage is a message received by the SMTP runner.
+type SMTPMessage struct {
+	// From - Envelope sender.
+	From string
+	// To - Envelope recipients.
+	To      []string
+	Subject string
+	Headers map[string][]string
+	Text    string
+	HTML    string
+	// Links - Links extracted from the text and HTML parts.
+	Links []string
+}
+
+type smtpRunner struct {
+	name   string
+	addr   string
+	server *smtp.Server
+	mu     sync.Mutex
+	// messages - Received messages that have not yet been matched by any step.
+	messages []*SMTPMessage
+	// received - Closed and renewed when a message is received.
+	received chan struct{}
+	// operatorID - The id of the operator for which the runner is defined.
+	operatorID string
+}
+
+type smtpRequest struct {
+	to      string
+	subject string
+	match   string
+	timeout time.Duration
+}
+
+type smtpSession struct {
+	rnr  *smtpRunner
+	from string
+	to   []string
+}
+
+func newSMTPRunner(name, dsn string) (*smtpRunner, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "smtp" || u.Host == "" {
+		return nil, fmt.Errorf("invalid SMTP DSN: %s", dsn)
+	}
+	return &smtpRunner{
+		name: name,
+		addr: u.Host,
+	}, nil
+}
+
+// start starts the local SMTP server.
+func (rnr *smtpRunner) start() error {
+	if rnr.server != nil {
+		return nil
+	}
+	l, err := net.Listen("tcp", rnr.addr)
+	if err != nil {
+		return fmt.Errorf("failed to start SMTP server on %s: %w", rnr.addr, err)
+	}
+	s := smtp.NewServer(smtp.BackendFunc(func(c *smtp.Conn) (smtp.Session, error) {
+		return &smtpSession{rnr: rnr}, nil
+	}))
+	s.Domain = "localhost"
+	s.AllowInsecureAuth = true
+	s.ErrorLog = log.New(io.Discard, "", 0)
+	rnr.mu.Lock()
+	rnr.server = s
+	rnr.messages = nil
+	rnr.received = make(chan struct{})
+	rnr.mu.Unlock()
+	go func() {
+		_ = s.Serve(l)
+	}()
+	return nil
+}
+
+func (rnr *smtpRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.server == nil {
+		return nil
+	}
+	err := rnr.server.Close()
+	rnr.server = nil
+	return err
+}
+
+func (rnr *smtpRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	e, err := o.expandBeforeRecord(s.smtpRequest, s)
+	if err != nil {
+		return err
+	}
+	v, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid SMTP request: %v", e)
+	}
+	req, err := parseSMTPRequest(v)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP request: %w", err)
+	}
+	m, err := rnr.wait(ctx, req, s)
+	if err != nil {
+		return err
+	}
+	o.record(s.idx, map[string]any{
+		smtpStoreResponseKey: smtpMessageToMap(m),
+	})
+	return nil
+}
+
+// wait waits for a received message that matches the request.
+func (rnr *smtpRunner) wait(ctx context.Context, r *smtpRequest, s *step) (*SMTPMessage, error) {
+	o := s.parent
+	timeout := r.timeout
+	if timeout == 0 {
+		timeout = smtpDefaultWaitTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		rnr.mu.Lock()
+		if rnr.server == nil {
+			rnr.mu.Unlock()
+			return nil, fmt.Errorf("SMTP server is not running: %s", rnr.addr)
+		}
+		for i, m := range rnr.messages {
+			tf, err := rnr.matchMessage(r, m, s)
+			if err != nil {
+				rnr.mu.Unlock()
+				return nil, err
+			}
+			if !tf {
+				continue
+			}
+			// A matched message is not matched again in the subsequent steps.
+			rnr.messages = append(rnr.messages[:i], rnr.messages[i+1:]...)
+			rnr.mu.Unlock()
+			o.capturers.captureSMTPMessage(rnr.name, m)
+			return m, nil
+		}
+		received := rnr.received
+		rnr.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, fmt.Errorf("no message matched within %s", timeout)
+		case <-received:
+		}
+	}
+}
+
+func (rnr *smtpRunner) matchMessage(r *smtpRequest, m *SMTPMessage, s *step) (bool, error) {
+	if r.to != "" {
+		found := false
+		for _, to := range m.To {
+			if strings.EqualFold(to, r.to) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	if r.subject != "" && !strings.Contains(m.Subject, r.subject) {
+		return false, nil
+	}
+	if r.match == "" {
+		return true, nil
+	}
+	o := s.parent
+	sm := o.store.ToMap()
+	sm[store.RootKeyIncluded] = o.included
+	if !s.deferred {
+		sm[store.RootKeyPrevious] = o.store.Latest()
+	}
+	// `current` in `match:` is the received message.
+	sm[store.RootKeyCurrent] = smtpMessageToMap(m)
+	return expr.EvalCond(r.match, sm)
+}
+
+func (rnr *smtpRunner) receive(m *SMTPMessage) {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	rnr.messages = append(rnr.messages, m)
+	close(rnr.received)
+	rnr.received = make(chan struct{})
+}
+
+func (s *smtpSession) AuthMechanisms() []string {
+	return []string{sasl.Plain, sasl.Login}
+}
+
+// Auth accepts any credentials.
+func (s *smtpSession) Auth(mech string) (sasl.Server, error) {
+	switch mech {
+	case sasl.Plain:
+		return sasl.NewPlainServer(func(identity, username, password string) error {
+			return nil
+		}), nil
+	case sasl.Login:
+		return sasl.NewLoginServer(func(username, password string) error {
+			return nil
+		}), nil
+	default:
+		return nil, smtp.ErrAuthUnknownMechanism
+	}
+}
+
+func (s *smtpSession) Mail(from string, opts *smtp.MailOptions) error {
+	s.from = from
+	return nil
+}
+
+func (s *smtpSession) Rcpt(to string, opts *smtp.RcptOptions) error {
+	s.to = append(s.to, to)
+	return nil
+}
+
+func (s *smtpSession) Data(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m, err := parseSMTPMessage(s.from, s.to, b)
+	if err != nil {
+		return err
+	}
+	s.rnr.receive(m)
+	return nil
+}
+
+func (s *smtpSession) Reset() {
+	s.from = ""
+	s.to = nil
+}
+
+func (s *smtpSession) Logout() error {
+	return nil
+}
+
+func parseSMTPMessage(from string, to []string, b []byte) (*SMTPMessage, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	dec := new(mime.WordDecoder)
+	subject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	m := &SMTPMessage{
+		From:    from,
+		To:      to,
+		Subject: subject,
+		Headers: map[string][]string(msg.Header),
+	}
+	if err := walkSMTPPart(textproto.MIMEHeader(msg.Header), msg.Body, m); err != nil {
+		return nil, err
+	}
+	m.Links = extractLinks(m.Text, m.HTML)
+	return m, nil
+}
+
+// walkSMTPPart sets the first text/plain part and the first text/html part to the message.
+func walkSMTPPart(h textproto.MIMEHeader, r io.Reader, m *SMTPMessage) error {
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		ct = "text/plain"
+	}
+	mt, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		mt = "text/plain"
+	}
+	if strings.HasPrefix(mt, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := walkSMTPPart(p.Header, p, m); err != nil {
+				return err
+			}
+		}
+	}
+	switch strings.ToLower(h.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	switch mt {
+	case "text/plain":
+		if m.Text == "" {
+			m.Text = string(b)
+		}
+	case "text/html":
+		if m.HTML == "" {
+			m.HTML = string(b)
+		}
+	}
+	return nil
+}
+
+func extractLinks(text, htm string) []string {
+	links := []string{}
+	seen := map[string]struct{}{}
+	add := func(l string) {
+		if _, ok := seen[l]; ok {
+			return
+		}
+		seen[l] = struct{}{}
+		links = append(links, l)
+	}
+	for _, sm := range smtpHTMLLinkRe.FindAllStringSubmatch(htm, -1) {
+		l := html.UnescapeString(sm[1])
+		if strings.HasPrefix(l, "http://") || strings.HasPrefix(l, "https://") {
+			add(l)
+		}
+	}
+	for _, l := range smtpTextLinkRe.FindAllString(text, -1) {
+		add(strings.TrimRight(l, ".,;:!?)]"))
+	}
+	return links
+}
+
+func smtpMessageToMap(m *SMTPMessage) map[string]any {
+	to := make([]any, 0, len(m.To))
+	for _, t := range m.To {
+		to = append(to, t)
+	}
+	links := make([]any, 0, len(m.Links))
+	for _, l := range m.Links {
+		links = append(links, l)
+	}
+	return map[string]any{
+		smtpMessageFromKey:    m.From,
+		smtpMessageToKey:      to,
+		smtpMessageSubjectKey: m.Subject,
+		smtpMessageHeadersKey: m.Headers,
+		smtpMessageTextKey:    m.Text,
+		smtpMessageHTMLKey:    m.HTML,
+		smtpMessageLinksKey:   links,
+	}
+}
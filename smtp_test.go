@@ -0,0 +1,219 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestSMTPRunner(t *testing.T) {
+	ctx := context.Background()
+	addr := fmt.Sprintf("127.0.0.1:%d", testutil.NewPort(t))
+	errc := make(chan error, 1)
+	o, err := New(
+		Book("testdata/book/smtp.yml"),
+		SMTPRunner("mail", fmt.Sprintf("smtp://%s", addr)),
+		BeforeFunc(func(_ *RunResult) error {
+			// Send the messages after the steps have started waiting.
+			go func() {
+				time.Sleep(100 * time.Millisecond)
+				var err error
+				for _, f := range []string{"testdata/smtp/reset.eml", "testdata/smtp/welcome.eml"} {
+					err = errors.Join(err, sendTestMail(addr, f))
+				}
+				errc <- err
+			}()
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+	if err := <-errc; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSMTPRunnerAddrInUse(t *testing.T) {
+	ctx := context.Background()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+	o, err := New(Book("testdata/book/smtp.yml"), SMTPRunner("mail", fmt.Sprintf("smtp://%s", l.Addr().String())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err == nil {
+		t.Error("want error")
+	}
+	if o.Result().Err == nil {
+		t.Error("want error in the run result")
+	}
+}
+
+func TestSMTPRunnerWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *smtpRequest
+		want    string
+		wantErr string
+	}{
+		{
+			"first message",
+			&smtpRequest{},
+			"Welcome to example.com",
+			"",
+		},
+		{
+			"match subject",
+			&smtpRequest{subject: "Reset"},
+			"Reset your password",
+			"",
+		},
+		{
+			"match condition",
+			&smtpRequest{match: `current.subject startsWith "Reset" && len(current.links) == 1`},
+			"Reset your password",
+			"",
+		},
+		{
+			"no message matched",
+			&smtpRequest{to: "bob@example.com", timeout: 200 * time.Millisecond},
+			"",
+			"no message matched within 200ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			addr := fmt.Sprintf("127.0.0.1:%d", testutil.NewPort(t))
+			o, err := New(SMTPRunner("mail", fmt.Sprintf("smtp://%s", addr)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := o.smtpRunners["mail"]
+			if err := r.start(); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = r.Close()
+			})
+			for _, f := range []string{"testdata/smtp/welcome.eml", "testdata/smtp/reset.eml"} {
+				if err := sendTestMail(addr, f); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s := newStep(0, "stepKey", o, nil)
+			got, err := r.wait(ctx, tt.req, s)
+			if err != nil {
+				if tt.wantErr == "" {
+					t.Fatal(err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("want error %q", tt.wantErr)
+			}
+			if got.Subject != tt.want {
+				t.Errorf("got %v, want %v", got.Subject, tt.want)
+			}
+			// A matched message is not matched again.
+			if _, err := r.wait(ctx, &smtpRequest{subject: tt.want, timeout: 100 * time.Millisecond}, s); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestParseSMTPMessage(t *testing.T) {
+	tests := []struct {
+		in   string
+		want *SMTPMessage
+	}{
+		{
+			"testdata/smtp/welcome.eml",
+			&SMTPMessage{
+				From:    "noreply@example.com",
+				To:      []string{"alice@example.com"},
+				Subject: "Welcome to example.com",
+				Text:    "Welcome, alice!\n",
+				Links:   []string{},
+			},
+		},
+		{
+			"testdata/smtp/reset.eml",
+			&SMTPMessage{
+				From:    "noreply@example.com",
+				To:      []string{"alice@example.com"},
+				Subject: "Reset your password",
+				Text:    "Open the link to reset your password: https://example.com/reset?token=abc&user=alice.",
+				HTML:    `<p><a href="https://example.com/reset?token=abc&amp;user=alice">Reset password</a></p>`,
+				Links:   []string{"https://example.com/reset?token=abc&user=alice"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			b, err := os.ReadFile(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseSMTPMessage("noreply@example.com", []string{"alice@example.com"}, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got.Headers = nil
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func sendTestMail(addr, f string) error {
+	b, err := os.ReadFile(f)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.Dial(addr)
+	if err != nil {
+		return err
+	}
+	// Do not send QUIT because the server may be closed as soon as the message is received.
+	defer c.Close()
+	if err := c.Mail("noreply@example.com"); err != nil {
+		return err
+	}
+	if err := c.Rcpt("alice@example.com"); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	return w.Close()
+}
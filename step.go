@@ -26,6 +26,16 @@ type step struct {
 	cdpActions       map[string]any
 	sshRunner        *sshRunner
 	sshCommand       map[string]any
+	wsRunner         *wsRunner
+	wsRequest        map[string]any
+	kafkaRunner      *kafkaRunner
+	kafkaRequest     map[string]any
+	redisRunner      *redisRunner
+	redisRequest     map[string]any
+	smtpRunner       *smtpRunner
+	smtpRequest      map[string]any
+	mockRunner       *mockRunner
+	mockRequest      map[string]any
 	execRunner       *execRunner
 	execCommand      map[string]any
 	testRunner       *testRunner
@@ -92,6 +102,16 @@ func (s *step) generateTrail() Trail {
 		tr.StepRunnerType = RunnerTypeCDP
 	case s.sshRunner != nil && s.sshCommand != nil:
 		tr.StepRunnerType = RunnerTypeSSH
+	case s.wsRunner != nil && s.wsRequest != nil:
+		tr.StepRunnerType = RunnerTypeWebSocket
+	case s.kafkaRunner != nil && s.kafkaRequest != nil:
+		tr.StepRunnerType = RunnerTypeKafka
+	case s.redisRunner != nil && s.redisRequest != nil:
+		tr.StepRunnerType = RunnerTypeRedis
+	case s.smtpRunner != nil && s.smtpRequest != nil:
+		tr.StepRunnerType = RunnerTypeSMTP
+	case s.mockRunner != nil && s.mockRequest != nil:
+		tr.StepRunnerType = RunnerTypeMock
 	case s.execRunner != nil && s.execCommand != nil:
 		tr.StepRunnerType = RunnerTypeExec
 	case s.includeRunner != nil && s.includeConfig != nil:
@@ -156,6 +176,11 @@ func (s *step) notYetDetectedRunner() bool {
 		s.grpcRunner == nil &&
 		s.cdpRunner == nil &&
 		s.sshRunner == nil &&
+		s.wsRunner == nil &&
+		s.kafkaRunner == nil &&
+		s.redisRunner == nil &&
+		s.smtpRunner == nil &&
+		s.mockRunner == nil &&
 		s.execRunner == nil &&
 		len(s.runnerValues) > 0
 }
@@ -0,0 +1,22 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+)
+
+// KafkaCluster returns DSN of an in-process fake Kafka cluster for testing.
+// The topics are created with a single partition.
+func KafkaCluster(t testing.TB, topic string, topics ...string) string {
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, append([]string{topic}, topics...)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+	})
+	return fmt.Sprintf("kafka://%s/%s", strings.Join(c.ListenAddrs(), ","), topic)
+}
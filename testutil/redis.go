@@ -0,0 +1,14 @@
+package testutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// RedisServer returns DSN of an in-process Redis server for testing.
+func RedisServer(t testing.TB) string {
+	s := miniredis.RunT(t)
+	return fmt.Sprintf("redis://%s/0", s.Addr())
+}
@@ -0,0 +1,77 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// WebSocketServer returns a WebSocket server for testing.
+// It echoes received messages with the following exceptions.
+//
+//   - "ping" -> "pong"
+//   - "whoami" -> value of the X-User header of the handshake request
+//   - {"type": "subscribe", "count": n} -> n of {"type": "event", "seq": i} and {"type": "done"}
+//   - "bye" -> close the connection
+func WebSocketServer(t testing.TB) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := r.Header.Get("X-User")
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				b, op, err := wsutil.ReadClientData(conn)
+				if err != nil {
+					return
+				}
+				if op == ws.OpBinary {
+					if err := wsutil.WriteServerBinary(conn, b); err != nil {
+						return
+					}
+					continue
+				}
+				var res [][]byte
+				switch string(b) {
+				case "ping":
+					res = append(res, []byte("pong"))
+				case "whoami":
+					res = append(res, []byte(user))
+				case "bye":
+					_ = wsutil.WriteServerMessage(conn, ws.OpClose, ws.NewCloseFrameBody(ws.StatusNormalClosure, "bye"))
+					return
+				default:
+					var sub struct {
+						Type  string `json:"type"`
+						Count int    `json:"count"`
+					}
+					if err := json.Unmarshal(b, &sub); err == nil && sub.Type == "subscribe" {
+						for i := 0; i < sub.Count; i++ {
+							res = append(res, []byte(fmt.Sprintf(`{"type":"event","seq":%d}`, i)))
+						}
+						res = append(res, []byte(`{"type":"done"}`))
+						break
+					}
+					res = append(res, b)
+				}
+				for _, m := range res {
+					if err := wsutil.WriteServerText(conn, m); err != nil {
+						return
+					}
+				}
+			}
+		}()
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	return ts
+}
@@ -18,16 +18,21 @@ const (
 type RunnerType string
 
 const (
-	RunnerTypeHTTP    RunnerType = "http"
-	RunnerTypeDB      RunnerType = "db"
-	RunnerTypeGRPC    RunnerType = "grpc"
-	RunnerTypeCDP     RunnerType = "cdp"
-	RunnerTypeSSH     RunnerType = "ssh"
-	RunnerTypeExec    RunnerType = "exec"
-	RunnerTypeTest    RunnerType = "test"
-	RunnerTypeDump    RunnerType = "dump"
-	RunnerTypeInclude RunnerType = "include"
-	RunnerTypeBind    RunnerType = "bind"
+	RunnerTypeHTTP      RunnerType = "http"
+	RunnerTypeDB        RunnerType = "db"
+	RunnerTypeGRPC      RunnerType = "grpc"
+	RunnerTypeCDP       RunnerType = "cdp"
+	RunnerTypeSSH       RunnerType = "ssh"
+	RunnerTypeWebSocket RunnerType = "websocket"
+	RunnerTypeKafka     RunnerType = "kafka"
+	RunnerTypeRedis     RunnerType = "redis"
+	RunnerTypeSMTP      RunnerType = "smtp"
+	RunnerTypeMock      RunnerType = "mock"
+	RunnerTypeExec      RunnerType = "exec"
+	RunnerTypeTest      RunnerType = "test"
+	RunnerTypeDump      RunnerType = "dump"
+	RunnerTypeInclude   RunnerType = "include"
+	RunnerTypeBind      RunnerType = "bind"
 )
 
 // Trail - The trail of elements in the runbook at runtime.
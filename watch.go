@@ -0,0 +1,200 @@
+package runn
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/k1LoW/runn/internal/store"
+	"github.com/k1LoW/stopw"
+	"github.com/k1LoW/waitmap"
+)
+
+// ReferencedFiles returns the local files that each selected runbook refers to, keyed by the path of the runbook.
+// The files include the runbook itself, the runbooks of `needs:` and `include:` (recursively), the files of `json://` and `yaml://` vars and the protos of gRPC runners.
+// The directory is returned instead of the files for the glob pattern of vars and the import path of protos.
+func (opn *operatorN) ReferencedFiles() (map[string][]string, error) {
+	files := map[string][]string{}
+	for _, op := range opn.ops {
+		seen := map[string]struct{}{}
+		if err := collectRunbookFiles(op.bookPath, seen); err != nil {
+			return nil, err
+		}
+		// Protos specified by the options are merged into the gRPC runners.
+		for _, r := range op.grpcRunners {
+			addGRPCRunnerFiles(r.protos, r.importPaths, seen)
+		}
+		var fs []string
+		for f := range seen {
+			fs = append(fs, f)
+		}
+		sort.Strings(fs)
+		files[op.bookPath] = fs
+	}
+	return files, nil
+}
+
+// Reload returns a new operatorN that runs only the runbooks of paths among the loaded runbooks.
+// The runbooks ( and the runbooks of `needs:` ) are parsed again to reflect the changes, and the other runbooks are not reloaded.
+// Because only the loaded runbooks are selected, the selection by `--run`, `--id` and `--label` is kept.
+func (opn *operatorN) Reload(paths []string) (*operatorN, error) {
+	ropn := &operatorN{
+		om:           map[string]*operator{},
+		nm:           waitmap.New[string, *store.Store](),
+		skipIncluded: opn.skipIncluded,
+		included:     map[string][]string{},
+		t:            opn.t,
+		sw:           stopw.New(),
+		profile:      opn.profile,
+		shuffle:      opn.shuffle,
+		shuffleSeed:  opn.shuffleSeed,
+		waitTimeout:  opn.waitTimeout,
+		failFast:     opn.failFast,
+		concmax:      opn.concmax,
+		opts:         opn.opts,
+		runNIndex:    atomic.Int64{},
+		kv:           opn.kv,
+		dbg:          opn.dbg,
+	}
+	ropn.runNIndex.Store(-1) // Set index to -1 ( no runN )
+	var ops []*operator
+	for _, op := range opn.ops {
+		if slices.Contains(paths, op.bookPath) {
+			ops = append(ops, op)
+		}
+	}
+	reloaded, err := copyOperators(ops, opn.opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range reloaded {
+		if err := ropn.traverseOperators(op); err != nil {
+			return nil, err
+		}
+	}
+	// Keep the IDs of the loaded runbooks ( including the runbooks of `needs:` ).
+	for p, op := range ropn.om {
+		if oo, ok := opn.om[p]; ok {
+			op.id = oo.id
+		}
+	}
+	ropn.ops = reloaded
+	return ropn, nil
+}
+
+// collectRunbookFiles collects the local files that the runbook refers to.
+func collectRunbookFiles(p string, seen map[string]struct{}) error {
+	if hasRemotePrefix(p) {
+		return nil
+	}
+	p, err := filepath.Abs(strings.TrimPrefix(p, prefixFile))
+	if err != nil {
+		return err
+	}
+	if _, ok := seen[p]; ok {
+		return nil
+	}
+	seen[p] = struct{}{}
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	bk, err := parseBook(f)
+	if err != nil {
+		return err
+	}
+	root := filepath.Dir(p)
+	addSchemaFiles(bk.vars, root, seen)
+	for _, n := range bk.needs {
+		// Referenced runbooks that can not be loaded are also watched for their creation.
+		_ = collectRunbookFiles(resolveReferencedPath(n, root), seen)
+	}
+	for _, r := range bk.runners {
+		c, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+		addGRPCRunnerFiles(resolveReferencedPaths(c["protos"], root), resolveReferencedPaths(c["importPaths"], root), seen)
+	}
+	for _, s := range bk.rawSteps {
+		switch v := s[includeRunnerKey].(type) {
+		case string:
+			_ = collectRunbookFiles(resolveReferencedPath(v, root), seen)
+		case map[string]any:
+			if ip, ok := v["path"].(string); ok {
+				_ = collectRunbookFiles(resolveReferencedPath(ip, root), seen)
+			}
+			if vars, ok := v["vars"].(map[string]any); ok {
+				addSchemaFiles(vars, root, seen)
+			}
+		}
+	}
+	return nil
+}
+
+// addSchemaFiles adds the files of `json://` and `yaml://` vars.
+func addSchemaFiles(vars map[string]any, root string, seen map[string]struct{}) {
+	for _, v := range vars {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		for _, e := range evaluators {
+			if !strings.HasPrefix(s, e.scheme) {
+				continue
+			}
+			p := resolveReferencedPath(s[len(e.scheme):], root)
+			if strings.Contains(p, multiple) {
+				p, _ = doublestar.SplitPattern(p)
+			}
+			seen[p] = struct{}{}
+		}
+	}
+}
+
+func addGRPCRunnerFiles(protos, importPaths []string, seen map[string]struct{}) {
+	for _, p := range protos {
+		if hasRemotePrefix(p) {
+			continue
+		}
+		if strings.Contains(p, multiple) {
+			p, _ = doublestar.SplitPattern(p)
+		}
+		seen[p] = struct{}{}
+	}
+	for _, p := range importPaths {
+		seen[p] = struct{}{}
+	}
+}
+
+func resolveReferencedPath(p, root string) string {
+	if hasRemotePrefix(p) {
+		return p
+	}
+	p = strings.TrimPrefix(p, prefixFile)
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(root, p)
+}
+
+func resolveReferencedPaths(v any, root string) []string {
+	l, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	var paths []string
+	for _, vv := range l {
+		s, ok := vv.(string)
+		if !ok {
+			continue
+		}
+		paths = append(paths, resolveReferencedPath(s, root))
+	}
+	return paths
+}
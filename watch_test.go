@@ -0,0 +1,108 @@
+package runn
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReferencedFiles(t *testing.T) {
+	tests := []struct {
+		book string
+		want []string
+	}{
+		{
+			"testdata/book/include_main.yml",
+			[]string{
+				"testdata/book/include_a.yml",
+				"testdata/book/include_b.yml",
+				"testdata/book/include_main.yml",
+			},
+		},
+		{
+			"testdata/book/vars_external.yml",
+			[]string{
+				"testdata/book/vars_external.yml",
+				"testdata/vars.json",
+				"testdata/vars_array.json",
+			},
+		},
+		{
+			"testdata/book/needs_2.yml",
+			[]string{
+				"testdata/book/needs_1.yml",
+				"testdata/book/needs_2.yml",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			opn, err := Load(tt.book)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := opn.ReferencedFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, p := range tt.want {
+				abs, err := filepath.Abs(p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want = append(want, abs)
+			}
+			if diff := cmp.Diff(got[tt.book], want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	opn, err := Load("testdata/book/needs_*.yml", RunLabel("needs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]string{}
+	for _, op := range opn.ops {
+		ids[op.bookPath] = op.id
+	}
+	tests := []struct {
+		paths []string
+		want  []string
+	}{
+		{[]string{"testdata/book/needs_2.yml"}, []string{"testdata/book/needs_2.yml"}},
+		{[]string{"testdata/book/needs_1.yml", "testdata/book/needs_2.yml"}, []string{"testdata/book/needs_1.yml", "testdata/book/needs_2.yml"}},
+		{[]string{"testdata/book/include_main.yml"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.paths, ","), func(t *testing.T) {
+			ropn, err := opn.Reload(tt.paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, op := range ropn.ops {
+				got = append(got, op.bookPath)
+				if op.id != ids[op.bookPath] {
+					t.Errorf("got %s, want %s", op.id, ids[op.bookPath])
+				}
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+			if err := ropn.RunN(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if ropn.Result().HasFailure() {
+				t.Error("want no failure")
+			}
+		})
+	}
+}
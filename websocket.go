@@ -0,0 +1,354 @@
+package runn
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+)
+
+type WebSocketOp string
+
+const (
+	WebSocketOpText    WebSocketOp = "text"
+	WebSocketOpBinary  WebSocketOp = "binary"
+	WebSocketOpJSON    WebSocketOp = "json"
+	WebSocketOpReceive WebSocketOp = "receive"
+	WebSocketOpClose   WebSocketOp = "close"
+)
+
+type WebSocketMessageType string
+
+const (
+	WebSocketMessageText   WebSocketMessageType = "text"
+	WebSocketMessageBinary WebSocketMessageType = "binary"
+)
+
+const (
+	wsStoreMessageKey  = "message"
+	wsStoreMessagesKey = "messages"
+	wsStoreResponseKey = "res"
+
+	wsMessageTypeKey = "type"
+	wsMessageDataKey = "data"
+	wsMessageJSONKey = "json"
+)
+
+const wsDefaultReceiveTimeout = 5 * time.Second
+
+// errWSConnectionClosed is returned when a message op is run after the connection is closed in the same step.
+var errWSConnectionClosed = errors.New("connection is closed")
+
+type wsRunner struct {
+	name      string
+	endpoint  string
+	conn      net.Conn
+	rw        io.ReadWriter
+	hostRules hostRules
+	// headers - Headers of the handshake request. They are applied every time the runner connects.
+	headers http.Header
+	// operatorID - The id of the operator for which the runner is defined.
+	operatorID string
+}
+
+type wsMessage struct {
+	op WebSocketOp
+	// data - Payload of text, binary and json. It is expanded at run time.
+	data    any
+	timeout time.Duration
+	match   string
+}
+
+type wsRequest struct {
+	headers  http.Header
+	messages []*wsMessage
+}
+
+// wsReadWriter reads the frames buffered during the handshake first.
+type wsReadWriter struct {
+	io.Reader
+	io.Writer
+}
+
+func newWSRunner(name, endpoint string) (*wsRunner, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("invalid WebSocket endpoint: %s", endpoint)
+	}
+	return &wsRunner{
+		name:     name,
+		endpoint: endpoint,
+	}, nil
+}
+
+func (rnr *wsRunner) Close() error {
+	if rnr.conn == nil {
+		return nil
+	}
+	err := rnr.conn.Close()
+	rnr.conn = nil
+	rnr.rw = nil
+	return err
+}
+
+func (rnr *wsRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	req, err := parseWebSocketRequest(s.wsRequest, s, o.expandBeforeRecord)
+	if err != nil {
+		return fmt.Errorf("invalid WebSocket request: %w", err)
+	}
+	if err := rnr.run(ctx, req, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *wsRunner) run(ctx context.Context, r *wsRequest, s *step) error {
+	o := s.parent
+	o.capturers.captureWebSocketStart(rnr.name, r.headers)
+	defer o.capturers.captureWebSocketEnd(rnr.name)
+
+	if len(r.headers) > 0 {
+		rnr.headers = r.headers
+	}
+	// The connection is kept across steps until `close` or the end of the runbook.
+	if rnr.conn == nil {
+		if err := rnr.connect(ctx, rnr.headers); err != nil {
+			return err
+		}
+		if err := donegroup.Cleanup(ctx, func() error {
+			// In the case of Reused runners, leave the cleanup to the main cleanup
+			if o.id != rnr.operatorID {
+				return nil
+			}
+			return rnr.Close()
+		}); err != nil {
+			return err
+		}
+	}
+
+	d := map[string]any{
+		string(wsStoreMessageKey): nil,
+	}
+	messages := []map[string]any{}
+	for _, m := range r.messages {
+		switch m.op {
+		case WebSocketOpText, WebSocketOpBinary, WebSocketOpJSON:
+			typ, b, err := rnr.encodeMessage(m, s)
+			if err != nil {
+				return err
+			}
+			if err := rnr.send(typ, b); err != nil {
+				return err
+			}
+			o.capturers.captureWebSocketSend(typ, b)
+		case WebSocketOpReceive:
+			received, err := rnr.receive(m, s)
+			messages = append(messages, received...)
+			if err != nil {
+				return err
+			}
+		case WebSocketOpClose:
+			if err := rnr.close(); err != nil {
+				return err
+			}
+			o.capturers.captureWebSocketClose()
+		default:
+			return fmt.Errorf("invalid op: %v", m.op)
+		}
+	}
+	if len(messages) > 0 {
+		d[wsStoreMessageKey] = messages[len(messages)-1]
+	}
+	d[wsStoreMessagesKey] = messages
+
+	o.record(s.idx, map[string]any{
+		string(wsStoreResponseKey): d,
+	})
+	return nil
+}
+
+func (rnr *wsRunner) connect(ctx context.Context, h http.Header) error {
+	d := ws.Dialer{
+		Header: ws.HandshakeHeaderHTTP(h),
+	}
+	if len(rnr.hostRules) > 0 {
+		d.NetDial = rnr.hostRules.dialContextFunc()
+	}
+	conn, br, _, err := d.Dial(ctx, rnr.endpoint)
+	if err != nil {
+		return err
+	}
+	rnr.conn = conn
+	rnr.rw = conn
+	if br != nil {
+		rnr.rw = &wsReadWriter{
+			Reader: io.MultiReader(br, conn),
+			Writer: conn,
+		}
+	}
+	return nil
+}
+
+func (rnr *wsRunner) encodeMessage(m *wsMessage, s *step) (WebSocketMessageType, []byte, error) {
+	o := s.parent
+	// Lazy expand due to the possibility of computing variables between multiple messages.
+	e, err := o.expandBeforeRecord(m.data, s)
+	if err != nil {
+		return "", nil, err
+	}
+	switch m.op {
+	case WebSocketOpText:
+		v, ok := e.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("invalid text message: %v", e)
+		}
+		return WebSocketMessageText, []byte(v), nil
+	case WebSocketOpBinary:
+		v, ok := e.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("invalid binary message: %v", e)
+		}
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid binary message (should be base64 encoded): %w", err)
+		}
+		return WebSocketMessageBinary, b, nil
+	case WebSocketOpJSON:
+		b, err := json.Marshal(e)
+		if err != nil {
+			return "", nil, err
+		}
+		return WebSocketMessageText, b, nil
+	default:
+		return "", nil, fmt.Errorf("invalid op: %v", m.op)
+	}
+}
+
+func (rnr *wsRunner) send(typ WebSocketMessageType, b []byte) error {
+	if rnr.conn == nil {
+		return errWSConnectionClosed
+	}
+	op := ws.OpText
+	if typ == WebSocketMessageBinary {
+		op = ws.OpBinary
+	}
+	return wsutil.WriteClientMessage(rnr.conn, op, b)
+}
+
+// receive receives messages until a message matches the condition.
+// If no condition is specified, it receives only one message.
+func (rnr *wsRunner) receive(m *wsMessage, s *step) ([]map[string]any, error) {
+	o := s.parent
+	if rnr.conn == nil {
+		return nil, errWSConnectionClosed
+	}
+	timeout := m.timeout
+	if timeout == 0 {
+		timeout = wsDefaultReceiveTimeout
+	}
+	if err := rnr.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rnr.conn != nil {
+			_ = rnr.conn.SetReadDeadline(time.Time{})
+		}
+	}()
+	var received []map[string]any
+	for {
+		b, op, err := wsutil.ReadServerData(rnr.rw)
+		if err != nil {
+			var (
+				ne net.Error
+				ce wsutil.ClosedError
+			)
+			switch {
+			case errors.As(err, &ne) && ne.Timeout():
+				if m.match != "" {
+					return received, fmt.Errorf("no message matched %q within %s", m.match, timeout)
+				}
+				return received, fmt.Errorf("no message received within %s", timeout)
+			case errors.As(err, &ce):
+				_ = rnr.Close()
+				return received, fmt.Errorf("connection closed by server: %d %s", ce.Code, ce.Reason)
+			}
+			return received, err
+		}
+		typ := WebSocketMessageText
+		if op == ws.OpBinary {
+			typ = WebSocketMessageBinary
+		}
+		o.capturers.captureWebSocketReceive(typ, b)
+		msg := wsMessageToMap(typ, b)
+		received = append(received, msg)
+		if m.match == "" {
+			return received, nil
+		}
+		sm := o.store.ToMap()
+		sm[store.RootKeyIncluded] = o.included
+		if !s.deferred {
+			sm[store.RootKeyPrevious] = o.store.Latest()
+		}
+		// `current` in `match:` is the received message.
+		sm[store.RootKeyCurrent] = msg
+		tf, err := expr.EvalCond(m.match, sm)
+		if err != nil {
+			return received, err
+		}
+		if tf {
+			return received, nil
+		}
+	}
+}
+
+// close sends a close frame and waits for the close frame from the server.
+func (rnr *wsRunner) close() error {
+	if rnr.conn == nil {
+		return errWSConnectionClosed
+	}
+	if err := wsutil.WriteClientMessage(rnr.conn, ws.OpClose, ws.NewCloseFrameBody(ws.StatusNormalClosure, "")); err != nil {
+		return err
+	}
+	if err := rnr.conn.SetReadDeadline(time.Now().Add(wsDefaultReceiveTimeout)); err != nil {
+		return err
+	}
+	for {
+		// Discard messages received after sending the close frame.
+		if _, _, err := wsutil.ReadServerData(rnr.rw); err != nil {
+			break
+		}
+	}
+	return rnr.Close()
+}
+
+func wsMessageToMap(typ WebSocketMessageType, b []byte) map[string]any {
+	m := map[string]any{
+		wsMessageTypeKey: string(typ),
+	}
+	if typ == WebSocketMessageBinary {
+		m[wsMessageDataKey] = base64.StdEncoding.EncodeToString(b)
+		return m
+	}
+	m[wsMessageDataKey] = string(b)
+	var v any
+	if err := json.Unmarshal(b, &v); err == nil {
+		m[wsMessageJSONKey] = v
+	}
+	return m
+}
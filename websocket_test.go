@@ -0,0 +1,127 @@
+package runn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestWebSocketRunner(t *testing.T) {
+	ctx := context.Background()
+	ts := testutil.WebSocketServer(t)
+	endpoint := strings.Replace(ts.URL, "http://", "ws://", 1)
+	o, err := New(Book("testdata/book/websocket.yml"), WebSocketRunner("ws", endpoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWebSocketRunnerReceive(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []any
+		wantErr  string
+		want     int
+	}{
+		{
+			"receive one message",
+			[]any{map[string]any{"text": "hello"}, "receive"},
+			"",
+			1,
+		},
+		{
+			"receive until matched",
+			[]any{
+				map[string]any{"json": map[string]any{"type": "subscribe", "count": 2}},
+				map[string]any{"receive": map[string]any{"match": `current.json.type == "done"`}},
+			},
+			"",
+			3,
+		},
+		{
+			"timeout",
+			[]any{map[string]any{"receive": map[string]any{"timeout": "100msec"}}},
+			"no message received within 100ms",
+			0,
+		},
+		{
+			"timeout with match",
+			[]any{
+				map[string]any{"text": "ping"},
+				map[string]any{"receive": map[string]any{"timeout": "100msec", "match": `current.data == "never"`}},
+			},
+			`no message matched "current.data == \"never\"" within 100ms`,
+			0,
+		},
+		{
+			"closed by server",
+			[]any{map[string]any{"text": "bye"}, "receive"},
+			"connection closed by server: 1000 bye",
+			0,
+		},
+		{
+			"send after close",
+			[]any{"close", map[string]any{"text": "hello"}},
+			"connection is closed",
+			0,
+		},
+		{
+			"close after close",
+			[]any{"close", "close"},
+			"connection is closed",
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			ts := testutil.WebSocketServer(t)
+			endpoint := strings.Replace(ts.URL, "http://", "ws://", 1)
+			o, err := New(WebSocketRunner("ws", endpoint))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, ok := o.wsRunners["ws"]
+			if !ok {
+				t.Fatal("ws runner not found")
+			}
+			s := newStep(0, "stepKey", o, nil)
+			s.wsRequest = map[string]any{"messages": tt.messages}
+			if err := r.Run(ctx, s); err != nil {
+				if tt.wantErr == "" {
+					t.Fatal(err)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("want error %q", tt.wantErr)
+			}
+			sm := o.store.ToMap()
+			sl, ok := sm["steps"].([]map[string]any)
+			if !ok {
+				t.Fatal("steps not found")
+			}
+			res, ok := sl[0]["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %v", sl[0])
+			}
+			got, ok := res["messages"].([]map[string]any)
+			if !ok {
+				t.Fatalf("invalid messages: %v", res["messages"])
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %v, want %v", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -62,6 +62,19 @@ func CreateHTTPStepMapSlice(key string, req *http.Request) (yaml.MapSlice, error
 				{Key: contentType, Value: nil},
 			}
 		}
+	case isEncodedBodyMediaType(contentType):
+		b, err := io.ReadAll(save)
+		if err != nil {
+			return nil, fmt.Errorf("failed to io.ReadAll: %w", err)
+		}
+		var v any = string(b)
+		// If the body cannot be decoded, it is written as is.
+		if dv, err := decodeBody(contentType, b); err == nil {
+			v = dv
+		}
+		bd = yaml.MapSlice{
+			{Key: contentType, Value: v},
+		}
 	case strings.Contains(contentType, "json"):
 		var v any
 		if err := json.NewDecoder(save).Decode(&v); err != nil {